@@ -0,0 +1,156 @@
+package core
+
+// Alignment は交差軸方向の配置を表します
+//
+// Row では垂直方向、Column では水平方向の配置として解釈されます。
+// Props には Alignment 型と文字列のどちらでも指定できます。
+type Alignment string
+
+const (
+	// AlignStart は先頭（Row では上端、Column では左端）に配置します
+	AlignStart Alignment = "start"
+	// AlignCenter は中央に配置します
+	AlignCenter Alignment = "center"
+	// AlignEnd は末尾（Row では下端、Column では右端）に配置します
+	AlignEnd Alignment = "end"
+)
+
+// Box の中で子ノードを配置する位置を表す2次元の配置です
+// AlignStart、AlignCenter、AlignEnd を Box に指定した場合は両方の軸に同じ配置を適用します
+const (
+	TopStart     Alignment = "topStart"
+	TopCenter    Alignment = "topCenter"
+	TopEnd       Alignment = "topEnd"
+	CenterStart  Alignment = "centerStart"
+	Center       Alignment = "center"
+	CenterEnd    Alignment = "centerEnd"
+	BottomStart  Alignment = "bottomStart"
+	BottomCenter Alignment = "bottomCenter"
+	BottomEnd    Alignment = "bottomEnd"
+)
+
+// Split は2次元の配置を水平方向と垂直方向の配置に分解します
+func (a Alignment) Split() (horizontal, vertical Alignment) {
+	switch a {
+	case TopStart:
+		return AlignStart, AlignStart
+	case TopCenter:
+		return AlignCenter, AlignStart
+	case TopEnd:
+		return AlignEnd, AlignStart
+	case CenterStart:
+		return AlignStart, AlignCenter
+	case CenterEnd:
+		return AlignEnd, AlignCenter
+	case BottomStart:
+		return AlignStart, AlignEnd
+	case BottomCenter:
+		return AlignCenter, AlignEnd
+	case BottomEnd:
+		return AlignEnd, AlignEnd
+	default:
+		return a, a
+	}
+}
+
+// Offset は space の余りがあるときの配置位置のずれを返します
+// "top" と "bottom" はそれぞれ AlignStart と AlignEnd と同じ意味になります
+func (a Alignment) Offset(space float64) float64 {
+	if space <= 0 {
+		return 0
+	}
+	switch a {
+	case AlignCenter:
+		return space / 2
+	case AlignEnd, "bottom":
+		return space
+	default:
+		return 0
+	}
+}
+
+// Arrangement は主軸方向に子ノードを並べる方法を表します
+// Props には Arrangement 型と文字列のどちらでも指定できます。
+type Arrangement string
+
+const (
+	// ArrangeStart は先頭に詰めて並べます
+	ArrangeStart Arrangement = "start"
+	// ArrangeEnd は末尾に詰めて並べます
+	ArrangeEnd Arrangement = "end"
+	// ArrangeCenter は中央に詰めて並べます
+	ArrangeCenter Arrangement = "center"
+	// ArrangeSpaceBetween は最初と最後の子ノードを両端に置き、残りの空きを子ノードの間に均等に分配します
+	ArrangeSpaceBetween Arrangement = "spaceBetween"
+	// ArrangeSpaceAround は各子ノードの前後に同じ空きを置きます（両端の空きは間の半分になります）
+	ArrangeSpaceAround Arrangement = "spaceAround"
+	// ArrangeSpaceEvenly は両端と子ノードの間に同じ空きを置きます
+	ArrangeSpaceEvenly Arrangement = "spaceEvenly"
+)
+
+// Arrange は主軸方向のサイズが sizes の子ノードを mainSize の中に並べたときの各子ノードの位置を返します
+// spacing は子ノードの間に必ず確保する間隔で、空きの分配はその上に加算されます
+func (a Arrangement) Arrange(sizes []float64, mainSize, spacing float64) []float64 {
+	count := len(sizes)
+	positions := make([]float64, count)
+	if count == 0 {
+		return positions
+	}
+
+	total := spacing * float64(count-1)
+	for _, size := range sizes {
+		total += size
+	}
+	free := max(mainSize-total, 0)
+
+	start, gap := 0.0, spacing
+	switch a {
+	case ArrangeEnd:
+		start = free
+	case ArrangeCenter:
+		start = free / 2
+	case ArrangeSpaceBetween:
+		if count > 1 {
+			gap += free / float64(count-1)
+		}
+	case ArrangeSpaceAround:
+		start = free / float64(count) / 2
+		gap += free / float64(count)
+	case ArrangeSpaceEvenly:
+		start = free / float64(count+1)
+		gap += free / float64(count+1)
+	}
+
+	position := start
+	for i, size := range sizes {
+		positions[i] = position
+		position += size + gap
+	}
+	return positions
+}
+
+// GetAlignment は配置のプロパティを取得します
+func (p Props) GetAlignment(key string, defaultValue Alignment) Alignment {
+	if value, exists := p[key]; exists {
+		switch alignment := value.(type) {
+		case Alignment:
+			return alignment
+		case string:
+			return Alignment(alignment)
+		}
+	}
+	return defaultValue
+}
+
+// GetArrangement は並べ方のプロパティを取得します
+func (p Props) GetArrangement(key string, defaultValue Arrangement) Arrangement {
+	if value, exists := p[key]; exists {
+		switch arrangement := value.(type) {
+		case Arrangement:
+			return arrangement
+		case string:
+			return Arrangement(arrangement)
+		}
+	}
+	return defaultValue
+}
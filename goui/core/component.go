@@ -1,5 +1,9 @@
 package core
 
+import (
+	"sync"
+)
+
 // Component はUIコンポーネントのインターフェースです
 type Component interface {
 	// Render はコンポーネントのUIツリーを生成します
@@ -37,6 +41,11 @@ func (fc *FunctionComponent) ShouldUpdate(oldProps, newProps Props) bool {
 }
 
 // StatefulComponent は状態を持つコンポーネントのインターフェースです
+//
+// Composer はマウント時に Initialize、アンマウント時に Cleanup を呼び出しますが、
+// SetState の呼び出しを検知することはできません。SetState で再構成させるには
+// Invalidator を埋め込み（または Invalidatable を実装し）、SetState の中で Invalidate を呼んでください。
+// StateManager の状態を Render 中に読み取る場合は、状態の変更で自動的に再構成されます。
 type StatefulComponent interface {
 	Component
 	
@@ -52,3 +61,36 @@ type StatefulComponent interface {
 	// Cleanup はコンポーネントのクリーンアップを行います
 	Cleanup()
 }
+
+// Invalidatable はランタイムから再構成を要求する関数を受け取るコンポーネントのインターフェースです
+// Composer はマウント時に SetInvalidator を呼び出し、アンマウント時に nil を渡します
+type Invalidatable interface {
+	SetInvalidator(invalidate func())
+}
+
+// Invalidator は StatefulComponent に埋め込んで使う再構成要求のヘルパーです
+// SetState の中で Invalidate を呼ぶと、そのコンポーネントだけが再構成されます
+type Invalidator struct {
+	invalidate func()
+	mutex      sync.Mutex
+}
+
+// SetInvalidator は再構成を要求する関数を設定します
+func (i *Invalidator) SetInvalidator(invalidate func()) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	
+	i.invalidate = invalidate
+}
+
+// Invalidate はコンポーネントの再構成を要求します
+// コンポジションにマウントされていない場合は何もしません
+func (i *Invalidator) Invalidate() {
+	i.mutex.Lock()
+	invalidate := i.invalidate
+	i.mutex.Unlock()
+	
+	if invalidate != nil {
+		invalidate()
+	}
+}
@@ -0,0 +1,490 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Composer はUIツリーのコンポジションを管理するランタイムです
+//
+// コンポーネントの Render 中に読み取られた状態キーを記録し、
+// StateManager.SetState で状態が変化したときに該当するコンポーネントを無効化します。
+// 無効化は Recompose が呼ばれるまでまとめられ、無効になったサブツリーだけが再構成されます。
+// StatefulComponent はマウント時に Initialize、アンマウント時に Cleanup が呼ばれます
+// （ネストしたコンポーネントは親から順に初期化され、子から順にクリーンアップされます）。
+// Render と Recompose はコンポジション用の単一のゴルーチンから呼び出してください。
+type Composer struct {
+	stateManager *StateManager
+	root         *Node
+
+	renderStack []*composeScope
+	readers     map[string]map[*composeScope]struct{}
+	subscribed  map[string]func()
+	invalid     map[*composeScope]struct{}
+	commitFuncs []func(root *Node)
+	signal      chan struct{}
+	stopReads   func()
+	mutex       sync.Mutex
+
+	rememberCount    int
+	pendingEffects   []func()
+	pendingDisposals []func()
+}
+
+// composeScope はコンポジション中のコンポーネントインスタンス1つ分の情報です
+type composeScope struct {
+	composer *Composer
+	node     *Node
+	parent   *composeScope
+	reads    map[string]struct{}
+	slots    slotTable
+	disposed bool
+}
+
+// NewComposer は新しいコンポーザーを作成します
+func NewComposer(stateManager *StateManager) *Composer {
+	c := &Composer{
+		stateManager: stateManager,
+		readers:      make(map[string]map[*composeScope]struct{}),
+		subscribed:   make(map[string]func()),
+		invalid:      make(map[*composeScope]struct{}),
+		signal:       make(chan struct{}, 1),
+	}
+	c.stopReads = stateManager.ObserveReads(c.recordRead)
+	return c
+}
+
+// NewComponentNode はコンポーネントを持つカスタムノードを作成します
+func NewComponentNode(key string, component Component, props Props) *Node {
+	node := NewNode(CustomNodeType, key, props)
+	node.Component = component
+	return node
+}
+
+// SetContent はルートノードを設定してコンポジションを実行し、構成済みのツリーを返します
+//
+// 以前のコンテンツがある場合は Key と Type に基づいて対応付けられ、
+// 一致したコンポーネントのインスタンスは引き継がれます。
+func (c *Composer) SetContent(root *Node) *Node {
+	old := c.root
+	if old != nil && (root == nil || !sameIdentity(old, root)) {
+		c.disposeTree(old)
+		old = nil
+	}
+
+	c.root = root
+	if root != nil {
+		c.composeNode(root, old, nil, nil)
+	}
+
+	c.commit()
+	return root
+}
+
+// Root は構成済みのルートノードを返します
+func (c *Composer) Root() *Node {
+	return c.root
+}
+
+// OnCommit はコンポジションが確定するたびに呼ばれる関数を登録します
+func (c *Composer) OnCommit(listener func(root *Node)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.commitFuncs = append(c.commitFuncs, listener)
+}
+
+// HasPendingChanges は再構成待ちの無効化があるかを返します
+func (c *Composer) HasPendingChanges() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.invalid) > 0
+}
+
+// Invalidated は無効化が発生したときに通知を受け取るチャネルを返します
+func (c *Composer) Invalidated() <-chan struct{} {
+	return c.signal
+}
+
+// Recompose は無効化されたコンポーネントのサブツリーだけを再構成します
+// 再構成が行われた場合は true を返します
+//
+// 取り出した無効なスコープはレンダリングされた時点で pending から取り除かれます。
+// 祖先の再構成で再利用された部分木の中に残ったスコープも、外側から順に再構成されます。
+func (c *Composer) Recompose() bool {
+	c.mutex.Lock()
+	pending := c.invalid
+	c.invalid = make(map[*composeScope]struct{})
+	c.mutex.Unlock()
+
+	recomposed := false
+	for len(pending) > 0 {
+		for scope := range pending {
+			if scope.disposed {
+				delete(pending, scope)
+				continue
+			}
+			if hasInvalidAncestor(scope, pending) {
+				// 祖先が無効な場合は祖先の再構成に含まれる
+				continue
+			}
+			c.recomposeScope(scope, pending)
+			recomposed = true
+		}
+	}
+
+	if recomposed {
+		c.commit()
+	}
+	return recomposed
+}
+
+// Run はフレーム間隔ごとに Recompose を実行し、コンテキストが終了するまでブロックします
+// 1フレームの間に発生した無効化はまとめて処理されます
+func (c *Composer) Run(ctx context.Context, frameInterval time.Duration) error {
+	ticker := time.NewTicker(frameInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			c.Recompose()
+		}
+	}
+}
+
+// Dispose はコンポジションを破棄し、状態の監視を解除します
+func (c *Composer) Dispose() {
+	if c.root != nil {
+		c.disposeTree(c.root)
+		c.root = nil
+	}
+
+	// 開始前の副作用は実行せず、予約された停止だけを実行する
+	c.mutex.Lock()
+	c.pendingEffects = nil
+	c.mutex.Unlock()
+	c.runDisposals()
+
+	c.mutex.Lock()
+	subscribed := c.subscribed
+	c.subscribed = make(map[string]func())
+	c.invalid = make(map[*composeScope]struct{})
+	c.mutex.Unlock()
+
+	for _, unsubscribe := range subscribed {
+		if unsubscribe != nil {
+			unsubscribe()
+		}
+	}
+	c.stopReads()
+}
+
+// composeNode は新しいノードを古いノードと対応付けながら構成します
+// pending は Recompose で取り出した無効なスコープで、これに含まれるスコープも再レンダリングされます
+func (c *Composer) composeNode(node, old *Node, parentScope *composeScope, pending map[*composeScope]struct{}) {
+	if old != nil {
+		adoptComponent(old, node)
+	}
+
+	if node.Component == nil {
+		if old != nil && old.scope != nil {
+			c.disposeTree(old)
+			old = nil
+		}
+		node.composer = c
+		node.ownerScope = parentScope
+		if old != nil && old.subcomposition != nil {
+			// レイアウト中に構成された子ノードはサブコンポジションごと引き継ぐ
+			old.subcomposition.adopt(old, node, parentScope)
+			return
+		}
+		node.subcomposition = nil
+		c.composeChildren(node, old, parentScope, pending)
+		return
+	}
+
+	// 同じコンポーネントインスタンスであればスコープを引き継ぐ
+	var scope *composeScope
+	reused := old != nil && old.scope != nil && sameComponent(old.Component, node.Component)
+	if reused {
+		scope = old.scope
+	} else {
+		if old != nil {
+			c.disposeTree(old)
+			old = nil
+		}
+		scope = &composeScope{composer: c, reads: make(map[string]struct{})}
+	}
+	scope.node = node
+	scope.parent = parentScope
+	node.scope = scope
+	if !reused {
+		c.mount(scope)
+	}
+
+	// プロパティが変わらず無効化もされていなければ前回の結果を再利用する
+	if old != nil && !c.isInvalid(scope, pending) && !node.Component.ShouldUpdate(old.Props, node.Props) {
+		node.Children = old.Children
+		for _, child := range node.Children {
+			child.Parent = node
+		}
+		return
+	}
+
+	var oldContent *Node
+	if old != nil && len(old.Children) > 0 {
+		oldContent = old.Children[0]
+	}
+	c.composeContent(scope, oldContent, pending)
+}
+
+// composeChildren は子ノードを古い子ノードと対応付けながら構成します
+func (c *Composer) composeChildren(node, old *Node, parentScope *composeScope, pending map[*composeScope]struct{}) {
+	var oldChildren []*Node
+	if old != nil {
+		oldChildren = old.Children
+	}
+
+	matches := matchChildren(oldChildren, node.Children)
+	matched := make([]bool, len(oldChildren))
+	for i, child := range node.Children {
+		var oldChild *Node
+		if matches[i] >= 0 {
+			oldChild = oldChildren[matches[i]]
+			matched[matches[i]] = true
+		}
+		child.Parent = node
+		c.composeNode(child, oldChild, parentScope, pending)
+	}
+
+	for i, oldChild := range oldChildren {
+		if !matched[i] {
+			c.disposeTree(oldChild)
+		}
+	}
+}
+
+// composeContent はスコープのコンポーネントをレンダリングし、結果を子ノードとして構成します
+func (c *Composer) composeContent(scope *composeScope, oldContent *Node, pending map[*composeScope]struct{}) {
+	node := scope.node
+	delete(pending, scope)
+	content := c.renderScope(scope)
+
+	if oldContent != nil && (content == nil || !sameIdentity(oldContent, content)) {
+		c.disposeTree(oldContent)
+		oldContent = nil
+	}
+
+	if content == nil {
+		node.Children = []*Node{}
+		return
+	}
+
+	content.Parent = node
+	node.Children = []*Node{content}
+	c.composeNode(content, oldContent, scope, pending)
+}
+
+// recomposeScope は無効化されたスコープを再構成します
+func (c *Composer) recomposeScope(scope *composeScope, pending map[*composeScope]struct{}) {
+	var oldContent *Node
+	if len(scope.node.Children) > 0 {
+		oldContent = scope.node.Children[0]
+	}
+	c.composeContent(scope, oldContent, pending)
+}
+
+// renderScope は読み取った状態を記録しながらコンポーネントをレンダリングします
+func (c *Composer) renderScope(scope *composeScope) *Node {
+	c.mutex.Lock()
+	delete(c.invalid, scope)
+	previousReads := scope.reads
+	scope.reads = make(map[string]struct{})
+	c.renderStack = append(c.renderStack, scope)
+	c.mutex.Unlock()
+
+	scope.slots.begin()
+	props, release := scope.renderProps()
+	content := scope.node.Component.Render(props)
+	release()
+	scope.slots.end()
+
+	c.mutex.Lock()
+	c.renderStack = c.renderStack[:len(c.renderStack)-1]
+	for key := range previousReads {
+		if _, stillRead := scope.reads[key]; !stillRead {
+			c.removeReader(key, scope)
+		}
+	}
+	newKeys := []string{}
+	for key := range scope.reads {
+		if c.readers[key] == nil {
+			c.readers[key] = make(map[*composeScope]struct{})
+		}
+		c.readers[key][scope] = struct{}{}
+		if _, ok := c.subscribed[key]; !ok {
+			c.subscribed[key] = nil
+			newKeys = append(newKeys, key)
+		}
+	}
+	c.mutex.Unlock()
+
+	// 初めて読まれたキーの変更を監視する
+	for _, key := range newKeys {
+		stateKey := key
+		unsubscribe := c.stateManager.Subscribe(stateKey, func(oldState, newState interface{}) {
+			c.invalidateKey(stateKey)
+		})
+		c.mutex.Lock()
+		c.subscribed[stateKey] = unsubscribe
+		c.mutex.Unlock()
+	}
+
+	return content
+}
+
+// recordRead は Render 中の状態の読み取りを記録します
+func (c *Composer) recordRead(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.renderStack) == 0 {
+		return
+	}
+	c.renderStack[len(c.renderStack)-1].reads[key] = struct{}{}
+}
+
+// invalidateKey は状態キーを読み取ったすべてのスコープを無効化します
+func (c *Composer) invalidateKey(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for scope := range c.readers[key] {
+		c.invalidateLocked(scope)
+	}
+}
+
+// invalidateScope はスコープを無効化します
+func (c *Composer) invalidateScope(scope *composeScope) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.invalidateLocked(scope)
+}
+
+// invalidateLocked はスコープを無効化します（ロック取得済みで呼び出すこと）
+func (c *Composer) invalidateLocked(scope *composeScope) {
+	if scope.disposed {
+		return
+	}
+	c.invalid[scope] = struct{}{}
+
+	select {
+	case c.signal <- struct{}{}:
+	default:
+	}
+}
+
+// isInvalid はスコープが無効化されているか、再構成待ちの pending に含まれているかを返します
+func (c *Composer) isInvalid(scope *composeScope, pending map[*composeScope]struct{}) bool {
+	if _, ok := pending[scope]; ok {
+		return true
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, invalid := c.invalid[scope]
+	return invalid
+}
+
+// removeReader はスコープを状態キーの読み取り元から外します（ロック取得済みで呼び出すこと）
+func (c *Composer) removeReader(key string, scope *composeScope) {
+	readers := c.readers[key]
+	delete(readers, scope)
+	if len(readers) == 0 {
+		delete(c.readers, key)
+	}
+}
+
+// disposeTree はツリーから外れたノード以下のスコープを破棄します
+// レイアウト中に構成された子ノードは、表示されていないスロットも含めて破棄します
+func (c *Composer) disposeTree(node *Node) {
+	if node.subcomposition != nil && node.subcomposition.node == node {
+		node.subcomposition.dispose()
+	} else {
+		for _, child := range node.Children {
+			c.disposeTree(child)
+		}
+	}
+
+	scope := node.scope
+	if scope == nil || scope.node != node || scope.disposed {
+		return
+	}
+
+	c.mutex.Lock()
+	scope.disposed = true
+	for key := range scope.reads {
+		c.removeReader(key, scope)
+	}
+	delete(c.invalid, scope)
+	c.mutex.Unlock()
+
+	// 保持していた値を破棄
+	scope.slots.disposeAll()
+
+	c.unmount(scope)
+}
+
+// mount は新しくツリーに追加されたコンポーネントを初期化します
+func (c *Composer) mount(scope *composeScope) {
+	component := scope.node.Component
+	if invalidatable, ok := component.(Invalidatable); ok {
+		invalidatable.SetInvalidator(func() {
+			c.invalidateScope(scope)
+		})
+	}
+	if stateful, ok := component.(StatefulComponent); ok {
+		stateful.Initialize(scope.node.Props)
+	}
+}
+
+// unmount はツリーから外れたコンポーネントのクリーンアップを行います
+func (c *Composer) unmount(scope *composeScope) {
+	component := scope.node.Component
+	if stateful, ok := component.(StatefulComponent); ok {
+		stateful.Cleanup()
+	}
+	if invalidatable, ok := component.(Invalidatable); ok {
+		invalidatable.SetInvalidator(nil)
+	}
+}
+
+// commit は予約された副作用を実行し、コンポジションの確定をリスナーに通知します
+func (c *Composer) commit() {
+	c.runEffects()
+
+	c.mutex.Lock()
+	listeners := c.commitFuncs
+	c.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener(c.root)
+	}
+}
+
+// hasInvalidAncestor はスコープの祖先に無効なスコープがあるかを返します
+func hasInvalidAncestor(scope *composeScope, invalid map[*composeScope]struct{}) bool {
+	for ancestor := scope.parent; ancestor != nil; ancestor = ancestor.parent {
+		if _, ok := invalid[ancestor]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package core
+
+import (
+	"testing"
+)
+
+// textOf はコンポーネントノードの下にある最初の Text ノードの text を返します
+func textOf(node *Node) string {
+	if node == nil {
+		return ""
+	}
+	if node.Type == TextNodeType {
+		return node.Props.GetString("text", "")
+	}
+	for _, child := range node.Children {
+		if text := textOf(child); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+func TestRecomposeNestedScopesInvalidatedInSameFrame(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("parent", "p0")
+	manager.SetState("child", "c0")
+	composer := NewComposer(manager)
+	defer composer.Dispose()
+
+	childRenders := 0
+	child := func(props Props) *Node {
+		childRenders++
+		return NewNode(TextNodeType, "text", Props{"text": manager.GetState("child")})
+	}
+	parent := func(props Props) *Node {
+		column := NewNode(ColumnNodeType, "column", Props{})
+		column.AddChild(NewNode(TextNodeType, "label", Props{"text": manager.GetState("parent")}))
+		column.AddChild(NewComponentNode("child", NewFunctionComponent(child), Props{}))
+		return column
+	}
+
+	root := composer.SetContent(NewComponentNode("parent", NewFunctionComponent(parent), Props{}))
+	childNode := root.Children[0].Children[1]
+	if got := textOf(childNode); got != "c0" {
+		t.Fatalf("initial child text = %q, want c0", got)
+	}
+
+	// 親と子を同じフレームで無効化する
+	manager.SetState("parent", "p1")
+	manager.SetState("child", "c1")
+	if !composer.Recompose() {
+		t.Fatal("Recompose returned false")
+	}
+
+	childNode = composer.Root().Children[0].Children[1]
+	if got := textOf(childNode); got != "c1" {
+		t.Errorf("child text = %q, want c1", got)
+	}
+	if childRenders != 2 {
+		t.Errorf("child rendered %d times, want 2", childRenders)
+	}
+	if composer.HasPendingChanges() {
+		t.Error("invalidations remain after Recompose")
+	}
+}
+
+func TestRecomposeScopeInsideReusedSubtree(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("parent", 0)
+	manager.SetState("leaf", "l0")
+	composer := NewComposer(manager)
+	defer composer.Dispose()
+
+	// 親 → 中間 → 葉 の順にネストし、中間のコンポーネントはプロパティが同じなので再利用される
+	leaf := func(props Props) *Node {
+		return NewNode(TextNodeType, "text", Props{"text": manager.GetState("leaf")})
+	}
+	middle := func(props Props) *Node {
+		return NewComponentNode("leaf", NewFunctionComponent(leaf), Props{})
+	}
+	parent := func(props Props) *Node {
+		manager.GetState("parent")
+		return NewComponentNode("middle", NewFunctionComponent(middle), Props{})
+	}
+
+	composer.SetContent(NewComponentNode("parent", NewFunctionComponent(parent), Props{}))
+
+	manager.SetState("parent", 1)
+	manager.SetState("leaf", "l1")
+	composer.Recompose()
+
+	if got := textOf(composer.Root()); got != "l1" {
+		t.Errorf("leaf text = %q, want l1", got)
+	}
+}
+
+// lifecycleComponent はマウントとアンマウントを記録する StatefulComponent です
+type lifecycleComponent struct {
+	Invalidator
+	name    string
+	log     *[]string
+	state   interface{}
+	content func(state interface{}) *Node
+}
+
+func (l *lifecycleComponent) Render(props Props) *Node {
+	return l.content(l.state)
+}
+
+func (l *lifecycleComponent) ShouldUpdate(oldProps, newProps Props) bool {
+	return !oldProps.Equal(newProps)
+}
+
+func (l *lifecycleComponent) GetState() interface{} {
+	return l.state
+}
+
+func (l *lifecycleComponent) SetState(newState interface{}) {
+	l.state = newState
+	l.Invalidate()
+}
+
+func (l *lifecycleComponent) Initialize(props Props) {
+	*l.log = append(*l.log, "init "+l.name)
+}
+
+func (l *lifecycleComponent) Cleanup() {
+	*l.log = append(*l.log, "cleanup "+l.name)
+}
+
+// takeLog は記録を返して空にします
+func takeLog(log *[]string) []string {
+	entries := *log
+	*log = nil
+	return entries
+}
+
+func expectLog(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("log = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("log = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestStatefulLifecycleOrderForNestedComponents(t *testing.T) {
+	log := []string{}
+	composer := NewComposer(NewStateManager())
+
+	// parent は state のキーごとに子を並べ、各子はさらに孫を1つ持つ
+	leaf := func(name string) *Node {
+		return NewComponentNode(name, &lifecycleComponent{
+			name: name,
+			log:  &log,
+			content: func(interface{}) *Node {
+				return NewNode(TextNodeType, "text", Props{"text": name})
+			},
+		}, Props{})
+	}
+	child := func(name string) *Node {
+		return NewComponentNode(name, &lifecycleComponent{
+			name: name,
+			log:  &log,
+			content: func(interface{}) *Node {
+				return leaf(name + "/leaf")
+			},
+		}, Props{})
+	}
+	parent := &lifecycleComponent{
+		name:  "parent",
+		log:   &log,
+		state: []string{"a", "b", "c"},
+		content: func(state interface{}) *Node {
+			column := NewNode(ColumnNodeType, "column", Props{})
+			for _, key := range state.([]string) {
+				column.AddChild(child(key))
+			}
+			return column
+		},
+	}
+
+	composer.SetContent(NewComponentNode("parent", parent, Props{}))
+	expectLog(t, takeLog(&log),
+		"init parent",
+		"init a", "init a/leaf",
+		"init b", "init b/leaf",
+		"init c", "init c/leaf",
+	)
+
+	// 再構成で key の付いた子を削除すると、孫から先にクリーンアップされる
+	parent.SetState([]string{"a", "c"})
+	composer.Recompose()
+	expectLog(t, takeLog(&log), "cleanup b/leaf", "cleanup b")
+
+	// 残った子は引き継がれ、新しい子だけが初期化される
+	parent.SetState([]string{"c", "d", "a"})
+	composer.Recompose()
+	expectLog(t, takeLog(&log), "init d", "init d/leaf")
+
+	composer.Dispose()
+	expectLog(t, takeLog(&log),
+		"cleanup c/leaf", "cleanup c",
+		"cleanup d/leaf", "cleanup d",
+		"cleanup a/leaf", "cleanup a",
+		"cleanup parent",
+	)
+}
+
+func TestStatefulSetStateRecomposesThroughInvalidator(t *testing.T) {
+	log := []string{}
+	composer := NewComposer(NewStateManager())
+	defer composer.Dispose()
+
+	component := &lifecycleComponent{
+		name:  "counter",
+		log:   &log,
+		state: "0",
+		content: func(state interface{}) *Node {
+			return NewNode(TextNodeType, "text", Props{"text": state})
+		},
+	}
+	composer.SetContent(NewComponentNode("counter", component, Props{}))
+
+	component.SetState("1")
+	if !composer.HasPendingChanges() {
+		t.Fatal("SetState did not invalidate the component")
+	}
+	composer.Recompose()
+	if got := textOf(composer.Root()); got != "1" {
+		t.Errorf("text = %q, want 1", got)
+	}
+
+	// アンマウント後の SetState は再構成を要求しない
+	composer.SetContent(NewNode(ColumnNodeType, "empty", Props{}))
+	component.SetState("2")
+	if composer.HasPendingChanges() {
+		t.Error("unmounted component was invalidated")
+	}
+}
+
+func TestRecomposePassesChangedCallbackToChild(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("count", 0)
+	composer := NewComposer(manager)
+	defer composer.Dispose()
+
+	// 子は受け取った onClick をそのまま Text に渡すだけで、状態は読まない
+	child := func(props Props) *Node {
+		onClick, _ := props.Get("onClick")
+		return NewNode(TextNodeType, "button", Props{"onClick": onClick})
+	}
+	clicked := -1
+	parent := func(props Props) *Node {
+		count := manager.GetState("count").(int)
+		return NewComponentNode("child", NewFunctionComponent(child), Props{
+			"onClick": func() { clicked = count },
+		})
+	}
+	composer.SetContent(NewComponentNode("parent", NewFunctionComponent(parent), Props{}))
+
+	manager.SetState("count", 5)
+	composer.Recompose()
+
+	button := composer.Root().Children[0].Children[0]
+	onClick, ok := button.Props["onClick"].(func())
+	if !ok {
+		t.Fatalf("button onClick = %T, want func()", button.Props["onClick"])
+	}
+	onClick()
+	if clicked != 5 {
+		t.Errorf("click handler saw %d, want 5", clicked)
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+)
+
+// effect はコンポジションに結び付いた副作用です
+type effect struct {
+	start   func()
+	stop    func()
+	stopped bool
+}
+
+// launch はコミット後に副作用を開始します
+func (e *effect) launch() {
+	if e.stopped {
+		return
+	}
+	e.start()
+}
+
+// dispose は副作用を停止します
+func (e *effect) dispose() {
+	if e.stopped {
+		return
+	}
+	e.stopped = true
+	if e.stop != nil {
+		e.stop()
+	}
+}
+
+// LaunchedEffect はコミット後にゴルーチンで block を実行します
+//
+// keys が前回の Render と異なる場合は実行中の block のコンテキストをキャンセルしてから再実行します。
+// ノードがツリーから外れたときもコンテキストはキャンセルされます。
+// keys を指定しない場合、block はノードがツリーにある間に一度だけ実行されます。
+func LaunchedEffect(props Props, block func(ctx context.Context), keys ...interface{}) {
+	rememberEffect(props, keys, func() *effect {
+		ctx, cancel := context.WithCancel(context.Background())
+		return &effect{
+			start: func() {
+				go block(ctx)
+			},
+			stop: cancel,
+		}
+	})
+}
+
+// DisposableEffect はコミット後に block を実行し、返されたクリーンアップ関数を保持します
+//
+// keys が前回の Render と異なる場合、またはノードがツリーから外れた場合に
+// クリーンアップ関数が呼ばれます（keys が変わった場合はその後で block が再実行されます）。
+func DisposableEffect(props Props, block func() (cleanup func()), keys ...interface{}) {
+	rememberEffect(props, keys, func() *effect {
+		e := &effect{}
+		e.start = func() {
+			e.stop = block()
+		}
+		return e
+	})
+}
+
+// SideEffect は Render が行われるたびにコミット後に block を実行します
+func SideEffect(props Props, block func()) {
+	scope := currentScope(props)
+	if scope == nil {
+		return
+	}
+	scope.composer.enqueueEffect(block)
+}
+
+// rememberEffect は副作用を呼び出し位置に保持し、新しく作られた場合は開始を予約します
+//
+// keys の変更やノードの削除で副作用が忘れられても、その場では停止せずに停止を予約します。
+// 予約された停止はコミット後に新しい副作用の開始より先に実行されるため、
+// 確定しなかった Render で古い副作用が止まることはありません。
+func rememberEffect(props Props, keys []interface{}, create func() *effect) {
+	scope := currentScope(props)
+	if scope == nil {
+		// Composer の外では副作用を実行しない
+		return
+	}
+
+	schedule := func() (interface{}, func()) {
+		e := create()
+		scope.composer.enqueueEffect(e.launch)
+		return e, func() {
+			scope.composer.enqueueDisposal(e.dispose)
+		}
+	}
+	s := scope.slots.next(keys, schedule)
+
+	if _, ok := s.value.(*effect); !ok {
+		// 呼び出し順が変わって種類が一致しない場合は作り直す
+		s.forget()
+		s.value, s.dispose = schedule()
+	}
+}
+
+// enqueueEffect はコミット後に実行する副作用を予約します
+func (c *Composer) enqueueEffect(block func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.pendingEffects = append(c.pendingEffects, block)
+}
+
+// enqueueDisposal はコミット後に実行する副作用の停止を予約します
+func (c *Composer) enqueueDisposal(dispose func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.pendingDisposals = append(c.pendingDisposals, dispose)
+}
+
+// runEffects は予約された副作用の停止を行ってから、予約された副作用を登録順に実行します
+func (c *Composer) runEffects() {
+	c.runDisposals()
+
+	c.mutex.Lock()
+	effects := c.pendingEffects
+	c.pendingEffects = nil
+	c.mutex.Unlock()
+
+	for _, block := range effects {
+		block()
+	}
+}
+
+// runDisposals は予約された副作用の停止を登録順に実行します
+func (c *Composer) runDisposals() {
+	c.mutex.Lock()
+	disposals := c.pendingDisposals
+	c.pendingDisposals = nil
+	c.mutex.Unlock()
+
+	for _, dispose := range disposals {
+		dispose()
+	}
+}
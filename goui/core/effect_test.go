@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// effectHost は key の状態を読み、その値をキーにした DisposableEffect を持つコンポーネントを作成します
+// Render の記録は DisposableEffect の呼び出しの後に行い、Render 中に停止が走っていないことを確かめます
+func effectHost(manager *StateManager, log *[]string) *Node {
+	host := func(props Props) *Node {
+		key := manager.GetState("key").(string)
+		DisposableEffect(props, func() func() {
+			*log = append(*log, "start "+key)
+			return func() {
+				*log = append(*log, "cleanup "+key)
+			}
+		}, key)
+		*log = append(*log, "render "+key)
+		return NewNode(TextNodeType, "text", Props{"text": key})
+	}
+	return NewComponentNode("host", NewFunctionComponent(host), Props{})
+}
+
+func TestDisposableEffectRunsAfterCommit(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("key", "a")
+	composer := NewComposer(manager)
+	defer composer.Dispose()
+
+	log := []string{}
+	composer.OnCommit(func(*Node) {
+		log = append(log, "commit")
+	})
+	composer.SetContent(effectHost(manager, &log))
+	expectLog(t, takeLog(&log), "render a", "start a", "commit")
+}
+
+func TestDisposableEffectRestartsOnKeyChange(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("key", "a")
+	manager.SetState("other", 0)
+	composer := NewComposer(manager)
+	defer composer.Dispose()
+
+	log := []string{}
+	composer.SetContent(effectHost(manager, &log))
+	takeLog(&log)
+
+	// 古い副作用の停止は Render の中ではなくコミット後に行われる
+	manager.SetState("key", "b")
+	composer.Recompose()
+	expectLog(t, takeLog(&log), "render b", "cleanup a", "start b")
+
+	// キーが同じ間は再実行も停止もされない
+	manager.SetState("key", "b")
+	composer.Recompose()
+	for _, entry := range takeLog(&log) {
+		if entry != "render b" {
+			t.Errorf("unexpected %q after recomposing with the same key", entry)
+		}
+	}
+}
+
+func TestDisposableEffectCleanedUpOnUnmount(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("key", "a")
+	composer := NewComposer(manager)
+
+	log := []string{}
+	root := func(withHost bool) *Node {
+		column := NewNode(ColumnNodeType, "root", Props{})
+		if withHost {
+			column.AddChild(effectHost(manager, &log))
+		}
+		return column
+	}
+	composer.SetContent(root(true))
+	takeLog(&log)
+
+	composer.SetContent(root(false))
+	expectLog(t, takeLog(&log), "cleanup a")
+
+	composer.Dispose()
+	expectLog(t, takeLog(&log))
+}
+
+func TestDisposableEffectCleanedUpOnDispose(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("key", "a")
+	composer := NewComposer(manager)
+
+	log := []string{}
+	composer.SetContent(effectHost(manager, &log))
+	takeLog(&log)
+
+	composer.Dispose()
+	expectLog(t, takeLog(&log), "cleanup a")
+}
+
+// waitDone はコンテキストがキャンセルされるまで待ちます
+func waitDone(t *testing.T, ctx context.Context, what string) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("context was not cancelled on %s", what)
+	}
+}
+
+func TestLaunchedEffectCancelsContext(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("key", "a")
+	composer := NewComposer(manager)
+
+	launched := make(chan context.Context, 4)
+	host := func(props Props) *Node {
+		key := manager.GetState("key").(string)
+		LaunchedEffect(props, func(ctx context.Context) {
+			launched <- ctx
+			<-ctx.Done()
+		}, key)
+		return NewNode(TextNodeType, "text", Props{"text": key})
+	}
+	composer.SetContent(NewComponentNode("host", NewFunctionComponent(host), Props{}))
+	first := <-launched
+
+	manager.SetState("key", "b")
+	composer.Recompose()
+	waitDone(t, first, "key change")
+	second := <-launched
+	if second.Err() != nil {
+		t.Error("restarted effect has a cancelled context")
+	}
+
+	composer.Dispose()
+	waitDone(t, second, "Dispose")
+}
+
+func TestSideEffectRunsOncePerComposition(t *testing.T) {
+	manager := NewStateManager()
+	manager.SetState("count", 0)
+	composer := NewComposer(manager)
+	defer composer.Dispose()
+
+	runs := 0
+	host := func(props Props) *Node {
+		count := manager.GetState("count")
+		SideEffect(props, func() {
+			runs++
+		})
+		return NewNode(TextNodeType, "text", Props{"text": count})
+	}
+	composer.SetContent(NewComponentNode("host", NewFunctionComponent(host), Props{}))
+	if runs != 1 {
+		t.Fatalf("runs after SetContent = %d, want 1", runs)
+	}
+
+	manager.SetState("count", 1)
+	composer.Recompose()
+	if runs != 2 {
+		t.Errorf("runs after Recompose = %d, want 2", runs)
+	}
+
+	// 無効化がなければ再構成も副作用の実行も行われない
+	composer.Recompose()
+	if runs != 2 {
+		t.Errorf("runs after an empty Recompose = %d, want 2", runs)
+	}
+}
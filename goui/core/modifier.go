@@ -0,0 +1,288 @@
+package core
+
+// ModifierKey は Props にモディファイアを格納するためのキーです
+const ModifierKey = "modifier"
+
+// Unspecified はサイズなどの値が指定されていないことを表します
+const Unspecified = -1.0
+
+// ModifierElement はモディファイアチェーンを構成する要素です
+//
+// レイアウト用の要素（PaddingModifier, SizeModifier など）は layout パッケージが、
+// 描画用の要素（BackgroundModifier, BorderModifier など）は render パッケージが解釈します。
+type ModifierElement interface {
+	isModifierElement()
+}
+
+// PaddingModifier は内側の要素の周囲に余白を追加します
+type PaddingModifier struct {
+	Start  float64
+	Top    float64
+	End    float64
+	Bottom float64
+}
+
+// SizeModifier は内側の要素のサイズを固定します
+// Unspecified（負の値）の軸は制約をそのまま引き継ぎます
+type SizeModifier struct {
+	Width  float64
+	Height float64
+}
+
+// BackgroundModifier はこの位置の領域を背景色で塗りつぶします
+type BackgroundModifier struct {
+	Color        string
+	CornerRadius float64
+}
+
+// BorderModifier はこの位置の領域に枠線を描画します
+type BorderModifier struct {
+	Width        float64
+	Color        string
+	CornerRadius float64
+}
+
+// ClickableModifier はこの位置の領域をクリック可能にします
+type ClickableModifier struct {
+	OnClick func()
+}
+
+// WeightModifier は Row や Column の中で、残りの空きを重みに応じて割り当てます
+// Fill が true の場合は割り当てられたサイズいっぱいに広がり、false の場合はそれを上限とします
+// 親のレイアウトが解釈する要素で、ノード自身のレイアウトには影響しません
+type WeightModifier struct {
+	Weight float64
+	Fill   bool
+}
+
+// AlignModifier は親の交差軸方向の配置を、この子ノードについてだけ上書きします
+// 親のレイアウトが解釈する要素で、ノード自身のレイアウトには影響しません
+type AlignModifier struct {
+	Alignment Alignment
+}
+
+// IntrinsicSize は固有サイズの最小値と最大値のどちらを使うかを表します
+type IntrinsicSize int
+
+const (
+	// IntrinsicUnspecified は固有サイズを使用しないことを表します
+	IntrinsicUnspecified IntrinsicSize = iota
+	// IntrinsicMin は最小固有サイズ（内容を崩さずに縮められる最小のサイズ）を使用します
+	IntrinsicMin
+	// IntrinsicMax は最大固有サイズ（内容を折り返さずに表示できるサイズ）を使用します
+	IntrinsicMax
+)
+
+// IntrinsicSizeModifier は内側の要素の固有サイズに合わせてサイズを固定します
+// 例えば Row に IntrinsicHeight(IntrinsicMin) を指定すると、最も高い子ノードの高さに揃い、
+// FillMaxHeight を指定した区切り線がその高さいっぱいに広がります
+type IntrinsicSizeModifier struct {
+	Width  IntrinsicSize
+	Height IntrinsicSize
+}
+
+// FillModifier は内側の要素を最大サイズに対する割合で広げます
+// 0 以下の軸は制約をそのまま引き継ぎます
+type FillModifier struct {
+	WidthFraction  float64
+	HeightFraction float64
+}
+
+// GridCellModifier は Grid の中で子ノードを配置するセル（0 から始まる行と列の番号）を指定します
+// 指定しない子ノードは空いているセルに行方向の順で自動的に配置されます
+// 親のレイアウトが解釈する要素で、ノード自身のレイアウトには影響しません
+type GridCellModifier struct {
+	Row    int
+	Column int
+}
+
+// GridSpanModifier は Grid の中で子ノードが占める行と列の数を指定します
+// 親のレイアウトが解釈する要素で、ノード自身のレイアウトには影響しません
+type GridSpanModifier struct {
+	RowSpan    int
+	ColumnSpan int
+}
+
+// LayoutIDModifier は親のレイアウトが子ノードを識別するための ID を指定します
+// ConstraintLayout はこの ID で制約の参照と子ノードを対応付けます
+type LayoutIDModifier struct {
+	ID string
+}
+
+func (PaddingModifier) isModifierElement()       {}
+func (SizeModifier) isModifierElement()          {}
+func (BackgroundModifier) isModifierElement()    {}
+func (BorderModifier) isModifierElement()        {}
+func (ClickableModifier) isModifierElement()     {}
+func (WeightModifier) isModifierElement()        {}
+func (AlignModifier) isModifierElement()         {}
+func (IntrinsicSizeModifier) isModifierElement() {}
+func (FillModifier) isModifierElement()          {}
+func (GridCellModifier) isModifierElement()      {}
+func (GridSpanModifier) isModifierElement()      {}
+func (LayoutIDModifier) isModifierElement()      {}
+
+// Modifier はノードの装飾や振る舞いを順序付きで連結したチェーンです
+//
+// 要素は外側から内側の順に適用されます。例えば
+// NewModifier().Padding(1).Background("#FFF") は余白の内側だけを塗りつぶし、
+// NewModifier().Background("#FFF").Padding(1) は余白を含めた領域を塗りつぶします。
+// Modifier は不変で、各メソッドは新しいチェーンを返します。
+type Modifier struct {
+	elements []ModifierElement
+}
+
+// NewModifier は空のモディファイアを作成します
+func NewModifier() Modifier {
+	return Modifier{}
+}
+
+// Then は別のモディファイアを内側に連結します
+func (m Modifier) Then(other Modifier) Modifier {
+	return m.with(other.elements...)
+}
+
+// with は要素をチェーンの末尾（内側）に追加した新しいモディファイアを返します
+func (m Modifier) with(elements ...ModifierElement) Modifier {
+	combined := make([]ModifierElement, 0, len(m.elements)+len(elements))
+	combined = append(combined, m.elements...)
+	combined = append(combined, elements...)
+	return Modifier{elements: combined}
+}
+
+// Elements はチェーンの要素を外側から順に返します
+func (m Modifier) Elements() []ModifierElement {
+	elements := make([]ModifierElement, len(m.elements))
+	copy(elements, m.elements)
+	return elements
+}
+
+// IsEmpty はチェーンが空かを判定します
+func (m Modifier) IsEmpty() bool {
+	return len(m.elements) == 0
+}
+
+// Padding は全方向に同じ余白を追加します
+func (m Modifier) Padding(all float64) Modifier {
+	return m.with(PaddingModifier{Start: all, Top: all, End: all, Bottom: all})
+}
+
+// PaddingSymmetric は水平方向と垂直方向の余白を追加します
+func (m Modifier) PaddingSymmetric(horizontal, vertical float64) Modifier {
+	return m.with(PaddingModifier{Start: horizontal, Top: vertical, End: horizontal, Bottom: vertical})
+}
+
+// PaddingValues は各辺に個別の余白を追加します
+func (m Modifier) PaddingValues(start, top, end, bottom float64) Modifier {
+	return m.with(PaddingModifier{Start: start, Top: top, End: end, Bottom: bottom})
+}
+
+// Size は幅と高さを固定します
+func (m Modifier) Size(width, height float64) Modifier {
+	return m.with(SizeModifier{Width: width, Height: height})
+}
+
+// Width は幅を固定します
+func (m Modifier) Width(width float64) Modifier {
+	return m.with(SizeModifier{Width: width, Height: Unspecified})
+}
+
+// Height は高さを固定します
+func (m Modifier) Height(height float64) Modifier {
+	return m.with(SizeModifier{Width: Unspecified, Height: height})
+}
+
+// IntrinsicWidth は幅を内側の要素の固有の幅に固定します
+func (m Modifier) IntrinsicWidth(size IntrinsicSize) Modifier {
+	return m.with(IntrinsicSizeModifier{Width: size})
+}
+
+// IntrinsicHeight は高さを内側の要素の固有の高さに固定します
+func (m Modifier) IntrinsicHeight(size IntrinsicSize) Modifier {
+	return m.with(IntrinsicSizeModifier{Height: size})
+}
+
+// FillMaxWidth は幅を最大幅に対する割合（1 で最大幅いっぱい）に広げます
+func (m Modifier) FillMaxWidth(fraction float64) Modifier {
+	return m.with(FillModifier{WidthFraction: fraction})
+}
+
+// FillMaxHeight は高さを最大の高さに対する割合（1 で最大の高さいっぱい）に広げます
+func (m Modifier) FillMaxHeight(fraction float64) Modifier {
+	return m.with(FillModifier{HeightFraction: fraction})
+}
+
+// FillMaxSize は幅と高さを最大サイズに対する割合に広げます
+func (m Modifier) FillMaxSize(fraction float64) Modifier {
+	return m.with(FillModifier{WidthFraction: fraction, HeightFraction: fraction})
+}
+
+// GridCell は Grid の中で子ノードを配置する行と列を指定します
+func (m Modifier) GridCell(row, column int) Modifier {
+	return m.with(GridCellModifier{Row: row, Column: column})
+}
+
+// GridSpan は Grid の中で子ノードが占める行と列の数を指定します
+func (m Modifier) GridSpan(rowSpan, columnSpan int) Modifier {
+	return m.with(GridSpanModifier{RowSpan: rowSpan, ColumnSpan: columnSpan})
+}
+
+// LayoutID は親のレイアウトが子ノードを識別するための ID を設定します
+func (m Modifier) LayoutID(id string) Modifier {
+	return m.with(LayoutIDModifier{ID: id})
+}
+
+// Background は背景色を設定します
+func (m Modifier) Background(color string) Modifier {
+	return m.with(BackgroundModifier{Color: color})
+}
+
+// RoundedBackground は角丸の背景色を設定します
+func (m Modifier) RoundedBackground(color string, cornerRadius float64) Modifier {
+	return m.with(BackgroundModifier{Color: color, CornerRadius: cornerRadius})
+}
+
+// Border は枠線を設定します
+func (m Modifier) Border(width float64, color string) Modifier {
+	return m.with(BorderModifier{Width: width, Color: color})
+}
+
+// RoundedBorder は角丸の枠線を設定します
+func (m Modifier) RoundedBorder(width float64, color string, cornerRadius float64) Modifier {
+	return m.with(BorderModifier{Width: width, Color: color, CornerRadius: cornerRadius})
+}
+
+// Clickable はクリック時に呼ばれる関数を設定します
+func (m Modifier) Clickable(onClick func()) Modifier {
+	return m.with(ClickableModifier{OnClick: onClick})
+}
+
+// Weight は Row や Column の残りの空きを重みに応じて割り当て、そのサイズいっぱいに広げます
+func (m Modifier) Weight(weight float64) Modifier {
+	return m.with(WeightModifier{Weight: weight, Fill: true})
+}
+
+// LooseWeight は Row や Column の残りの空きを重みに応じて割り当て、そのサイズを上限とします
+func (m Modifier) LooseWeight(weight float64) Modifier {
+	return m.with(WeightModifier{Weight: weight, Fill: false})
+}
+
+// Align は親の交差軸方向の配置をこの子ノードについてだけ上書きします
+func (m Modifier) Align(alignment Alignment) Modifier {
+	return m.with(AlignModifier{Alignment: alignment})
+}
+
+// GetModifier はプロパティからモディファイアを取得します
+func (p Props) GetModifier() Modifier {
+	if value, exists := p[ModifierKey]; exists {
+		if modifier, ok := value.(Modifier); ok {
+			return modifier
+		}
+	}
+	return Modifier{}
+}
+
+// Modifier はノードに設定されたモディファイアを返します
+func (n *Node) Modifier() Modifier {
+	return n.Props.GetModifier()
+}
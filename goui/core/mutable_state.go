@@ -0,0 +1,106 @@
+package core
+
+import (
+	"reflect"
+)
+
+// State は型付きの読み取り専用状態です
+type State[T any] interface {
+	// Get は現在の値を返します
+	Get() T
+}
+
+// EqualityPolicy は2つの値が等しいかを判定する関数です
+// 等しいと判定された更新は無視され、リスナーにも通知されません
+type EqualityPolicy[T any] func(a, b T) bool
+
+// StructuralEquality は reflect.DeepEqual による構造的な比較を行います
+func StructuralEquality[T any](a, b T) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// NeverEqual は常に異なる値とみなし、すべての更新を通知します
+func NeverEqual[T any](a, b T) bool {
+	return false
+}
+
+// MutableState は StateManager に保存される型付きの変更可能な状態です
+type MutableState[T any] struct {
+	manager *StateManager
+	key     string
+	equal   EqualityPolicy[T]
+}
+
+// NewMutableState は構造的な比較で変更を判定する型付き状態を作成します
+func NewMutableState[T any](manager *StateManager, key string, initialValue T) *MutableState[T] {
+	return NewMutableStateWithPolicy(manager, key, initialValue, StructuralEquality[T])
+}
+
+// NewMutableStateWithPolicy は指定した比較方法で変更を判定する型付き状態を作成します
+func NewMutableStateWithPolicy[T any](
+	manager *StateManager,
+	key string,
+	initialValue T,
+	equal EqualityPolicy[T],
+) *MutableState[T] {
+	manager.mutex.Lock()
+	manager.storeLocked(key, initialValue)
+	manager.mutex.Unlock()
+
+	return &MutableState[T]{
+		manager: manager,
+		key:     key,
+		equal:   equal,
+	}
+}
+
+// Key は StateManager 上の状態キーを返します
+func (s *MutableState[T]) Key() string {
+	return s.key
+}
+
+// Get は現在の値を返します
+// 保存されている値が T でない場合はゼロ値を返します
+func (s *MutableState[T]) Get() T {
+	return castState[T](s.manager.GetState(s.key))
+}
+
+// Set は値を更新します
+// 現在の値と等しい場合は何もしません
+func (s *MutableState[T]) Set(value T) {
+	s.Update(func(T) T {
+		return value
+	})
+}
+
+// Update は現在の値から新しい値を計算して更新します
+// 読み取りと書き込みはアトミックに行われます
+// update と比較関数はロックの外で呼ばれるため、中で他の状態を Get できます
+// （計算中に値が書き換えられた場合は update が再度呼ばれます）
+func (s *MutableState[T]) Update(update func(current T) T) {
+	s.manager.updateState(s.key, func(oldState interface{}) (interface{}, bool) {
+		current := castState[T](oldState)
+		next := update(current)
+		if s.equal(current, next) {
+			return oldState, false
+		}
+		return next, true
+	})
+}
+
+// AddListener は値の変更を監視するリスナーを追加し、登録を解除する関数を返します
+func (s *MutableState[T]) AddListener(listener func(oldValue, newValue T)) (unsubscribe func()) {
+	return s.manager.Subscribe(s.key, func(oldState, newState interface{}) {
+		listener(castState[T](oldState), castState[T](newState))
+	})
+}
+
+// castState は状態の値を T に変換します
+func castState[T any](value interface{}) T {
+	typed, ok := value.(T)
+	if !ok {
+		var zero T
+		return zero
+	}
+	return typed
+}
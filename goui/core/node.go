@@ -15,6 +15,28 @@ const (
 	ColumnNodeType  NodeType = "Column"
 	BoxNodeType     NodeType = "Box"
 	CustomNodeType  NodeType = "Custom"
+	
+	// LayoutNodeType は Props の MeasurePolicy で子ノードを配置するノードタイプです
+	LayoutNodeType NodeType = "Layout"
+	
+	// FlowRowNodeType と FlowColumnNodeType は子ノードを折り返して並べるノードタイプです
+	FlowRowNodeType    NodeType = "FlowRow"
+	FlowColumnNodeType NodeType = "FlowColumn"
+	
+	// GridNodeType は子ノードを行と列のトラックに配置するノードタイプです
+	GridNodeType NodeType = "Grid"
+	
+	// ConstraintLayoutNodeType は子ノード間の関係を制約で指定して配置するノードタイプです
+	ConstraintLayoutNodeType NodeType = "ConstraintLayout"
+	
+	// LazyColumnNodeType と LazyRowNodeType は表示範囲の項目だけを構成して並べるノードタイプです
+	// 子ノードはレイアウトのたびに項目を生成する関数から Subcomposition で構成されます
+	LazyColumnNodeType NodeType = "LazyColumn"
+	LazyRowNodeType    NodeType = "LazyRow"
+	
+	// VerticalScrollNodeType と HorizontalScrollNodeType は子ノードを並べ、表示領域を超えた分をスクロールして表示するノードタイプです
+	VerticalScrollNodeType   NodeType = "VerticalScroll"
+	HorizontalScrollNodeType NodeType = "HorizontalScroll"
 )
 
 // Node は UI ツリーの基本要素です
@@ -25,6 +47,16 @@ type Node struct {
 	Children  []*Node
 	Parent    *Node
 	Component Component
+	
+	// scope はコンポジション中のコンポーネントインスタンスの情報です
+	scope *composeScope
+	
+	// composer と ownerScope はノードを構成したコンポーザーと、ノードを含むコンポーネントのスコープです
+	composer   *Composer
+	ownerScope *composeScope
+	
+	// subcomposition はレイアウト中に子ノードを構成するためのハンドルです
+	subcomposition *Subcomposition
 }
 
 // NewNode は新しいノードを作成します
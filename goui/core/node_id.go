@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodeID はツリー内でノードを一意に識別するパスです
+//
+// 親ノードのパスに「キー#兄弟内の位置」を連結したもので、
+// 同じキーを持つノードが複数あっても区別できます（例: "root#0/buttons#2/increment#1"）。
+type NodeID string
+
+// idSegmentEscaper はパスの区切り文字をキーからエスケープします
+var idSegmentEscaper = strings.NewReplacer("%", "%25", "/", "%2F", "#", "%23")
+
+// RootNodeID はルートノードの ID を返します
+func RootNodeID(root *Node) NodeID {
+	return ChildNodeID("", root.Key, 0)
+}
+
+// ChildNodeID は親の ID、キー、兄弟内の位置から子ノードの ID を作成します
+func ChildNodeID(parent NodeID, key string, index int) NodeID {
+	segment := NodeID(fmt.Sprintf("%s#%d", idSegmentEscaper.Replace(key), index))
+	if parent == "" {
+		return segment
+	}
+	return parent + "/" + segment
+}
+
+// ID は Parent をたどってノードの ID を計算します
+func (n *Node) ID() NodeID {
+	if n.Parent == nil {
+		return RootNodeID(n)
+	}
+
+	index := 0
+	for i, sibling := range n.Parent.Children {
+		if sibling == n {
+			index = i
+			break
+		}
+	}
+	return ChildNodeID(n.Parent.ID(), n.Key, index)
+}
+
+// DuplicateKey は兄弟ノード間でのキーの重複を表す診断情報です
+type DuplicateKey struct {
+	Parent  NodeID
+	Key     string
+	Indices []int
+}
+
+// String は診断情報の文字列表現を返します
+func (d DuplicateKey) String() string {
+	return fmt.Sprintf("duplicate key %q among children of %s at indices %v", d.Key, d.Parent, d.Indices)
+}
+
+// FindDuplicateKeys はツリー全体から兄弟間で重複しているキーを検出します
+// 空のキーは重複とみなしません
+func FindDuplicateKeys(root *Node) []DuplicateKey {
+	duplicates := []DuplicateKey{}
+	if root == nil {
+		return duplicates
+	}
+	return findDuplicateKeys(root, RootNodeID(root), duplicates)
+}
+
+// findDuplicateKeys はノード以下を再帰的に検査します
+func findDuplicateKeys(node *Node, id NodeID, duplicates []DuplicateKey) []DuplicateKey {
+	indices := make(map[string][]int)
+	order := []string{}
+	for i, child := range node.Children {
+		if child.Key == "" {
+			continue
+		}
+		if _, seen := indices[child.Key]; !seen {
+			order = append(order, child.Key)
+		}
+		indices[child.Key] = append(indices[child.Key], i)
+	}
+
+	for _, key := range order {
+		if len(indices[key]) > 1 {
+			duplicates = append(duplicates, DuplicateKey{Parent: id, Key: key, Indices: indices[key]})
+		}
+	}
+
+	for i, child := range node.Children {
+		duplicates = findDuplicateKeys(child, ChildNodeID(id, child.Key, i), duplicates)
+	}
+	return duplicates
+}
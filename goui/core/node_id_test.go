@@ -0,0 +1,134 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChildNodeID(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent NodeID
+		key    string
+		index  int
+		want   NodeID
+	}{
+		{"root", "", "root", 0, "root#0"},
+		{"child", "root#0", "button", 2, "root#0/button#2"},
+		{"empty key", "root#0", "", 1, "root#0/#1"},
+		{"slash is escaped", "root#0", "a/b", 0, "root#0/a%2Fb#0"},
+		{"hash is escaped", "root#0", "a#1", 0, "root#0/a%231#0"},
+		{"percent is escaped", "root#0", "100%", 0, "root#0/100%25#0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChildNodeID(tt.parent, tt.key, tt.index); got != tt.want {
+				t.Errorf("ChildNodeID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChildNodeIDDistinguishesEscapedKeys(t *testing.T) {
+	// エスケープしないと "a/b#0" の子と "a" の子 "b" が同じパスになってしまう
+	nested := ChildNodeID(ChildNodeID("", "a", 0), "b", 0)
+	flat := ChildNodeID("", "a#0/b", 0)
+	if nested == flat {
+		t.Errorf("nested and flat IDs collide: %q", nested)
+	}
+}
+
+func TestNodeID(t *testing.T) {
+	root := NewNode(ColumnNodeType, "root", Props{})
+	first := NewNode(TextNodeType, "item", Props{})
+	second := NewNode(TextNodeType, "item", Props{})
+	root.AddChild(first)
+	root.AddChild(second)
+	leaf := NewNode(TextNodeType, "leaf", Props{})
+	second.AddChild(leaf)
+
+	tests := []struct {
+		name string
+		node *Node
+		want NodeID
+	}{
+		{"root", root, "root#0"},
+		{"first of duplicate keys", first, "root#0/item#0"},
+		{"second of duplicate keys", second, "root#0/item#1"},
+		{"nested", leaf, "root#0/item#1/leaf#0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.node.ID(); got != tt.want {
+				t.Errorf("ID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDuplicateKeys(t *testing.T) {
+	parent := func(key string, children ...*Node) *Node {
+		node := NewNode(ColumnNodeType, key, Props{})
+		for _, child := range children {
+			node.AddChild(child)
+		}
+		return node
+	}
+	text := func(key string) *Node {
+		return NewNode(TextNodeType, key, Props{})
+	}
+
+	tests := []struct {
+		name string
+		root *Node
+		want []string
+	}{
+		{
+			"unique keys",
+			parent("root", text("a"), text("b")),
+			[]string{},
+		},
+		{
+			"empty keys are ignored",
+			parent("root", text(""), text("")),
+			[]string{},
+		},
+		{
+			"duplicate keys",
+			parent("root", text("a"), text("b"), text("a")),
+			[]string{`duplicate key "a" among children of root#0 at indices [0 2]`},
+		},
+		{
+			"same key under different parents",
+			parent("root", parent("left", text("a")), parent("right", text("a"))),
+			[]string{},
+		},
+		{
+			"nested duplicates",
+			parent("root", parent("list", text("x"), text("x"), text("y"), text("y"))),
+			[]string{
+				`duplicate key "x" among children of root#0/list#0 at indices [0 1]`,
+				`duplicate key "y" among children of root#0/list#0 at indices [2 3]`,
+			},
+		},
+		{
+			"nil root",
+			nil,
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, duplicate := range FindDuplicateKeys(tt.root) {
+				got = append(got, duplicate.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDuplicateKeys = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
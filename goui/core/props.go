@@ -108,6 +108,8 @@ func (p Props) String() string {
 }
 
 // Equal は2つのプロパティが等しいかどうかを判定します
+// 関数は reflect.DeepEqual と同じく両方が nil の場合だけ等しいとみなすため、
+// コールバックを受け取るコンポーネントは毎回再レンダリングされ、新しいクロージャが子に渡されます
 func (p Props) Equal(other Props) bool {
 	if len(p) != len(other) {
 		return false
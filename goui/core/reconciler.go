@@ -0,0 +1,291 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// PatchType はパッチの種類を表す型です
+type PatchType int
+
+const (
+	// PatchInsert は新しいノードの挿入を表します
+	PatchInsert PatchType = iota
+	// PatchRemove は古いノードの削除を表します
+	PatchRemove
+	// PatchMove は兄弟間でのノードの移動を表します
+	PatchMove
+	// PatchUpdateProps はプロパティの更新を表します
+	PatchUpdateProps
+)
+
+// String はパッチの種類の文字列表現を返します
+func (t PatchType) String() string {
+	switch t {
+	case PatchInsert:
+		return "Insert"
+	case PatchRemove:
+		return "Remove"
+	case PatchMove:
+		return "Move"
+	case PatchUpdateProps:
+		return "UpdateProps"
+	default:
+		return fmt.Sprintf("PatchType(%d)", int(t))
+	}
+}
+
+// Patch は2つのUIツリー間の差分操作を表します
+type Patch struct {
+	Type PatchType
+	// Parent は操作対象の親ノードです（Remove では古いツリー側、それ以外は新しいツリー側）
+	// ルートノードに対する操作では nil になります
+	Parent *Node
+	// Node は操作対象のノードです（Remove では古いノード、それ以外は新しいノード）
+	Node *Node
+	// OldNode は Move と UpdateProps で対応する古いノードです
+	OldNode *Node
+	// Index は新しい子リストでの位置です
+	Index int
+	// OldIndex は古い子リストでの位置です
+	OldIndex int
+}
+
+// String はパッチの文字列表現を返します（デバッグ用）
+func (p Patch) String() string {
+	switch p.Type {
+	case PatchRemove:
+		return fmt.Sprintf("%s %s (Type: %s, Index: %d)", p.Type, p.Node.Key, p.Node.Type, p.OldIndex)
+	case PatchMove:
+		return fmt.Sprintf("%s %s (Type: %s, %d -> %d)", p.Type, p.Node.Key, p.Node.Type, p.OldIndex, p.Index)
+	default:
+		return fmt.Sprintf("%s %s (Type: %s, Index: %d)", p.Type, p.Node.Key, p.Node.Type, p.Index)
+	}
+}
+
+// Reconcile は古いツリーと新しいツリーを比較し、最小限のパッチ列を生成します
+//
+// 子ノードは Key と Type の組み合わせで対応付けられます（Key が空の場合は同じ Type の中で出現順に対応付けます）。
+// 対応付けられたノードのコンポーネントインスタンスは新しいツリーに引き継がれるため、
+// StatefulComponent の状態は再構築後も保持されます。
+func Reconcile(oldRoot, newRoot *Node) []Patch {
+	patches := []Patch{}
+
+	if oldRoot == nil && newRoot == nil {
+		return patches
+	}
+	if oldRoot == nil {
+		return append(patches, Patch{Type: PatchInsert, Node: newRoot})
+	}
+	if newRoot == nil {
+		return append(patches, Patch{Type: PatchRemove, Node: oldRoot})
+	}
+	if !sameIdentity(oldRoot, newRoot) {
+		// ルートが入れ替わった場合はツリー全体を置き換える
+		patches = append(patches, Patch{Type: PatchRemove, Node: oldRoot})
+		return append(patches, Patch{Type: PatchInsert, Node: newRoot})
+	}
+
+	return reconcileNode(nil, oldRoot, newRoot, 0, 0, patches)
+}
+
+// reconcileNode は対応付けられた2つのノードを比較し、パッチを追加します
+func reconcileNode(parent, oldNode, newNode *Node, oldIndex, index int, patches []Patch) []Patch {
+	adoptComponent(oldNode, newNode)
+
+	if propsChanged(oldNode, newNode) {
+		patches = append(patches, Patch{
+			Type:     PatchUpdateProps,
+			Parent:   parent,
+			Node:     newNode,
+			OldNode:  oldNode,
+			Index:    index,
+			OldIndex: oldIndex,
+		})
+	}
+
+	return reconcileChildren(oldNode, newNode, patches)
+}
+
+// reconcileChildren は子ノードのリストを比較し、パッチを追加します
+func reconcileChildren(oldParent, newParent *Node, patches []Patch) []Patch {
+	matches := matchChildren(oldParent.Children, newParent.Children)
+
+	// 対応付けられなかった古い子ノードを削除
+	matched := make([]bool, len(oldParent.Children))
+	for _, oldIndex := range matches {
+		if oldIndex >= 0 {
+			matched[oldIndex] = true
+		}
+	}
+	for i, child := range oldParent.Children {
+		if !matched[i] {
+			patches = append(patches, Patch{
+				Type:     PatchRemove,
+				Parent:   oldParent,
+				Node:     child,
+				OldIndex: i,
+			})
+		}
+	}
+
+	// 古い位置の最長増加部分列に含まれるノードは移動不要とみなす
+	stable := stableMatches(matches)
+	for i, child := range newParent.Children {
+		oldIndex := matches[i]
+		if oldIndex < 0 {
+			patches = append(patches, Patch{
+				Type:     PatchInsert,
+				Parent:   newParent,
+				Node:     child,
+				Index:    i,
+				OldIndex: -1,
+			})
+			continue
+		}
+
+		oldChild := oldParent.Children[oldIndex]
+		if !stable[i] {
+			patches = append(patches, Patch{
+				Type:     PatchMove,
+				Parent:   newParent,
+				Node:     child,
+				OldNode:  oldChild,
+				Index:    i,
+				OldIndex: oldIndex,
+			})
+		}
+
+		patches = reconcileNode(newParent, oldChild, child, oldIndex, i, patches)
+	}
+
+	return patches
+}
+
+// matchChildren は新しい子ノードそれぞれに対応する古い子ノードのインデックスを返します
+// 対応するノードがない場合は -1 になります
+func matchChildren(oldChildren, newChildren []*Node) []int {
+	keyed := make(map[string][]int)
+	unkeyed := make(map[NodeType][]int)
+	for i, child := range oldChildren {
+		if child.Key != "" {
+			id := identityKey(child)
+			keyed[id] = append(keyed[id], i)
+		} else {
+			unkeyed[child.Type] = append(unkeyed[child.Type], i)
+		}
+	}
+
+	matches := make([]int, len(newChildren))
+	for i, child := range newChildren {
+		matches[i] = -1
+
+		if child.Key != "" {
+			id := identityKey(child)
+			if candidates := keyed[id]; len(candidates) > 0 {
+				matches[i] = candidates[0]
+				keyed[id] = candidates[1:]
+			}
+			continue
+		}
+
+		if candidates := unkeyed[child.Type]; len(candidates) > 0 {
+			matches[i] = candidates[0]
+			unkeyed[child.Type] = candidates[1:]
+		}
+	}
+
+	return matches
+}
+
+// stableMatches は移動せずに済む新しい子ノードの集合を返します
+// 対応付けられた古いインデックス列の最長増加部分列を求めることで移動回数を最小化します
+func stableMatches(matches []int) []bool {
+	stable := make([]bool, len(matches))
+
+	// tails[k] は長さ k+1 の増加部分列の末尾となる matches のインデックス
+	tails := []int{}
+	prev := make([]int, len(matches))
+	for i, oldIndex := range matches {
+		prev[i] = -1
+		if oldIndex < 0 {
+			continue
+		}
+
+		// 二分探索で置き換え位置を決める
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if matches[tails[mid]] < oldIndex {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			stable[i] = true
+		}
+	}
+
+	return stable
+}
+
+// identityKey はノードの同一性判定に使う文字列を返します
+func identityKey(node *Node) string {
+	return string(node.Type) + "\x00" + node.Key
+}
+
+// sameIdentity は2つのノードが同じノードとみなせるかを判定します
+func sameIdentity(a, b *Node) bool {
+	return a.Type == b.Type && a.Key == b.Key
+}
+
+// adoptComponent は古いノードのコンポーネントインスタンスを新しいノードに引き継ぎます
+func adoptComponent(oldNode, newNode *Node) {
+	if oldNode.Component == nil || newNode.Component == nil {
+		return
+	}
+	if reflect.TypeOf(oldNode.Component) != reflect.TypeOf(newNode.Component) {
+		return
+	}
+	if sameComponent(oldNode.Component, newNode.Component) {
+		return
+	}
+
+	// 関数型コンポーネントは最新の描画関数を使いつつインスタンスを維持する
+	if oldFC, ok := oldNode.Component.(*FunctionComponent); ok {
+		oldFC.renderFunc = newNode.Component.(*FunctionComponent).renderFunc
+	}
+
+	newNode.Component = oldNode.Component
+}
+
+// sameComponent は2つのコンポーネントが同じインスタンスかを判定します
+func sameComponent(a, b Component) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// propsChanged はノードのプロパティ更新が必要かを判定します
+func propsChanged(oldNode, newNode *Node) bool {
+	if newNode.Component != nil {
+		return newNode.Component.ShouldUpdate(oldNode.Props, newNode.Props)
+	}
+	return !oldNode.Props.Equal(newNode.Props)
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// keyedList は key ごとに Text の子ノードを持つ Column を作成します
+func keyedList(keys ...string) *Node {
+	root := NewNode(ColumnNodeType, "list", Props{})
+	for _, key := range keys {
+		root.AddChild(NewNode(TextNodeType, key, Props{"text": key}))
+	}
+	return root
+}
+
+// patchSummary はパッチ列を比較しやすい文字列の列に変換します
+func patchSummary(patches []Patch) []string {
+	summary := make([]string, len(patches))
+	for i, patch := range patches {
+		summary[i] = patch.String()
+	}
+	return summary
+}
+
+func TestReconcileKeyedChildren(t *testing.T) {
+	tests := []struct {
+		name string
+		old  []string
+		new  []string
+		want []string
+	}{
+		{
+			name: "unchanged",
+			old:  []string{"a", "b", "c"},
+			new:  []string{"a", "b", "c"},
+			want: []string{},
+		},
+		{
+			name: "insert in the middle",
+			old:  []string{"a", "c"},
+			new:  []string{"a", "b", "c"},
+			want: []string{"Insert b (Type: Text, Index: 1)"},
+		},
+		{
+			name: "delete from the front",
+			old:  []string{"a", "b", "c"},
+			new:  []string{"b", "c"},
+			want: []string{"Remove a (Type: Text, Index: 0)"},
+		},
+		{
+			name: "move last to front",
+			old:  []string{"a", "b", "c", "d"},
+			new:  []string{"d", "a", "b", "c"},
+			want: []string{"Move d (Type: Text, 3 -> 0)"},
+		},
+		{
+			name: "move first to back",
+			old:  []string{"a", "b", "c", "d"},
+			new:  []string{"b", "c", "d", "a"},
+			want: []string{"Move a (Type: Text, 0 -> 3)"},
+		},
+		{
+			name: "swap keeps longest increasing run",
+			old:  []string{"a", "b", "c", "d", "e"},
+			new:  []string{"a", "d", "c", "b", "e"},
+			want: []string{
+				"Move d (Type: Text, 3 -> 1)",
+				"Move c (Type: Text, 2 -> 2)",
+			},
+		},
+		{
+			name: "insert, delete and move together",
+			old:  []string{"a", "b", "c"},
+			new:  []string{"c", "x", "a"},
+			want: []string{
+				"Remove b (Type: Text, Index: 1)",
+				"Move c (Type: Text, 2 -> 0)",
+				"Insert x (Type: Text, Index: 1)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := patchSummary(Reconcile(keyedList(tt.old...), keyedList(tt.new...)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("patches = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileMovesAreMinimal(t *testing.T) {
+	// 逆順にした場合は最長増加部分列の長さが 1 なので、残りのすべてが移動になる
+	old := keyedList("a", "b", "c", "d", "e")
+	reversed := keyedList("e", "d", "c", "b", "a")
+
+	moves := 0
+	for _, patch := range Reconcile(old, reversed) {
+		if patch.Type != PatchMove {
+			t.Errorf("unexpected patch %s", patch)
+		}
+		moves++
+	}
+	if moves != 4 {
+		t.Errorf("moves = %d, want 4", moves)
+	}
+}
+
+func TestReconcileUnkeyedChildrenMatchByType(t *testing.T) {
+	old := NewNode(ColumnNodeType, "", Props{})
+	old.AddChild(NewNode(TextNodeType, "", Props{"text": "a"}))
+	old.AddChild(NewNode(BoxNodeType, "", Props{}))
+
+	updated := NewNode(ColumnNodeType, "", Props{})
+	updated.AddChild(NewNode(BoxNodeType, "", Props{}))
+	updated.AddChild(NewNode(TextNodeType, "", Props{"text": "b"}))
+
+	got := patchSummary(Reconcile(old, updated))
+	want := []string{
+		"Move  (Type: Box, 1 -> 0)",
+		"UpdateProps  (Type: Text, Index: 1)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("patches = %q, want %q", got, want)
+	}
+}
+
+func TestReconcileReplacesRootWithDifferentIdentity(t *testing.T) {
+	got := patchSummary(Reconcile(NewNode(RowNodeType, "root", Props{}), NewNode(ColumnNodeType, "root", Props{})))
+	want := []string{
+		"Remove root (Type: Row, Index: 0)",
+		"Insert root (Type: Column, Index: 0)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("patches = %q, want %q", got, want)
+	}
+}
+
+func TestReconcileUpdatesRecreatedCallbacks(t *testing.T) {
+	build := func() *Node {
+		root := NewNode(ColumnNodeType, "root", Props{})
+		root.AddChild(NewNode(TextNodeType, "button", Props{
+			"text":      "OK",
+			"onClick":   func() {},
+			ModifierKey: NewModifier().Padding(1).Clickable(func() {}),
+		}))
+		return root
+	}
+
+	// 作り直されたコールバックは古いクロージャを使い続けないよう更新として扱う
+	got := patchSummary(Reconcile(build(), build()))
+	want := []string{"UpdateProps button (Type: Text, Index: 0)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("patches = %q, want %q", got, want)
+	}
+}
+
+func TestPropsEqual(t *testing.T) {
+	callback := func() {}
+	tests := []struct {
+		name string
+		a, b Props
+		want bool
+	}{
+		{"same scalars", Props{"text": "a", "width": 1.0}, Props{"text": "a", "width": 1.0}, true},
+		{"different scalar", Props{"text": "a"}, Props{"text": "b"}, false},
+		{"missing key", Props{"text": "a"}, Props{"label": "a"}, false},
+		{"different funcs", Props{"onClick": callback}, Props{"onClick": func() {}}, false},
+		{"same func", Props{"onClick": callback}, Props{"onClick": callback}, false},
+		{"nil funcs", Props{"onClick": (func())(nil)}, Props{"onClick": (func())(nil)}, true},
+		{"func and nil func", Props{"onClick": callback}, Props{"onClick": (func())(nil)}, false},
+		{"modifier callbacks", Props{ModifierKey: NewModifier().Clickable(callback)}, Props{ModifierKey: NewModifier().Clickable(func() {})}, false},
+		{"modifier values", Props{ModifierKey: NewModifier().Padding(1)}, Props{ModifierKey: NewModifier().Padding(2)}, false},
+		{"nested slices", Props{"items": []string{"a", "b"}}, Props{"items": []string{"a", "b"}}, true},
+		{"different types", Props{"width": 1}, Props{"width": 1.0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.want {
+				t.Errorf("Equal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Disposable はコンポジションから外れたときに破棄処理が必要な値です
+// Remember で保持された値がこのインターフェースを実装している場合、
+// キーの変更やノードの削除で値が忘れられるときに Dispose が呼ばれます
+type Disposable interface {
+	Dispose()
+}
+
+// slot は呼び出し位置ごとに保持される値です
+type slot struct {
+	keys    []interface{}
+	value   interface{}
+	dispose func()
+}
+
+// slotTable はコンポーネントインスタンスごとのスロットテーブルです
+// Render 中の呼び出し順をスロットの位置として値を保持します
+type slotTable struct {
+	slots  []*slot
+	cursor int
+}
+
+// begin は Render の開始時にカーソルを先頭に戻します
+func (t *slotTable) begin() {
+	t.cursor = 0
+}
+
+// end は Render の終了時に今回使われなかったスロットを破棄します
+func (t *slotTable) end() {
+	for i := len(t.slots) - 1; i >= t.cursor; i-- {
+		t.slots[i].forget()
+	}
+	t.slots = t.slots[:t.cursor]
+}
+
+// next は現在の呼び出し位置のスロットを返し、カーソルを進めます
+// keys が前回と異なる場合は古い値を破棄して calculation で作り直します
+func (t *slotTable) next(keys []interface{}, calculation func() (interface{}, func())) *slot {
+	index := t.cursor
+	t.cursor++
+
+	if index < len(t.slots) {
+		current := t.slots[index]
+		if reflect.DeepEqual(current.keys, keys) {
+			return current
+		}
+		current.forget()
+	}
+
+	value, dispose := calculation()
+	created := &slot{keys: keys, value: value, dispose: dispose}
+	if index < len(t.slots) {
+		t.slots[index] = created
+	} else {
+		t.slots = append(t.slots, created)
+	}
+	return created
+}
+
+// disposeAll はすべてのスロットを後ろから順に破棄します
+func (t *slotTable) disposeAll() {
+	for i := len(t.slots) - 1; i >= 0; i-- {
+		t.slots[i].forget()
+	}
+	t.slots = nil
+	t.cursor = 0
+}
+
+// forget はスロットの値を破棄します
+func (s *slot) forget() {
+	if s.dispose != nil {
+		s.dispose()
+		s.dispose = nil
+	}
+}
+
+// renderingScopes は Render に渡したプロパティから、そのプロパティで Render 中のスコープを引く表です
+// キーは Render ごとに複製したプロパティのマップの識別子で、Render の間だけ登録されます。
+// Composer をプロパティに加えないため、Render が受け取ったプロパティを子ノードに渡しても Composer は漏れず、
+// 別々のゴルーチンで動く Composer どうしが互いのスロットテーブルを参照することもありません
+var renderingScopes sync.Map
+
+// propsIdentity はプロパティのマップの識別子を返します
+func propsIdentity(props Props) uintptr {
+	return reflect.ValueOf(props).Pointer()
+}
+
+// renderProps は Render に渡すプロパティとして、ノードのプロパティの複製をスコープに登録して返します
+// ノードのプロパティそのものは変更しないため、ShouldUpdate の比較には影響しません
+// 返された関数で Render の終了時に登録を解除してください
+func (scope *composeScope) renderProps() (Props, func()) {
+	props := scope.node.Props.Merge(nil)
+	identity := propsIdentity(props)
+	renderingScopes.Store(identity, scope)
+	return props, func() {
+		renderingScopes.Delete(identity)
+	}
+}
+
+// currentScope は props を受け取って Render 中のスコープを返します
+// Render が受け取ったプロパティでない場合や Render が終了している場合は nil を返します
+func currentScope(props Props) *composeScope {
+	if props == nil {
+		return nil
+	}
+	scope, ok := renderingScopes.Load(propsIdentity(props))
+	if !ok {
+		return nil
+	}
+	return scope.(*composeScope)
+}
+
+// Remember は Render をまたいで値を保持します
+//
+// 値はコンポーネントインスタンス内での呼び出し位置に対応付けられるため、
+// 条件分岐などで呼び出し順が変わらないように使用してください。
+// keys が前回の Render と異なる場合は calculation が再度実行されます。
+// props には Render が受け取ったプロパティを渡してください。
+// Composer の外で呼び出された場合は毎回 calculation を実行します。
+func Remember[T any](props Props, calculation func() T, keys ...interface{}) T {
+	scope := currentScope(props)
+	if scope == nil {
+		return calculation()
+	}
+
+	s := scope.slots.next(keys, func() (interface{}, func()) {
+		value := calculation()
+		if disposable, ok := interface{}(value).(Disposable); ok {
+			return value, disposable.Dispose
+		}
+		return value, nil
+	})
+
+	value, ok := s.value.(T)
+	if !ok {
+		// 呼び出し順が変わって型が一致しない場合は作り直す
+		s.forget()
+		value = calculation()
+		s.value = value
+		if disposable, ok := interface{}(value).(Disposable); ok {
+			s.dispose = disposable.Dispose
+		}
+	}
+	return value
+}
+
+// RememberState は Render をまたいで保持される型付き状態を作成します
+//
+// 状態はコンポーザーの StateManager に保存され、ノードがツリーから外れると
+// 読み取りの記録と変更の監視とともに削除されます。
+// props には Render が受け取ったプロパティを渡してください。
+// Composer の外で呼び出された場合は保持されない一時的な状態を返します。
+func RememberState[T any](props Props, initialValue T, keys ...interface{}) *MutableState[T] {
+	scope := currentScope(props)
+	if scope == nil {
+		return NewMutableState(NewStateManager(), "remember", initialValue)
+	}
+
+	s := scope.slots.next(keys, func() (interface{}, func()) {
+		key := scope.composer.nextRememberKey()
+		state := NewMutableState(scope.composer.stateManager, key, initialValue)
+		return state, func() {
+			scope.composer.releaseState(key)
+		}
+	})
+
+	state, ok := s.value.(*MutableState[T])
+	if !ok {
+		// 呼び出し順が変わって型が一致しない場合は作り直す
+		s.forget()
+		key := scope.composer.nextRememberKey()
+		state = NewMutableState(scope.composer.stateManager, key, initialValue)
+		s.value = state
+		s.dispose = func() {
+			scope.composer.releaseState(key)
+		}
+	}
+	return state
+}
+
+// nextRememberKey は RememberState 用の一意な状態キーを生成します
+func (c *Composer) nextRememberKey() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.rememberCount++
+	return fmt.Sprintf("$remember/%d", c.rememberCount)
+}
+
+// releaseState は RememberState の状態を削除し、その状態の読み取りの記録と変更の監視を解除します
+// 解除しないと破棄されたスロットのキーが残り続け、破棄済みのスコープが無効化されてしまいます
+func (c *Composer) releaseState(key string) {
+	c.mutex.Lock()
+	delete(c.readers, key)
+	unsubscribe := c.subscribed[key]
+	delete(c.subscribed, key)
+	c.mutex.Unlock()
+
+	if unsubscribe != nil {
+		unsubscribe()
+	}
+	c.stateManager.DeleteState(key)
+}
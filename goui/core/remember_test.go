@@ -0,0 +1,176 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// rememberKeys は Composer と StateManager に残っている RememberState のキーを返します
+func rememberKeys(composer *Composer) []string {
+	composer.mutex.Lock()
+	defer composer.mutex.Unlock()
+	composer.stateManager.mutex.RLock()
+	defer composer.stateManager.mutex.RUnlock()
+
+	keys := []string{}
+	for key := range composer.readers {
+		if strings.HasPrefix(key, "$remember/") {
+			keys = append(keys, "readers:"+key)
+		}
+	}
+	for key := range composer.subscribed {
+		if strings.HasPrefix(key, "$remember/") {
+			keys = append(keys, "subscribed:"+key)
+		}
+	}
+	for key := range composer.stateManager.states {
+		if strings.HasPrefix(key, "$remember/") {
+			keys = append(keys, "states:"+key)
+		}
+	}
+	return keys
+}
+
+func TestRememberStateKeepsValueAcrossRecomposition(t *testing.T) {
+	composer := NewComposer(NewStateManager())
+	defer composer.Dispose()
+
+	var count *MutableState[int]
+	counter := func(props Props) *Node {
+		count = RememberState(props, 0)
+		return NewNode(TextNodeType, "text", Props{"text": string(rune('0' + count.Get()))})
+	}
+	composer.SetContent(NewComponentNode("counter", NewFunctionComponent(counter), Props{}))
+
+	first := count
+	count.Set(2)
+	composer.Recompose()
+
+	if count != first {
+		t.Error("RememberState returned a different state after recomposition")
+	}
+	if got := textOf(composer.Root()); got != "2" {
+		t.Errorf("text = %q, want 2", got)
+	}
+}
+
+func TestRememberStateReleasedOnUnmount(t *testing.T) {
+	composer := NewComposer(NewStateManager())
+	defer composer.Dispose()
+
+	var count *MutableState[int]
+	counter := func(props Props) *Node {
+		count = RememberState(props, 0)
+		return NewNode(TextNodeType, "text", Props{"text": count.Get()})
+	}
+	tree := func(withCounter bool) *Node {
+		root := NewNode(ColumnNodeType, "root", Props{})
+		if withCounter {
+			root.AddChild(NewComponentNode("counter", NewFunctionComponent(counter), Props{}))
+		}
+		return root
+	}
+
+	composer.SetContent(tree(true))
+	if len(rememberKeys(composer)) == 0 {
+		t.Fatal("remembered state was not registered")
+	}
+
+	composer.SetContent(tree(false))
+	if keys := rememberKeys(composer); len(keys) != 0 {
+		t.Errorf("keys left after unmount: %v", keys)
+	}
+
+	// 破棄されたスロットの状態を書き換えても再構成は要求されない
+	count.Set(5)
+	if composer.HasPendingChanges() {
+		t.Error("disposed scope was invalidated")
+	}
+}
+
+func TestRememberIsolatedBetweenConcurrentComposers(t *testing.T) {
+	const composers = 8
+	const frames = 50
+
+	mismatches := make(chan string, composers*frames)
+	var wg sync.WaitGroup
+	for i := 0; i < composers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			manager := NewStateManager()
+			manager.SetState("frame", 0)
+			composer := NewComposer(manager)
+			defer composer.Dispose()
+
+			// 各 Composer は自分の id を保持し、フレームごとに再レンダリングされる
+			component := func(props Props) *Node {
+				manager.GetState("frame")
+				remembered := Remember(props, func() int { return id })
+				state := RememberState(props, id)
+				if remembered != id || state.Get() != id {
+					mismatches <- fmt.Sprintf("composer %d: Remember = %d, RememberState = %d", id, remembered, state.Get())
+				}
+				return NewNode(TextNodeType, "text", Props{"text": remembered})
+			}
+			composer.SetContent(NewComponentNode("root", NewFunctionComponent(component), Props{}))
+			for frame := 1; frame <= frames; frame++ {
+				manager.SetState("frame", frame)
+				composer.Recompose()
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(mismatches)
+
+	for mismatch := range mismatches {
+		t.Error(mismatch)
+	}
+}
+
+func TestRenderPropsDoNotCarryComposer(t *testing.T) {
+	composer := NewComposer(NewStateManager())
+	defer composer.Dispose()
+
+	// 親は受け取ったプロパティをそのまま子ノードと子コンポーネントに渡す
+	var inner *MutableState[int]
+	child := func(props Props) *Node {
+		inner = RememberState(props, 1)
+		return NewNode(TextNodeType, "text", props.Merge(Props{"text": "child"}))
+	}
+	parent := func(props Props) *Node {
+		RememberState(props, 0)
+		node := NewNode(ContainerNodeType, "box", props)
+		node.AddChild(NewNode(BoxNodeType, "forwarded", props.Clone()))
+		node.AddChild(NewComponentNode("child", NewFunctionComponent(child), props))
+		return node
+	}
+	props := Props{"color": "red"}
+	composer.SetContent(NewComponentNode("parent", NewFunctionComponent(parent), props))
+
+	content := composer.Root().Children[0]
+	for _, node := range []*Node{content, content.Children[0], content.Children[1]} {
+		if !node.Props.Equal(props) {
+			t.Errorf("%s props = %v, want %v", node.Key, node.Props, props)
+		}
+	}
+	if text := content.Children[1].Children[0]; len(text.Props) != 2 {
+		t.Errorf("child text props = %v, want color and text only", text.Props)
+	}
+
+	// 子コンポーネントは自分のスコープで値を保持する
+	first := inner
+	inner.Set(3)
+	composer.Recompose()
+	if inner != first || inner.Get() != 3 {
+		t.Errorf("child state = %d, want the same state with 3", inner.Get())
+	}
+
+	// Render の外では受け取ったプロパティを渡してもスコープは見つからない
+	if currentScope(content.Props) != nil {
+		t.Error("forwarded props resolved a scope outside Render")
+	}
+}
@@ -8,49 +8,181 @@ import (
 // StateManager は状態管理を担当します
 type StateManager struct {
 	states     map[string]interface{}
-	listeners  map[string][]StateChangeListener
+	listeners  map[string][]listenerEntry
+	observers  []observerEntry
+	nextID     uint64
 	mutex      sync.RWMutex
+	// versions はキーごとに最後に書き込んだときの revision です
+	versions   map[string]uint64
+	revision   uint64
 }
 
 // StateChangeListener は状態変更を監視するリスナーです
 type StateChangeListener func(oldState, newState interface{})
 
+// StateReadObserver は状態の読み取りを監視するオブザーバーです
+type StateReadObserver func(key string)
+
+// listenerEntry は登録されたリスナーと識別子の組です
+type listenerEntry struct {
+	id       uint64
+	listener StateChangeListener
+}
+
+// observerEntry は登録された読み取りオブザーバーと識別子の組です
+type observerEntry struct {
+	id       uint64
+	observer StateReadObserver
+}
+
 // NewStateManager は新しい状態管理マネージャーを作成します
 func NewStateManager() *StateManager {
 	return &StateManager{
 		states:    make(map[string]interface{}),
-		listeners: make(map[string][]StateChangeListener),
+		listeners: make(map[string][]listenerEntry),
+		versions:  make(map[string]uint64),
 	}
 }
 
 // GetState は指定されたキーの状態を取得します
 func (sm *StateManager) GetState(key string) interface{} {
 	sm.mutex.RLock()
-	defer sm.mutex.RUnlock()
+	state := sm.states[key]
+	observers := sm.observers
+	sm.mutex.RUnlock()
+	
+	// 読み取りをオブザーバーに通知
+	for _, entry := range observers {
+		entry.observer(key)
+	}
+	
+	return state
+}
+
+// DeleteState は指定されたキーの状態とリスナーを削除します
+func (sm *StateManager) DeleteState(key string) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
 	
-	return sm.states[key]
+	delete(sm.states, key)
+	delete(sm.listeners, key)
+	delete(sm.versions, key)
 }
 
 // SetState は状態を更新し、リスナーに通知します
 func (sm *StateManager) SetState(key string, newState interface{}) {
 	sm.mutex.Lock()
 	oldState := sm.states[key]
-	sm.states[key] = newState
+	sm.storeLocked(key, newState)
 	listeners := sm.listeners[key]
 	sm.mutex.Unlock()
 	
 	// リスナーに通知
-	for _, listener := range listeners {
-		listener(oldState, newState)
+	for _, entry := range listeners {
+		entry.listener(oldState, newState)
+	}
+}
+
+// updateState は現在の状態をもとに新しい状態をアトミックに計算して更新します
+// update が false を返した場合は更新せず、リスナーにも通知しません
+//
+// update はロックを保持せずに呼び出されるため、中で状態を読み書きできます。
+// 計算中に同じキーが別の更新で書き換えられた場合は、新しい値で update を呼び直します。
+func (sm *StateManager) updateState(key string, update func(oldState interface{}) (interface{}, bool)) {
+	for {
+		sm.mutex.RLock()
+		oldState := sm.states[key]
+		version := sm.versions[key]
+		sm.mutex.RUnlock()
+		
+		newState, changed := update(oldState)
+		if !changed {
+			return
+		}
+		
+		sm.mutex.Lock()
+		if sm.versions[key] != version {
+			// 読み取った後に書き換えられたのでやり直す
+			sm.mutex.Unlock()
+			continue
+		}
+		sm.storeLocked(key, newState)
+		listeners := sm.listeners[key]
+		sm.mutex.Unlock()
+		
+		// リスナーに通知
+		for _, entry := range listeners {
+			entry.listener(oldState, newState)
+		}
+		return
 	}
 }
 
+// storeLocked は状態を書き込み、書き込みの revision を記録します（ロック取得済みで呼び出すこと）
+func (sm *StateManager) storeLocked(key string, value interface{}) {
+	sm.revision++
+	sm.states[key] = value
+	sm.versions[key] = sm.revision
+}
+
 // AddListener は状態変更リスナーを追加します
 func (sm *StateManager) AddListener(key string, listener StateChangeListener) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 	
-	sm.listeners[key] = append(sm.listeners[key], listener)
+	sm.nextID++
+	sm.listeners[key] = append(sm.listeners[key], listenerEntry{id: sm.nextID, listener: listener})
+}
+
+// Subscribe は状態変更リスナーを追加し、登録を解除する関数を返します
+// RemoveListener と異なり、同じ関数リテラルから作られた複数のリスナーも個別に解除できます
+func (sm *StateManager) Subscribe(key string, listener StateChangeListener) (unsubscribe func()) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	
+	sm.nextID++
+	id := sm.nextID
+	sm.listeners[key] = append(sm.listeners[key], listenerEntry{id: id, listener: listener})
+	
+	return func() {
+		sm.mutex.Lock()
+		defer sm.mutex.Unlock()
+		
+		listeners := sm.listeners[key]
+		for i, entry := range listeners {
+			if entry.id == id {
+				// 通知中のスライスを壊さないようにコピーして削除
+				updated := make([]listenerEntry, 0, len(listeners)-1)
+				updated = append(updated, listeners[:i]...)
+				sm.listeners[key] = append(updated, listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// ObserveReads は状態の読み取りを監視するオブザーバーを追加し、登録を解除する関数を返します
+func (sm *StateManager) ObserveReads(observer StateReadObserver) (unsubscribe func()) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	
+	sm.nextID++
+	id := sm.nextID
+	sm.observers = append(sm.observers, observerEntry{id: id, observer: observer})
+	
+	return func() {
+		sm.mutex.Lock()
+		defer sm.mutex.Unlock()
+		
+		for i, entry := range sm.observers {
+			if entry.id == id {
+				updated := make([]observerEntry, 0, len(sm.observers)-1)
+				updated = append(updated, sm.observers[:i]...)
+				sm.observers = append(updated, sm.observers[i+1:]...)
+				return
+			}
+		}
+	}
 }
 
 // RemoveListener は状態変更リスナーを削除します
@@ -59,11 +191,14 @@ func (sm *StateManager) RemoveListener(key string, listener StateChangeListener)
 	defer sm.mutex.Unlock()
 	
 	listeners := sm.listeners[key]
-	for i, l := range listeners {
+	for i, entry := range listeners {
 		// 関数ポインタの比較は難しいので、この実装は単純化しています
-		// 実際には、リスナーを識別するための別の方法が必要かもしれません
-		if reflect.ValueOf(l).Pointer() == reflect.ValueOf(listener).Pointer() {
-			sm.listeners[key] = append(listeners[:i], listeners[i+1:]...)
+		// 個別に解除する必要がある場合は Subscribe を使用してください
+		if reflect.ValueOf(entry.listener).Pointer() == reflect.ValueOf(listener).Pointer() {
+			// 通知中のスライスを壊さないようにコピーして削除
+			updated := make([]listenerEntry, 0, len(listeners)-1)
+			updated = append(updated, listeners[:i]...)
+			sm.listeners[key] = append(updated, listeners[i+1:]...)
 			break
 		}
 	}
@@ -75,7 +210,7 @@ func (sm *StateManager) CreateState(key string, initialValue interface{}) (
 	setter func(interface{}),
 ) {
 	sm.mutex.Lock()
-	sm.states[key] = initialValue
+	sm.storeLocked(key, initialValue)
 	sm.mutex.Unlock()
 	
 	getter = func() interface{} {
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// withTimeout は f がデッドロックせずに終わることを確認します
+func withTimeout(t *testing.T, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out (deadlock?)")
+	}
+}
+
+func TestMutableStateUpdateCanReadOtherState(t *testing.T) {
+	manager := NewStateManager()
+	count := NewMutableState(manager, "count", 1)
+	step := NewMutableState(manager, "step", 2)
+
+	withTimeout(t, func() {
+		count.Update(func(c int) int { return c + step.Get() })
+	})
+	if got := count.Get(); got != 3 {
+		t.Errorf("count = %d, want 3", got)
+	}
+}
+
+func TestMutableStateEqualityPolicyCanReadState(t *testing.T) {
+	manager := NewStateManager()
+	threshold := NewMutableState(manager, "threshold", 10)
+	// 差が threshold 未満の更新は同じ値とみなす
+	value := NewMutableStateWithPolicy(manager, "value", 0, func(a, b int) bool {
+		diff := a - b
+		if diff < 0 {
+			diff = -diff
+		}
+		return diff < threshold.Get()
+	})
+
+	withTimeout(t, func() {
+		value.Set(5)
+		value.Set(20)
+	})
+	if got := value.Get(); got != 20 {
+		t.Errorf("value = %d, want 20", got)
+	}
+}
+
+func TestMutableStateUpdateIsAtomic(t *testing.T) {
+	manager := NewStateManager()
+	count := NewMutableState(manager, "count", 0)
+
+	const workers, increments = 8, 200
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				count.Update(func(c int) int { return c + 1 })
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := count.Get(); got != workers*increments {
+		t.Errorf("count = %d, want %d", got, workers*increments)
+	}
+}
+
+func TestMutableStateUpdateNotifiesOnlyOnChange(t *testing.T) {
+	manager := NewStateManager()
+	value := NewMutableState(manager, "value", "a")
+
+	var changes [][2]string
+	value.AddListener(func(oldValue, newValue string) {
+		changes = append(changes, [2]string{oldValue, newValue})
+	})
+	value.Set("a")
+	value.Set("b")
+
+	if len(changes) != 1 || changes[0] != [2]string{"a", "b"} {
+		t.Errorf("changes = %v, want [[a b]]", changes)
+	}
+}
+
+func TestRemoveListenerDoesNotModifyNotifiedSnapshot(t *testing.T) {
+	manager := NewStateManager()
+	calls := []string{}
+	first := func(oldState, newState interface{}) { calls = append(calls, "first") }
+	second := func(oldState, newState interface{}) { calls = append(calls, "second") }
+	third := func(oldState, newState interface{}) { calls = append(calls, "third") }
+	manager.AddListener("key", first)
+	manager.AddListener("key", second)
+	manager.AddListener("key", third)
+
+	// 通知中の SetState が保持しているスライスと同じもの
+	snapshot := manager.listeners["key"]
+	manager.RemoveListener("key", first)
+
+	for _, entry := range snapshot {
+		entry.listener(nil, nil)
+	}
+	want := []string{"first", "second", "third"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+
+	calls = nil
+	manager.SetState("key", 1)
+	if len(calls) != 2 || calls[0] != "second" || calls[1] != "third" {
+		t.Errorf("calls after removal = %v, want [second third]", calls)
+	}
+}
@@ -0,0 +1,111 @@
+package core
+
+// Subcomposition はレイアウトの中で子ノードを構成するためのハンドルです
+//
+// LazyColumn のように測定の結果で表示する子ノードが決まるレイアウトは、
+// Subcompose でスロットのキーごとに子ノードを構成し、SetChildren で子ノードを確定します。
+// Composer で構成されたノードでは、子ノードの中のコンポーネントもコンポジションに加わり、
+// Remember や副作用、状態の読み取りによる再構成を使用できます。
+// スロットは SetChildren で子ノードにするか保持するよう指定した間だけ次のレイアウトに引き継がれ、それ以外は破棄されます。
+// Composer の外のノードでは content の結果をそのまま子ノードにします。
+// Composer で構成されたノードのレイアウトは、コンポジション用のゴルーチンから行ってください。
+type Subcomposition struct {
+	composer *Composer
+	node     *Node
+	parent   *composeScope
+	slots    map[interface{}]*Node
+}
+
+// Subcomposition はノードの子ノードをレイアウト中に構成するためのハンドルを返します
+func (n *Node) Subcomposition() *Subcomposition {
+	if n.subcomposition == nil {
+		n.subcomposition = &Subcomposition{
+			composer: n.composer,
+			node:     n,
+			parent:   n.ownerScope,
+			slots:    make(map[interface{}]*Node),
+		}
+	}
+	return n.subcomposition
+}
+
+// Subcompose は slotKey のスロットの子ノードを content で構成して返します
+// 前回同じ slotKey で構成した子ノードと Key と Type が一致すれば、コンポーネントと保持した値を引き継ぎます
+func (s *Subcomposition) Subcompose(slotKey interface{}, content func() *Node) *Node {
+	node := content()
+	if node == nil {
+		node = NewNode(ContainerNodeType, "", Props{})
+	}
+	node.Parent = s.node
+	if s.composer == nil {
+		return node
+	}
+
+	old := s.slots[slotKey]
+	if old != nil && !sameIdentity(old, node) {
+		s.composer.disposeTree(old)
+		old = nil
+	}
+	s.composer.composeNode(node, old, s.parent, nil)
+	s.slots[slotKey] = node
+	return node
+}
+
+// SetChildren は構成した子ノードを並べた順にノードの子ノードにします
+//
+// retained は子ノードにはしないものの、次のレイアウトのために保持しておくノードです。
+// children と retained のどちらにも含まれないスロットは、測定のために構成しただけのものも含めて破棄され、
+// その後で予約された副作用が実行されます。
+func (s *Subcomposition) SetChildren(children, retained []*Node) {
+	s.node.Children = children
+	for _, child := range children {
+		child.Parent = s.node
+	}
+	if s.composer == nil {
+		return
+	}
+
+	for slotKey, node := range s.slots {
+		if !containsNode(children, node) && !containsNode(retained, node) {
+			s.composer.disposeTree(node)
+			delete(s.slots, slotKey)
+		}
+	}
+	s.composer.runEffects()
+}
+
+// adopt は古いノードのサブコンポジションを新しいノードに引き継ぎます
+// 子ノードは次のレイアウトで構成し直されるまで古いノードのものを使用します
+func (s *Subcomposition) adopt(old, node *Node, parent *composeScope) {
+	s.node = node
+	s.parent = parent
+	node.subcomposition = s
+	node.Children = old.Children
+	for _, child := range node.Children {
+		child.Parent = node
+	}
+}
+
+// dispose はすべてのスロットを破棄します
+// 子ノードになっているスロットは並び順に、それ以外のスロットはその後に破棄します
+func (s *Subcomposition) dispose() {
+	for _, child := range s.node.Children {
+		s.composer.disposeTree(child)
+	}
+	for slotKey, node := range s.slots {
+		if !containsNode(s.node.Children, node) {
+			s.composer.disposeTree(node)
+		}
+		delete(s.slots, slotKey)
+	}
+}
+
+// containsNode はノードの列に node が含まれるかを返します
+func containsNode(nodes []*Node, node *Node) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package layout
+
+import (
+	"github.com/tak/goui/core"
+)
+
+// BoxPolicy はすべての子ノードを重ねて配置するスタックの MeasurePolicy です
+//
+// 大きさは最も大きい子ノードに合わせ、各子ノードの位置は ContentAlignment
+// （子ノードの Align があればそれ）に従って決まります。
+// 描画は子ノードの順序で行われるため、後の子ノードほど手前に表示されます。
+type BoxPolicy struct {
+	// ContentAlignment は子ノードの配置です（core.TopStart … core.BottomEnd）
+	ContentAlignment core.Alignment
+	// Width と Height は正の値が指定されていればその軸のサイズを固定します
+	Width  float64
+	Height float64
+}
+
+// Measure は子ノードを重ねて測定します
+func (p BoxPolicy) Measure(measurables []Measurable, constraints Constraints) MeasureResult {
+	// 明示的なサイズが指定されている軸は固定する
+	width, height := p.Width, p.Height
+	if width <= 0 {
+		width = core.Unspecified
+	}
+	if height <= 0 {
+		height = core.Unspecified
+	}
+	constraints = constraints.Tighten(width, height)
+
+	// 子ノードは最小サイズの制約なしに測定する
+	childConstraints := Loose(constraints.MaxSize)
+	contentSize := constraints.MinSize
+	placeables := make([]Placeable, len(measurables))
+	for i, child := range measurables {
+		placeables[i] = child.Measure(childConstraints)
+		contentSize.Width = max(contentSize.Width, placeables[i].Size().Width)
+		contentSize.Height = max(contentSize.Height, placeables[i].Size().Height)
+	}
+	contentSize = constraints.Constrain(contentSize)
+
+	return MeasureResult{
+		Size: contentSize,
+		Place: func() {
+			for i, child := range measurables {
+				alignment := p.ContentAlignment
+				if align, ok := childAlignment(child); ok {
+					alignment = align
+				}
+				horizontal, vertical := alignment.Split()
+				size := placeables[i].Size()
+				placeables[i].Place(
+					horizontal.Offset(contentSize.Width-size.Width),
+					vertical.Offset(contentSize.Height-size.Height),
+				)
+			}
+		},
+	}
+}
+
+// boxPolicyFromProps は Box のプロパティから BoxPolicy を作成します
+func boxPolicyFromProps(props core.Props) MeasurePolicy {
+	return BoxPolicy{
+		ContentAlignment: props.GetAlignment("contentAlignment", core.TopStart),
+		Width:            props.GetFloat("width", 0),
+		Height:           props.GetFloat("height", 0),
+	}
+}
@@ -0,0 +1,71 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+func TestBoxLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		props    core.Props
+		children []*core.Node
+		want     map[core.NodeID]Rect
+	}{
+		{
+			name:     "wraps the largest child",
+			children: []*core.Node{box("a", 4, 1), box("b", 2, 3)},
+			want: map[core.NodeID]Rect{
+				"box#0":     rect(0, 0, 4, 3),
+				"box#0/a#0": rect(0, 0, 4, 1),
+				"box#0/b#1": rect(0, 0, 2, 3),
+			},
+		},
+		{
+			name:     "content alignment",
+			props:    core.Props{"width": 10.0, "height": 6.0, "contentAlignment": core.Center},
+			children: []*core.Node{box("a", 4, 2)},
+			want: map[core.NodeID]Rect{
+				"box#0":     rect(0, 0, 10, 6),
+				"box#0/a#0": rect(3, 2, 4, 2),
+			},
+		},
+		{
+			name:  "child align overrides content alignment",
+			props: core.Props{"width": 10.0, "height": 6.0, "contentAlignment": core.TopStart},
+			children: []*core.Node{
+				box("a", 4, 2),
+				modified(box("b", 2, 2), core.NewModifier().Align(core.BottomEnd)),
+			},
+			want: map[core.NodeID]Rect{
+				"box#0/a#0": rect(0, 0, 4, 2),
+				"box#0/b#1": rect(8, 4, 2, 2),
+			},
+		},
+		{
+			name:     "single axis alignment applies to both axes",
+			props:    core.Props{"width": 6.0, "height": 4.0, "contentAlignment": core.AlignEnd},
+			children: []*core.Node{box("a", 2, 2)},
+			want: map[core.NodeID]Rect{
+				"box#0/a#0": rect(4, 2, 2, 2),
+			},
+		},
+		{
+			name:     "fixed size is clamped by constraints",
+			props:    core.Props{"width": 50.0, "height": 2.0},
+			children: []*core.Node{box("a", 30, 1)},
+			want: map[core.NodeID]Rect{
+				"box#0":     rect(0, 0, 20, 2),
+				"box#0/a#0": rect(0, 0, 20, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := container(core.BoxNodeType, "box", tt.props, tt.children...)
+			expectRects(t, calculate(root, Loose(Size{Width: 20, Height: 10})), tt.want)
+		})
+	}
+}
@@ -0,0 +1,511 @@
+package layout
+
+import (
+	"errors"
+	"math"
+)
+
+// このファイルは ConstraintLayout が使用する線形制約ソルバー（Cassowary アルゴリズム）です
+//
+// 制約は「式 関係 0」の形の一次式で表され、強度が required の制約は必ず満たされ、
+// それより弱い制約は強度で重み付けした誤差が最小になるように満たされます。
+// 編集変数による増分的な更新は使用しないため実装していません。
+
+var (
+	// errUnsatisfiable は required の制約が他の required の制約と矛盾する場合のエラーです
+	errUnsatisfiable = errors.New("layout: unsatisfiable constraint")
+	// errUnbounded は目的関数が有界でない場合のエラーです
+	errUnbounded = errors.New("layout: unbounded objective")
+)
+
+// 制約の強度です
+const (
+	strengthRequired = 1001001000.0
+	strengthStrong   = 1000000.0
+	strengthMedium   = 1000.0
+	strengthWeak     = 1.0
+)
+
+// relation は制約の関係です
+type relation int
+
+const (
+	relationEqual relation = iota
+	relationLessOrEqual
+	relationGreaterOrEqual
+)
+
+// variable はソルバーが値を決定する変数です
+type variable struct {
+	name  string
+	value float64
+}
+
+// term は係数付きの変数です
+type term struct {
+	variable    *variable
+	coefficient float64
+}
+
+// expression は変数の一次式です
+type expression struct {
+	terms    []term
+	constant float64
+}
+
+// variableExpression は変数1つだけの式を返します
+func variableExpression(v *variable) expression {
+	return expression{terms: []term{{variable: v, coefficient: 1}}}
+}
+
+// constantExpression は定数だけの式を返します
+func constantExpression(c float64) expression {
+	return expression{constant: c}
+}
+
+// plus は式の和を返します
+func (e expression) plus(other expression) expression {
+	terms := make([]term, 0, len(e.terms)+len(other.terms))
+	terms = append(terms, e.terms...)
+	terms = append(terms, other.terms...)
+	return expression{terms: terms, constant: e.constant + other.constant}
+}
+
+// minus は式の差を返します
+func (e expression) minus(other expression) expression {
+	return e.plus(other.times(-1))
+}
+
+// times は式を定数倍します
+func (e expression) times(k float64) expression {
+	terms := make([]term, len(e.terms))
+	for i, t := range e.terms {
+		terms[i] = term{variable: t.variable, coefficient: t.coefficient * k}
+	}
+	return expression{terms: terms, constant: e.constant * k}
+}
+
+// add は式に定数を加えます
+func (e expression) add(c float64) expression {
+	return expression{terms: e.terms, constant: e.constant + c}
+}
+
+// linearConstraint は「expression relation 0」の形の制約です
+type linearConstraint struct {
+	expression expression
+	relation   relation
+	strength   float64
+}
+
+// equal は a == b の制約を返します
+func equal(a, b expression, strength float64) *linearConstraint {
+	return &linearConstraint{expression: a.minus(b), relation: relationEqual, strength: strength}
+}
+
+// lessOrEqual は a <= b の制約を返します
+func lessOrEqual(a, b expression, strength float64) *linearConstraint {
+	return &linearConstraint{expression: a.minus(b), relation: relationLessOrEqual, strength: strength}
+}
+
+// greaterOrEqual は a >= b の制約を返します
+func greaterOrEqual(a, b expression, strength float64) *linearConstraint {
+	return &linearConstraint{expression: a.minus(b), relation: relationGreaterOrEqual, strength: strength}
+}
+
+// symbolKind はシンプレックス表の記号の種類です
+type symbolKind int
+
+const (
+	symbolInvalid symbolKind = iota
+	symbolExternal
+	symbolSlack
+	symbolError
+	symbolDummy
+)
+
+// symbol はシンプレックス表の記号です
+// id は生成順の番号で、同じ条件の候補から常に同じ記号を選ぶために使用します
+type symbol struct {
+	id   int
+	kind symbolKind
+}
+
+// tableRow は「基底変数 = constant + Σ cells」の形のシンプレックス表の行です
+type tableRow struct {
+	constant float64
+	cells    map[symbol]float64
+}
+
+// nearZero は値が誤差の範囲で 0 かを判定します
+func nearZero(value float64) bool {
+	return math.Abs(value) < 1e-8
+}
+
+func newTableRow(constant float64) *tableRow {
+	return &tableRow{constant: constant, cells: make(map[symbol]float64)}
+}
+
+// copy は行の複製を返します
+func (r *tableRow) copy() *tableRow {
+	result := newTableRow(r.constant)
+	for s, c := range r.cells {
+		result.cells[s] = c
+	}
+	return result
+}
+
+// insertSymbol は記号の係数を加えます
+func (r *tableRow) insertSymbol(s symbol, coefficient float64) {
+	value := r.cells[s] + coefficient
+	if nearZero(value) {
+		delete(r.cells, s)
+		return
+	}
+	r.cells[s] = value
+}
+
+// insertRow は別の行を coefficient 倍して加えます
+func (r *tableRow) insertRow(other *tableRow, coefficient float64) {
+	r.constant += other.constant * coefficient
+	for s, c := range other.cells {
+		r.insertSymbol(s, c*coefficient)
+	}
+}
+
+// reverseSign は行の符号を反転します
+func (r *tableRow) reverseSign() {
+	r.constant = -r.constant
+	for s, c := range r.cells {
+		r.cells[s] = -c
+	}
+}
+
+// solveFor は行を s について解きます（s は行から取り除かれます）
+func (r *tableRow) solveFor(s symbol) {
+	coefficient := -1 / r.cells[s]
+	delete(r.cells, s)
+	r.constant *= coefficient
+	for other, c := range r.cells {
+		r.cells[other] = c * coefficient
+	}
+}
+
+// solveForPair は「lhs = 行」を rhs について解きます
+func (r *tableRow) solveForPair(lhs, rhs symbol) {
+	r.insertSymbol(lhs, -1)
+	r.solveFor(rhs)
+}
+
+// substitute は行に含まれる s を other で置き換えます
+func (r *tableRow) substitute(s symbol, other *tableRow) {
+	if coefficient, ok := r.cells[s]; ok {
+		delete(r.cells, s)
+		r.insertRow(other, coefficient)
+	}
+}
+
+// firstSymbol は条件を満たす記号のうち最も先に生成されたものを返します
+func (r *tableRow) firstSymbol(accept func(s symbol, coefficient float64) bool) symbol {
+	result := symbol{}
+	for s, c := range r.cells {
+		if accept(s, c) && (result.kind == symbolInvalid || s.id < result.id) {
+			result = s
+		}
+	}
+	return result
+}
+
+// constraintTag は制約に対応する記号です
+type constraintTag struct {
+	marker symbol
+	other  symbol
+}
+
+// solver は Cassowary アルゴリズムによる線形制約ソルバーです
+type solver struct {
+	constraints map[*linearConstraint]constraintTag
+	rows        map[symbol]*tableRow
+	variables   map[*variable]symbol
+	objective   *tableRow
+	artificial  *tableRow
+	nextID      int
+}
+
+func newSolver() *solver {
+	return &solver{
+		constraints: make(map[*linearConstraint]constraintTag),
+		rows:        make(map[symbol]*tableRow),
+		variables:   make(map[*variable]symbol),
+		objective:   newTableRow(0),
+	}
+}
+
+// newSymbol は新しい記号を生成します
+func (s *solver) newSymbol(kind symbolKind) symbol {
+	s.nextID++
+	return symbol{id: s.nextID, kind: kind}
+}
+
+// solverState は制約を追加する前のシンプレックス表の状態です
+type solverState struct {
+	rows      map[symbol]*tableRow
+	variables map[*variable]symbol
+	objective *tableRow
+	nextID    int
+}
+
+// snapshot は現在の表の状態を複製します
+func (s *solver) snapshot() solverState {
+	state := solverState{
+		rows:      make(map[symbol]*tableRow, len(s.rows)),
+		variables: make(map[*variable]symbol, len(s.variables)),
+		objective: s.objective.copy(),
+		nextID:    s.nextID,
+	}
+	for sym, row := range s.rows {
+		state.rows[sym] = row.copy()
+	}
+	for v, sym := range s.variables {
+		state.variables[v] = sym
+	}
+	return state
+}
+
+// restore は snapshot で保存した状態に表を戻します
+func (s *solver) restore(state solverState) {
+	s.rows = state.rows
+	s.variables = state.variables
+	s.objective = state.objective
+	s.nextID = state.nextID
+	s.artificial = nil
+}
+
+// addConstraint は制約を追加します
+// required の制約が満たせない場合や目的関数が有界でなくなる場合はエラーを返し、
+// 表を追加前の状態に戻します（失敗した制約は追加されません）
+func (s *solver) addConstraint(c *linearConstraint) error {
+	if _, exists := s.constraints[c]; exists {
+		return nil
+	}
+
+	state := s.snapshot()
+	if err := s.insertConstraint(c); err != nil {
+		s.restore(state)
+		delete(s.constraints, c)
+		return err
+	}
+	return nil
+}
+
+// insertConstraint は制約の行を表に加えて最適化します
+// 失敗した場合の表の状態は不定なので、呼び出し側で元に戻す必要があります
+func (s *solver) insertConstraint(c *linearConstraint) error {
+	row, tag := s.createRow(c)
+	subject := s.chooseSubject(row, tag)
+	if subject.kind == symbolInvalid && allDummies(row) {
+		if !nearZero(row.constant) {
+			return errUnsatisfiable
+		}
+		subject = tag.marker
+	}
+
+	if subject.kind == symbolInvalid {
+		ok, err := s.addWithArtificialVariable(row)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errUnsatisfiable
+		}
+	} else {
+		row.solveFor(subject)
+		s.substitute(subject, row)
+		s.rows[subject] = row
+	}
+
+	s.constraints[c] = tag
+	return s.optimize(s.objective)
+}
+
+// updateVariables は解を変数の値に反映します
+func (s *solver) updateVariables() {
+	for v, sym := range s.variables {
+		if row, ok := s.rows[sym]; ok {
+			v.value = row.constant
+		} else {
+			v.value = 0
+		}
+	}
+}
+
+// variableSymbol は変数に対応する記号を返します
+func (s *solver) variableSymbol(v *variable) symbol {
+	if sym, ok := s.variables[v]; ok {
+		return sym
+	}
+	sym := s.newSymbol(symbolExternal)
+	s.variables[v] = sym
+	return sym
+}
+
+// createRow は制約からシンプレックス表の行を作成します
+func (s *solver) createRow(c *linearConstraint) (*tableRow, constraintTag) {
+	row := newTableRow(c.expression.constant)
+	for _, t := range c.expression.terms {
+		if nearZero(t.coefficient) {
+			continue
+		}
+		sym := s.variableSymbol(t.variable)
+		if basic, ok := s.rows[sym]; ok {
+			row.insertRow(basic, t.coefficient)
+		} else {
+			row.insertSymbol(sym, t.coefficient)
+		}
+	}
+
+	tag := constraintTag{}
+	switch c.relation {
+	case relationLessOrEqual, relationGreaterOrEqual:
+		coefficient := 1.0
+		if c.relation == relationGreaterOrEqual {
+			coefficient = -1
+		}
+		tag.marker = s.newSymbol(symbolSlack)
+		row.insertSymbol(tag.marker, coefficient)
+		if c.strength < strengthRequired {
+			tag.other = s.newSymbol(symbolError)
+			row.insertSymbol(tag.other, -coefficient)
+			s.objective.insertSymbol(tag.other, c.strength)
+		}
+	case relationEqual:
+		if c.strength < strengthRequired {
+			tag.marker = s.newSymbol(symbolError)
+			tag.other = s.newSymbol(symbolError)
+			row.insertSymbol(tag.marker, -1)
+			row.insertSymbol(tag.other, 1)
+			s.objective.insertSymbol(tag.marker, c.strength)
+			s.objective.insertSymbol(tag.other, c.strength)
+		} else {
+			tag.marker = s.newSymbol(symbolDummy)
+			row.insertSymbol(tag.marker, 1)
+		}
+	}
+
+	if row.constant < 0 {
+		row.reverseSign()
+	}
+	return row, tag
+}
+
+// chooseSubject は行を解く対象の記号を選びます
+func (s *solver) chooseSubject(row *tableRow, tag constraintTag) symbol {
+	external := row.firstSymbol(func(sym symbol, _ float64) bool {
+		return sym.kind == symbolExternal
+	})
+	if external.kind != symbolInvalid {
+		return external
+	}
+	for _, sym := range []symbol{tag.marker, tag.other} {
+		if sym.kind == symbolSlack || sym.kind == symbolError {
+			if row.cells[sym] < 0 {
+				return sym
+			}
+		}
+	}
+	return symbol{}
+}
+
+// allDummies は行がダミーの記号だけで構成されているかを判定します
+func allDummies(row *tableRow) bool {
+	for sym := range row.cells {
+		if sym.kind != symbolDummy {
+			return false
+		}
+	}
+	return true
+}
+
+// addWithArtificialVariable は人為変数を使って行を表に追加します
+func (s *solver) addWithArtificialVariable(row *tableRow) (bool, error) {
+	art := s.newSymbol(symbolSlack)
+	s.rows[art] = row.copy()
+	s.artificial = row.copy()
+
+	if err := s.optimize(s.artificial); err != nil {
+		s.artificial = nil
+		return false, err
+	}
+	success := nearZero(s.artificial.constant)
+	s.artificial = nil
+
+	if basic, ok := s.rows[art]; ok {
+		delete(s.rows, art)
+		if len(basic.cells) == 0 {
+			return success, nil
+		}
+		entering := basic.firstSymbol(func(sym symbol, _ float64) bool {
+			return sym.kind == symbolSlack || sym.kind == symbolError
+		})
+		if entering.kind == symbolInvalid {
+			return false, nil
+		}
+		basic.solveForPair(art, entering)
+		s.substitute(entering, basic)
+		s.rows[entering] = basic
+	}
+
+	for _, r := range s.rows {
+		delete(r.cells, art)
+	}
+	delete(s.objective.cells, art)
+	return success, nil
+}
+
+// substitute は表全体の sym を row で置き換えます
+func (s *solver) substitute(sym symbol, row *tableRow) {
+	for _, r := range s.rows {
+		r.substitute(sym, row)
+	}
+	s.objective.substitute(sym, row)
+	if s.artificial != nil {
+		s.artificial.substitute(sym, row)
+	}
+}
+
+// optimize は目的関数が最小になるまでピボットを繰り返します
+func (s *solver) optimize(objective *tableRow) error {
+	for {
+		entering := objective.firstSymbol(func(sym symbol, coefficient float64) bool {
+			return sym.kind != symbolDummy && coefficient < 0
+		})
+		if entering.kind == symbolInvalid {
+			return nil
+		}
+
+		leaving := symbol{}
+		ratio := math.MaxFloat64
+		for sym, row := range s.rows {
+			if sym.kind == symbolExternal {
+				continue
+			}
+			coefficient := row.cells[entering]
+			if coefficient >= 0 {
+				continue
+			}
+			r := -row.constant / coefficient
+			if r < ratio || (r == ratio && sym.id < leaving.id) {
+				ratio = r
+				leaving = sym
+			}
+		}
+		if leaving.kind == symbolInvalid {
+			return errUnbounded
+		}
+
+		row := s.rows[leaving]
+		delete(s.rows, leaving)
+		row.solveForPair(leaving, entering)
+		s.substitute(entering, row)
+		s.rows[entering] = row
+	}
+}
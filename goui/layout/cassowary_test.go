@@ -0,0 +1,185 @@
+package layout
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// constantOf は定数の式を返す短縮形です
+func constantOf(c float64) expression {
+	return constantExpression(c)
+}
+
+func TestSolverStrengths(t *testing.T) {
+	x := &variable{name: "x"}
+	y := &variable{name: "y"}
+	vx, vy := variableExpression(x), variableExpression(y)
+
+	tests := []struct {
+		name        string
+		constraints []*linearConstraint
+		wantX       float64
+		wantY       float64
+	}{
+		{
+			"required bound beats weak preference",
+			[]*linearConstraint{
+				greaterOrEqual(vx, constantOf(10), strengthRequired),
+				equal(vx, constantOf(0), strengthWeak),
+			},
+			10, 0,
+		},
+		{
+			"stronger preference wins",
+			[]*linearConstraint{
+				equal(vx, constantOf(20), strengthWeak),
+				equal(vx, constantOf(5), strengthStrong),
+				equal(vx, constantOf(8), strengthMedium),
+			},
+			5, 0,
+		},
+		{
+			"linked variables",
+			[]*linearConstraint{
+				equal(vy, vx.add(3), strengthRequired),
+				equal(vx.plus(vy), constantOf(11), strengthRequired),
+			},
+			4, 7,
+		},
+		{
+			"inequality range",
+			[]*linearConstraint{
+				lessOrEqual(vx, constantOf(6), strengthRequired),
+				greaterOrEqual(vx, constantOf(2), strengthRequired),
+				equal(vx, constantOf(100), strengthWeak),
+			},
+			6, 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x.value, y.value = 0, 0
+			s := newSolver()
+			for _, c := range tt.constraints {
+				if err := s.addConstraint(c); err != nil {
+					t.Fatalf("addConstraint: %v", err)
+				}
+			}
+			s.updateVariables()
+			if math.Abs(x.value-tt.wantX) > 1e-6 || math.Abs(y.value-tt.wantY) > 1e-6 {
+				t.Errorf("x, y = %v, %v, want %v, %v", x.value, y.value, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestSolverRejectsUnsatisfiableConstraintAtomically(t *testing.T) {
+	x := &variable{name: "x"}
+	y := &variable{name: "y"}
+	z := &variable{name: "z"}
+	vx, vy, vz := variableExpression(x), variableExpression(y), variableExpression(z)
+
+	tests := []struct {
+		name    string
+		before  []*linearConstraint
+		invalid *linearConstraint
+		after   []*linearConstraint
+	}{
+		{
+			"conflicting equalities",
+			[]*linearConstraint{equal(vx, constantOf(10), strengthRequired)},
+			equal(vx, constantOf(20), strengthRequired),
+			[]*linearConstraint{equal(vy, vx.add(1), strengthRequired)},
+		},
+		{
+			"empty range",
+			[]*linearConstraint{
+				greaterOrEqual(vx, constantOf(10), strengthRequired),
+				equal(vx, constantOf(0), strengthWeak),
+			},
+			lessOrEqual(vx, constantOf(5), strengthRequired),
+			[]*linearConstraint{lessOrEqual(vy, vx, strengthRequired), equal(vy, constantOf(50), strengthWeak)},
+		},
+		{
+			"conflict through another variable",
+			[]*linearConstraint{
+				equal(vy, vx.add(5), strengthRequired),
+				greaterOrEqual(vx, constantOf(0), strengthRequired),
+				lessOrEqual(vy, constantOf(8), strengthRequired),
+				equal(vx, constantOf(1), strengthMedium),
+			},
+			greaterOrEqual(vx, constantOf(4), strengthRequired),
+			[]*linearConstraint{equal(vz, vy.times(2), strengthRequired)},
+		},
+		{
+			"variables of the rejected constraint are discarded",
+			[]*linearConstraint{equal(vx, constantOf(3), strengthRequired)},
+			equal(vz.minus(vz), constantOf(1), strengthRequired),
+			[]*linearConstraint{equal(vz, vx.add(2), strengthStrong)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 矛盾する制約を挟んだ場合と挟まない場合で同じ解になる
+			expected := newSolver()
+			for _, c := range append(append([]*linearConstraint{}, tt.before...), tt.after...) {
+				if err := expected.addConstraint(c); err != nil {
+					t.Fatalf("addConstraint: %v", err)
+				}
+			}
+			expected.updateVariables()
+			want := [3]float64{x.value, y.value, z.value}
+
+			s := newSolver()
+			for _, c := range tt.before {
+				if err := s.addConstraint(c); err != nil {
+					t.Fatalf("addConstraint: %v", err)
+				}
+			}
+			if err := s.addConstraint(tt.invalid); !errors.Is(err, errUnsatisfiable) {
+				t.Fatalf("addConstraint(invalid) = %v, want %v", err, errUnsatisfiable)
+			}
+			if _, exists := s.constraints[tt.invalid]; exists {
+				t.Error("rejected constraint is still registered")
+			}
+			if _, exists := s.variables[z]; exists {
+				t.Error("variable of the rejected constraint is still registered")
+			}
+			for _, c := range tt.after {
+				if err := s.addConstraint(c); err != nil {
+					t.Fatalf("addConstraint after rejection: %v", err)
+				}
+			}
+			s.updateVariables()
+			got := [3]float64{x.value, y.value, z.value}
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-6 {
+					t.Fatalf("x, y, z = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSolverConflictingPreferences(t *testing.T) {
+	// 同じ強度の矛盾する制約はエラーにならず、どちらかを満たす範囲で解かれる
+	x := &variable{name: "x"}
+	vx := variableExpression(x)
+	s := newSolver()
+	for _, c := range []*linearConstraint{
+		equal(vx, constantOf(10), strengthStrong),
+		equal(vx, constantOf(20), strengthStrong),
+		lessOrEqual(vx, constantOf(15), strengthRequired),
+	} {
+		if err := s.addConstraint(c); err != nil {
+			t.Fatalf("addConstraint: %v", err)
+		}
+	}
+	s.updateVariables()
+	if x.value < 10-1e-6 || x.value > 15+1e-6 {
+		t.Errorf("x = %v, want within [10, 15]", x.value)
+	}
+}
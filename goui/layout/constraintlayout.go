@@ -0,0 +1,748 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tak/goui/core"
+)
+
+// ConstraintSetKey は ConstraintLayoutNodeType のノードの Props に ConstraintSet を格納するキーです
+const ConstraintSetKey = "constraintSet"
+
+// dimensionKind は ConstraintLayout の子ノードのサイズの決め方です
+type dimensionKind int
+
+const (
+	dimensionWrap dimensionKind = iota
+	dimensionFixed
+	dimensionFill
+)
+
+// Dimension は ConstraintLayout の子ノードの幅または高さの指定です
+type Dimension struct {
+	kind  dimensionKind
+	value float64
+}
+
+// WrapContent は子ノードの大きさに合わせるサイズを返します（既定値）
+func WrapContent() Dimension {
+	return Dimension{kind: dimensionWrap}
+}
+
+// FixedDimension は固定サイズを返します
+func FixedDimension(size float64) Dimension {
+	return Dimension{kind: dimensionFixed, value: size}
+}
+
+// FillToConstraints は両側のアンカーの間いっぱいに広げるサイズを返します
+// 片側しかリンクされていない場合は WrapContent と同じです
+func FillToConstraints() Dimension {
+	return Dimension{kind: dimensionFill}
+}
+
+// anchorLine はアンカーが指す位置を、解いている軸の式に変換します
+type anchorLine interface {
+	expression(system *axisSystem) expression
+}
+
+// VerticalAnchor は水平方向の位置を表す垂直な線（参照の左右の辺、ガイドライン、バリア）です
+type VerticalAnchor struct {
+	line anchorLine
+}
+
+// HorizontalAnchor は垂直方向の位置を表す水平な線（参照の上下の辺、ガイドライン、バリア）です
+type HorizontalAnchor struct {
+	line anchorLine
+}
+
+// anchorLink は参照の辺とアンカーの間のリンクです
+type anchorLink struct {
+	line   anchorLine
+	margin float64
+}
+
+// ConstrainedRef は ConstraintSet の中で子ノードを参照し、その辺のリンクとサイズを保持します
+// 子ノードとは LayoutID モディファイアの ID で対応付けられます
+type ConstrainedRef struct {
+	id     string
+	parent bool
+
+	// Width と Height は子ノードのサイズの決め方です
+	Width  Dimension
+	Height Dimension
+	// HorizontalBias と VerticalBias は両側がリンクされているときの位置の偏りです
+	// 0 で開始側、1 で終了側、既定値の 0.5 で中央に配置されます
+	HorizontalBias float64
+	VerticalBias   float64
+
+	start, end, top, bottom *anchorLink
+}
+
+// refSide は参照の辺です
+type refSide struct {
+	ref *ConstrainedRef
+	end bool
+}
+
+// expression は辺の位置を返します（親の開始側は 0、終了側は親のサイズです）
+func (s refSide) expression(system *axisSystem) expression {
+	if s.ref.parent {
+		if s.end {
+			return variableExpression(system.size)
+		}
+		return constantExpression(0)
+	}
+	vars := system.refVariables(s.ref)
+	if s.end {
+		return variableExpression(vars.hi)
+	}
+	return variableExpression(vars.lo)
+}
+
+// Start は左辺のアンカーを返します
+func (r *ConstrainedRef) Start() VerticalAnchor {
+	return VerticalAnchor{line: refSide{ref: r}}
+}
+
+// End は右辺のアンカーを返します
+func (r *ConstrainedRef) End() VerticalAnchor {
+	return VerticalAnchor{line: refSide{ref: r, end: true}}
+}
+
+// Top は上辺のアンカーを返します
+func (r *ConstrainedRef) Top() HorizontalAnchor {
+	return HorizontalAnchor{line: refSide{ref: r}}
+}
+
+// Bottom は下辺のアンカーを返します
+func (r *ConstrainedRef) Bottom() HorizontalAnchor {
+	return HorizontalAnchor{line: refSide{ref: r, end: true}}
+}
+
+// LinkStart は左辺を anchor から margin だけ右に置きます
+func (r *ConstrainedRef) LinkStart(anchor VerticalAnchor, margin float64) *ConstrainedRef {
+	r.start = &anchorLink{line: anchor.line, margin: margin}
+	return r
+}
+
+// LinkEnd は右辺を anchor から margin だけ左に置きます
+func (r *ConstrainedRef) LinkEnd(anchor VerticalAnchor, margin float64) *ConstrainedRef {
+	r.end = &anchorLink{line: anchor.line, margin: margin}
+	return r
+}
+
+// LinkTop は上辺を anchor から margin だけ下に置きます
+func (r *ConstrainedRef) LinkTop(anchor HorizontalAnchor, margin float64) *ConstrainedRef {
+	r.top = &anchorLink{line: anchor.line, margin: margin}
+	return r
+}
+
+// LinkBottom は下辺を anchor から margin だけ上に置きます
+func (r *ConstrainedRef) LinkBottom(anchor HorizontalAnchor, margin float64) *ConstrainedRef {
+	r.bottom = &anchorLink{line: anchor.line, margin: margin}
+	return r
+}
+
+// CenterHorizontallyTo は左右の辺を other の左右の辺にリンクし、水平方向の中央に置きます
+func (r *ConstrainedRef) CenterHorizontallyTo(other *ConstrainedRef) *ConstrainedRef {
+	return r.LinkStart(other.Start(), 0).LinkEnd(other.End(), 0)
+}
+
+// CenterVerticallyTo は上下の辺を other の上下の辺にリンクし、垂直方向の中央に置きます
+func (r *ConstrainedRef) CenterVerticallyTo(other *ConstrainedRef) *ConstrainedRef {
+	return r.LinkTop(other.Top(), 0).LinkBottom(other.Bottom(), 0)
+}
+
+// CenterTo は other の中央に置きます
+func (r *ConstrainedRef) CenterTo(other *ConstrainedRef) *ConstrainedRef {
+	return r.CenterHorizontallyTo(other).CenterVerticallyTo(other)
+}
+
+// axisLinks は軸の開始側と終了側のリンク、サイズ、偏りを返します
+func (r *ConstrainedRef) axisLinks(vertical bool) (lo, hi *anchorLink, dimension Dimension, bias float64) {
+	if vertical {
+		return r.top, r.bottom, r.Height, r.VerticalBias
+	}
+	return r.start, r.end, r.Width, r.HorizontalBias
+}
+
+// guideline は親の辺からの距離または割合で位置が決まる線です
+type guideline struct {
+	offset   float64
+	fraction float64
+	fromEnd  bool
+	relative bool
+}
+
+// expression はガイドラインの位置を返します
+func (g guideline) expression(system *axisSystem) expression {
+	size := variableExpression(system.size)
+	switch {
+	case g.relative:
+		return size.times(g.fraction)
+	case g.fromEnd:
+		return size.add(-g.offset)
+	default:
+		return constantExpression(g.offset)
+	}
+}
+
+// barrier は複数の参照の辺のうち最も外側にある位置の線です
+type barrier struct {
+	refs []*ConstrainedRef
+	end  bool
+}
+
+// expression はバリアの位置を返します
+func (b *barrier) expression(system *axisSystem) expression {
+	return variableExpression(system.barrierVariable(b))
+}
+
+// ChainStyle はチェーンの中で空きを分配する方法です
+type ChainStyle struct {
+	kind chainKind
+	bias float64
+}
+
+type chainKind int
+
+const (
+	chainSpread chainKind = iota
+	chainSpreadInside
+	chainPacked
+)
+
+var (
+	// ChainSpread は両端を含むすべての間隔を均等にします
+	ChainSpread = ChainStyle{kind: chainSpread}
+	// ChainSpreadInside は両端の参照をアンカーに付け、内側の間隔を均等にします
+	ChainSpreadInside = ChainStyle{kind: chainSpreadInside}
+	// ChainPacked は参照を詰めて並べ、まとめて中央に置きます
+	ChainPacked = ChainStyle{kind: chainPacked, bias: 0.5}
+)
+
+// ChainPackedWithBias は参照を詰めて並べ、まとめて bias の位置に置くスタイルを返します
+func ChainPackedWithBias(bias float64) ChainStyle {
+	return ChainStyle{kind: chainPacked, bias: bias}
+}
+
+// constraintChain は一方向に並べる参照のチェーンです
+type constraintChain struct {
+	vertical bool
+	style    ChainStyle
+	refs     []*ConstrainedRef
+}
+
+// ConstraintSet は ConstraintLayout の子ノードの間の制約の集合です
+//
+// 参照の辺を他の参照、親、ガイドライン、バリアのアンカーにリンクして位置を決めます。
+// 両側がリンクされた参照はその間に偏り（既定では中央）に従って配置され、
+// リンクされていない辺は親の開始側に置かれます。
+// 矛盾するリンクは可能な限り満たすように解かれます。
+type ConstraintSet struct {
+	parent *ConstrainedRef
+	refs   []*ConstrainedRef
+	chains []constraintChain
+}
+
+// NewConstraintSet は空の制約の集合を作成します
+func NewConstraintSet() *ConstraintSet {
+	return &ConstraintSet{parent: &ConstrainedRef{parent: true}}
+}
+
+// Parent は ConstraintLayout 自身を表す参照を返します
+func (s *ConstraintSet) Parent() *ConstrainedRef {
+	return s.parent
+}
+
+// Ref は LayoutID が id の子ノードの参照を返します（初めて参照するときに作成されます）
+func (s *ConstraintSet) Ref(id string) *ConstrainedRef {
+	if ref := s.find(id); ref != nil {
+		return ref
+	}
+	ref := &ConstrainedRef{id: id, HorizontalBias: 0.5, VerticalBias: 0.5}
+	s.refs = append(s.refs, ref)
+	return ref
+}
+
+// find は id の参照を返します
+func (s *ConstraintSet) find(id string) *ConstrainedRef {
+	for _, ref := range s.refs {
+		if ref.id == id {
+			return ref
+		}
+	}
+	return nil
+}
+
+// GuidelineFromStart は親の左辺から offset の位置の垂直なガイドラインを返します
+func (s *ConstraintSet) GuidelineFromStart(offset float64) VerticalAnchor {
+	return VerticalAnchor{line: guideline{offset: offset}}
+}
+
+// GuidelineFromEnd は親の右辺から offset の位置の垂直なガイドラインを返します
+func (s *ConstraintSet) GuidelineFromEnd(offset float64) VerticalAnchor {
+	return VerticalAnchor{line: guideline{offset: offset, fromEnd: true}}
+}
+
+// GuidelineFromStartFraction は親の幅に対する割合の位置の垂直なガイドラインを返します
+func (s *ConstraintSet) GuidelineFromStartFraction(fraction float64) VerticalAnchor {
+	return VerticalAnchor{line: guideline{fraction: fraction, relative: true}}
+}
+
+// GuidelineFromTop は親の上辺から offset の位置の水平なガイドラインを返します
+func (s *ConstraintSet) GuidelineFromTop(offset float64) HorizontalAnchor {
+	return HorizontalAnchor{line: guideline{offset: offset}}
+}
+
+// GuidelineFromBottom は親の下辺から offset の位置の水平なガイドラインを返します
+func (s *ConstraintSet) GuidelineFromBottom(offset float64) HorizontalAnchor {
+	return HorizontalAnchor{line: guideline{offset: offset, fromEnd: true}}
+}
+
+// GuidelineFromTopFraction は親の高さに対する割合の位置の水平なガイドラインを返します
+func (s *ConstraintSet) GuidelineFromTopFraction(fraction float64) HorizontalAnchor {
+	return HorizontalAnchor{line: guideline{fraction: fraction, relative: true}}
+}
+
+// StartBarrier は refs の左辺のうち最も左の位置のバリアを返します
+func (s *ConstraintSet) StartBarrier(refs ...*ConstrainedRef) VerticalAnchor {
+	return VerticalAnchor{line: &barrier{refs: refs}}
+}
+
+// EndBarrier は refs の右辺のうち最も右の位置のバリアを返します
+func (s *ConstraintSet) EndBarrier(refs ...*ConstrainedRef) VerticalAnchor {
+	return VerticalAnchor{line: &barrier{refs: refs, end: true}}
+}
+
+// TopBarrier は refs の上辺のうち最も上の位置のバリアを返します
+func (s *ConstraintSet) TopBarrier(refs ...*ConstrainedRef) HorizontalAnchor {
+	return HorizontalAnchor{line: &barrier{refs: refs}}
+}
+
+// BottomBarrier は refs の下辺のうち最も下の位置のバリアを返します
+func (s *ConstraintSet) BottomBarrier(refs ...*ConstrainedRef) HorizontalAnchor {
+	return HorizontalAnchor{line: &barrier{refs: refs, end: true}}
+}
+
+// HorizontalChain は refs を水平方向のチェーンにします
+//
+// チェーンは先頭の参照の左辺のリンク（なければ親の左辺）から末尾の参照の右辺のリンク（なければ親の右辺）までの間に
+// style に従って参照を並べます。途中の参照の左右のリンクはマージンだけが使用されます。
+// FillToConstraints の幅の参照を含む場合は、間隔を空けずにそれらの参照で残りを均等に分け合います。
+func (s *ConstraintSet) HorizontalChain(style ChainStyle, refs ...*ConstrainedRef) {
+	s.chains = append(s.chains, constraintChain{style: style, refs: refs})
+}
+
+// VerticalChain は refs を垂直方向のチェーンにします（HorizontalChain を参照）
+func (s *ConstraintSet) VerticalChain(style ChainStyle, refs ...*ConstrainedRef) {
+	s.chains = append(s.chains, constraintChain{vertical: true, style: style, refs: refs})
+}
+
+// axisVariables は参照の開始側と終了側の位置の変数です
+type axisVariables struct {
+	lo, hi *variable
+}
+
+// axisSystem は1つの軸について参照の位置を解く制約の系です
+type axisSystem struct {
+	vertical  bool
+	solver    *solver
+	size      *variable
+	refs      map[*ConstrainedRef]axisVariables
+	barriers  map[*barrier]*variable
+	fillSizes map[*ConstrainedRef]bool
+	// dropped は他の制約と矛盾するため破棄した制約です
+	dropped []*linearConstraint
+}
+
+// refVariables は参照の位置の変数を返します
+func (a *axisSystem) refVariables(ref *ConstrainedRef) axisVariables {
+	if vars, ok := a.refs[ref]; ok {
+		return vars
+	}
+	// 子ノードのない参照は大きさのない点として扱う
+	vars := axisVariables{lo: &variable{name: ref.id + ".lo"}, hi: &variable{name: ref.id + ".hi"}}
+	a.refs[ref] = vars
+	a.add(equal(variableExpression(vars.hi), variableExpression(vars.lo), strengthRequired))
+	return vars
+}
+
+// barrierVariable はバリアの位置の変数を返します
+func (a *axisSystem) barrierVariable(b *barrier) *variable {
+	if v, ok := a.barriers[b]; ok {
+		return v
+	}
+	v := &variable{name: "barrier"}
+	a.barriers[b] = v
+	position := variableExpression(v)
+	for _, ref := range b.refs {
+		if b.end {
+			a.add(greaterOrEqual(position, refSide{ref: ref, end: true}.expression(a), strengthRequired))
+		} else {
+			a.add(lessOrEqual(position, refSide{ref: ref}.expression(a), strengthRequired))
+		}
+	}
+	// 参照の辺にできるだけ近づける
+	if b.end {
+		a.add(equal(position, constantExpression(0), strengthWeak))
+	} else {
+		a.add(equal(position, variableExpression(a.size), strengthWeak))
+	}
+	return v
+}
+
+// add は制約を追加します
+// 満たせない制約はソルバーが追加前の状態に戻して破棄し、dropped に記録します
+func (a *axisSystem) add(c *linearConstraint) {
+	if err := a.solver.addConstraint(c); err != nil {
+		a.dropped = append(a.dropped, c)
+	}
+}
+
+// linkPosition はリンクのアンカーにマージンを加えた位置を返します
+// リンクがない場合は親の開始側または終了側の位置です
+func (a *axisSystem) linkPosition(link *anchorLink, end bool) expression {
+	if link == nil {
+		return refSide{ref: &ConstrainedRef{parent: true}, end: end}.expression(a)
+	}
+	if end {
+		return link.line.expression(a).add(-link.margin)
+	}
+	return link.line.expression(a).add(link.margin)
+}
+
+// solveConstraintAxis は子ノードのサイズ sizes を使って1つの軸の位置を解き、
+// 各参照の位置とサイズ、親のサイズを返します
+func solveConstraintAxis(
+	set *ConstraintSet,
+	refs []*ConstrainedRef,
+	sizes []float64,
+	vertical bool,
+	minSize, maxSize float64,
+) (positions, resolved []float64, size float64) {
+	system := &axisSystem{
+		vertical:  vertical,
+		solver:    newSolver(),
+		size:      &variable{name: "parent"},
+		refs:      make(map[*ConstrainedRef]axisVariables),
+		barriers:  make(map[*barrier]*variable),
+		fillSizes: make(map[*ConstrainedRef]bool),
+	}
+	parentSize := variableExpression(system.size)
+
+	// 親のサイズは制約の範囲でできるだけ小さくする
+	system.add(greaterOrEqual(parentSize, constantExpression(minSize), strengthRequired))
+	if !math.IsInf(maxSize, 1) {
+		system.add(lessOrEqual(parentSize, constantExpression(maxSize), strengthRequired))
+	}
+	system.add(equal(parentSize, constantExpression(0), strengthWeak))
+
+	chained := make(map[*ConstrainedRef]bool)
+	for _, chain := range set.chains {
+		if chain.vertical == vertical {
+			for _, ref := range chain.refs {
+				chained[ref] = true
+			}
+		}
+	}
+
+	// 各参照のサイズを決め、親が参照を含むように広げる
+	for i, ref := range refs {
+		vars := axisVariables{lo: &variable{name: ref.id + ".lo"}, hi: &variable{name: ref.id + ".hi"}}
+		system.refs[ref] = vars
+		lo, hi := variableExpression(vars.lo), variableExpression(vars.hi)
+
+		loLink, hiLink, dimension, _ := ref.axisLinks(vertical)
+		fill := dimension.kind == dimensionFill && (chained[ref] || (loLink != nil && hiLink != nil))
+		if fill {
+			system.fillSizes[ref] = true
+			system.add(greaterOrEqual(hi, lo, strengthRequired))
+		} else {
+			system.add(equal(hi.minus(lo), constantExpression(sizes[i]), strengthRequired))
+		}
+		system.add(greaterOrEqual(parentSize, hi, strengthMedium))
+		system.add(greaterOrEqual(lo, constantExpression(0), strengthMedium))
+	}
+
+	// チェーンに含まれない参照をリンクに従って配置する
+	for i, ref := range refs {
+		if chained[ref] {
+			continue
+		}
+		vars := system.refs[ref]
+		lo, hi := variableExpression(vars.lo), variableExpression(vars.hi)
+		loLink, hiLink, _, bias := ref.axisLinks(vertical)
+		switch {
+		case loLink != nil && hiLink != nil:
+			begin := system.linkPosition(loLink, false)
+			end := system.linkPosition(hiLink, true)
+			if system.fillSizes[ref] {
+				system.add(equal(lo, begin, strengthStrong))
+				system.add(equal(hi, end, strengthStrong))
+			} else {
+				// lo - begin == bias * (end - begin - size)
+				space := end.minus(begin).add(-sizes[i])
+				system.add(equal(lo.minus(begin), space.times(bias), strengthStrong))
+			}
+		case hiLink != nil:
+			system.add(equal(hi, system.linkPosition(hiLink, true), strengthStrong))
+		default:
+			system.add(equal(lo, system.linkPosition(loLink, false), strengthStrong))
+		}
+	}
+
+	for _, chain := range set.chains {
+		if chain.vertical == vertical && len(chain.refs) > 0 {
+			system.addChain(chain)
+		}
+	}
+
+	system.solver.updateVariables()
+	positions = make([]float64, len(refs))
+	resolved = make([]float64, len(refs))
+	for i, ref := range refs {
+		vars := system.refs[ref]
+		positions[i] = vars.lo.value
+		resolved[i] = sizes[i]
+		if system.fillSizes[ref] {
+			resolved[i] = max(vars.hi.value-vars.lo.value, 0)
+		}
+	}
+	return positions, resolved, system.size.value
+}
+
+// addChain はチェーンの参照を並べる制約を追加します
+func (a *axisSystem) addChain(chain constraintChain) {
+	refs := chain.refs
+	gaps := make([]expression, len(refs)+1)
+	for i := range gaps {
+		gaps[i] = variableExpression(&variable{name: "gap"})
+	}
+
+	head, _, _, _ := refs[0].axisLinks(a.vertical)
+	_, tail, _, _ := refs[len(refs)-1].axisLinks(a.vertical)
+	begin := a.linkPosition(head, false)
+	end := a.linkPosition(tail, true)
+
+	// 参照の辺を間隔でつなぐ
+	previous := begin
+	hasFill := false
+	for i, ref := range refs {
+		vars := a.refVariables(ref)
+		margin := 0.0
+		if i > 0 {
+			lo, _, _, _ := ref.axisLinks(a.vertical)
+			_, hi, _, _ := refs[i-1].axisLinks(a.vertical)
+			if lo != nil {
+				margin += lo.margin
+			}
+			if hi != nil {
+				margin += hi.margin
+			}
+		}
+		a.add(equal(variableExpression(vars.lo), previous.plus(gaps[i]).add(margin), strengthStrong))
+		previous = variableExpression(vars.hi)
+		hasFill = hasFill || a.fillSizes[ref]
+	}
+	a.add(equal(end, previous.plus(gaps[len(refs)]), strengthStrong))
+
+	last := len(gaps) - 1
+	zero := constantExpression(0)
+	switch {
+	case hasFill:
+		// 間隔を空けずに FillToConstraints の参照で均等に分け合う
+		for _, gap := range gaps {
+			a.add(equal(gap, zero, strengthRequired))
+		}
+		var first expression
+		found := false
+		for _, ref := range refs {
+			if !a.fillSizes[ref] {
+				continue
+			}
+			vars := a.refVariables(ref)
+			size := variableExpression(vars.hi).minus(variableExpression(vars.lo))
+			if found {
+				a.add(equal(size, first, strengthRequired))
+			} else {
+				first, found = size, true
+			}
+		}
+	case chain.style.kind == chainSpread:
+		for i := 1; i < len(gaps); i++ {
+			a.add(equal(gaps[i], gaps[0], strengthRequired))
+		}
+	case chain.style.kind == chainSpreadInside:
+		a.add(equal(gaps[0], zero, strengthRequired))
+		a.add(equal(gaps[last], zero, strengthRequired))
+		for i := 2; i < last; i++ {
+			a.add(equal(gaps[i], gaps[1], strengthRequired))
+		}
+	default:
+		for i := 1; i < last; i++ {
+			a.add(equal(gaps[i], zero, strengthRequired))
+		}
+		// gaps[0] == bias * (gaps[0] + gaps[last])
+		bias := chain.style.bias
+		a.add(equal(gaps[0].times(1-bias), gaps[last].times(bias), strengthRequired))
+	}
+}
+
+// LayoutIDOf は子ノードに LayoutID モディファイアで設定された ID を返します
+func LayoutIDOf(child Measurable) string {
+	return layoutIDOf(child.Props())
+}
+
+// layoutIDOf はプロパティのモディファイアに含まれる LayoutID を返します
+func layoutIDOf(props core.Props) string {
+	for _, element := range props.GetModifier().Elements() {
+		if id, ok := element.(core.LayoutIDModifier); ok {
+			return id.ID
+		}
+	}
+	return ""
+}
+
+// DuplicateLayoutID は ConstraintLayout の子ノード間での LayoutID の重複を表す診断情報です
+// 重複した ID の子ノードは最初のものだけが制約の参照に対応付けられます
+type DuplicateLayoutID struct {
+	Parent  core.NodeID
+	ID      string
+	Indices []int
+}
+
+// String は診断情報の文字列表現を返します
+func (d DuplicateLayoutID) String() string {
+	return fmt.Sprintf("duplicate layout ID %q among children of %s at indices %v", d.ID, d.Parent, d.Indices)
+}
+
+// FindDuplicateLayoutIDs はツリー全体の ConstraintLayout から子ノード間で重複している LayoutID を検出します
+// LayoutID のない子ノードは重複とみなしません
+func FindDuplicateLayoutIDs(root *core.Node) []DuplicateLayoutID {
+	duplicates := []DuplicateLayoutID{}
+	if root == nil {
+		return duplicates
+	}
+	return findDuplicateLayoutIDs(root, core.RootNodeID(root), duplicates)
+}
+
+// findDuplicateLayoutIDs はノード以下を再帰的に検査します
+func findDuplicateLayoutIDs(node *core.Node, id core.NodeID, duplicates []DuplicateLayoutID) []DuplicateLayoutID {
+	if node.Type == core.ConstraintLayoutNodeType {
+		indices := make(map[string][]int)
+		order := []string{}
+		for i, child := range node.Children {
+			layoutID := layoutIDOf(child.Props)
+			if layoutID == "" {
+				continue
+			}
+			if _, seen := indices[layoutID]; !seen {
+				order = append(order, layoutID)
+			}
+			indices[layoutID] = append(indices[layoutID], i)
+		}
+
+		for _, layoutID := range order {
+			if len(indices[layoutID]) > 1 {
+				duplicates = append(duplicates, DuplicateLayoutID{Parent: id, ID: layoutID, Indices: indices[layoutID]})
+			}
+		}
+	}
+
+	for i, child := range node.Children {
+		duplicates = findDuplicateLayoutIDs(child, core.ChildNodeID(id, child.Key, i), duplicates)
+	}
+	return duplicates
+}
+
+// ConstraintLayoutPolicy は ConstraintSet の制約を線形制約ソルバーで解いて子ノードを配置する MeasurePolicy です
+//
+// 水平方向の位置を解いてから FillToConstraints の幅の子ノードを測定し直し、
+// その高さを使って垂直方向の位置を解きます。
+// 大きさは制約の範囲ですべての子ノードを含む最小のサイズです。
+type ConstraintLayoutPolicy struct {
+	Set *ConstraintSet
+}
+
+// Measure は制約を解いて子ノードを測定します
+func (p ConstraintLayoutPolicy) Measure(measurables []Measurable, constraints Constraints) MeasureResult {
+	set := p.Set
+	if set == nil {
+		set = NewConstraintSet()
+	}
+
+	// 子ノードを参照に対応付ける（参照のない子ノードは左上に置く）
+	// 同じ LayoutID の子ノードが複数ある場合は最初の子ノードだけを対応付ける（FindDuplicateLayoutIDs で検出できます）
+	refs := make([]*ConstrainedRef, len(measurables))
+	assigned := make(map[*ConstrainedRef]bool)
+	for i, child := range measurables {
+		refs[i] = set.find(LayoutIDOf(child))
+		if refs[i] == nil || assigned[refs[i]] {
+			refs[i] = &ConstrainedRef{}
+		}
+		assigned[refs[i]] = true
+	}
+
+	dimensionConstraint := func(dimension Dimension, size, maxSize float64) (float64, float64) {
+		switch dimension.kind {
+		case dimensionFixed:
+			return dimension.value, dimension.value
+		case dimensionFill:
+			if size >= 0 {
+				return size, size
+			}
+		}
+		return 0, maxSize
+	}
+	measure := func(i int, width, height float64) Placeable {
+		minWidth, maxWidth := dimensionConstraint(refs[i].Width, width, constraints.MaxSize.Width)
+		minHeight, maxHeight := dimensionConstraint(refs[i].Height, height, constraints.MaxSize.Height)
+		return measurables[i].Measure(NewConstraints(minWidth, minHeight, maxWidth, maxHeight))
+	}
+
+	// 大きさに合わせて測定し、水平方向を解く
+	placeables := make([]Placeable, len(measurables))
+	widths := make([]float64, len(measurables))
+	for i := range measurables {
+		placeables[i] = measure(i, -1, -1)
+		widths[i] = placeables[i].Size().Width
+	}
+	xs, widths, width := solveConstraintAxis(set, refs, widths, false, constraints.MinSize.Width, constraints.MaxSize.Width)
+
+	// 幅が決まった子ノードを測定し直し、垂直方向を解く
+	heights := make([]float64, len(measurables))
+	for i := range measurables {
+		if refs[i].Width.kind == dimensionFill && widths[i] != placeables[i].Size().Width {
+			placeables[i] = measure(i, widths[i], -1)
+		}
+		heights[i] = placeables[i].Size().Height
+	}
+	ys, heights, height := solveConstraintAxis(set, refs, heights, true, constraints.MinSize.Height, constraints.MaxSize.Height)
+
+	for i := range measurables {
+		if refs[i].Height.kind == dimensionFill && heights[i] != placeables[i].Size().Height {
+			fillWidth := -1.0
+			if refs[i].Width.kind == dimensionFill {
+				fillWidth = widths[i]
+			}
+			placeables[i] = measure(i, fillWidth, heights[i])
+		}
+	}
+
+	return MeasureResult{
+		Size: Size{Width: width, Height: height},
+		Place: func() {
+			for i := range measurables {
+				placeables[i].Place(xs[i], ys[i])
+			}
+		},
+	}
+}
+
+// constraintLayoutPolicyFromProps は ConstraintLayout のプロパティから ConstraintLayoutPolicy を作成します
+func constraintLayoutPolicyFromProps(props core.Props) MeasurePolicy {
+	set, _ := props[ConstraintSetKey].(*ConstraintSet)
+	return ConstraintLayoutPolicy{Set: set}
+}
@@ -0,0 +1,159 @@
+package layout
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+// withID は LayoutID を設定した Box を作成します
+func withID(id string, width, height float64) *core.Node {
+	return modified(box(id, width, height), core.NewModifier().LayoutID(id))
+}
+
+func TestConstraintLayout(t *testing.T) {
+	tests := []struct {
+		name        string
+		build       func(set *ConstraintSet)
+		children    []*core.Node
+		constraints Constraints
+		want        map[core.NodeID]Rect
+	}{
+		{
+			name: "links to the parent with margins",
+			build: func(set *ConstraintSet) {
+				set.Ref("a").LinkStart(set.Parent().Start(), 2).LinkTop(set.Parent().Top(), 1)
+			},
+			children:    []*core.Node{withID("a", 3, 1)},
+			constraints: Loose(Size{Width: 20, Height: 20}),
+			want: map[core.NodeID]Rect{
+				"cl#0":     rect(0, 0, 5, 2),
+				"cl#0/a#0": rect(2, 1, 3, 1),
+			},
+		},
+		{
+			name: "centered in the parent",
+			build: func(set *ConstraintSet) {
+				set.Ref("a").CenterTo(set.Parent())
+			},
+			children:    []*core.Node{withID("a", 4, 2)},
+			constraints: FixedSize(10, 10),
+			want: map[core.NodeID]Rect{
+				"cl#0/a#0": rect(3, 4, 4, 2),
+			},
+		},
+		{
+			name: "linked to a sibling",
+			build: func(set *ConstraintSet) {
+				a, b := set.Ref("a"), set.Ref("b")
+				b.LinkStart(a.End(), 1).LinkTop(a.Bottom(), 0)
+			},
+			children:    []*core.Node{withID("a", 3, 2), withID("b", 2, 1)},
+			constraints: Loose(Size{Width: 20, Height: 20}),
+			want: map[core.NodeID]Rect{
+				"cl#0":     rect(0, 0, 6, 3),
+				"cl#0/b#1": rect(4, 2, 2, 1),
+			},
+		},
+		{
+			name: "spread chain",
+			build: func(set *ConstraintSet) {
+				set.HorizontalChain(ChainSpread, set.Ref("a"), set.Ref("b"))
+			},
+			children:    []*core.Node{withID("a", 2, 1), withID("b", 2, 1)},
+			constraints: FixedSize(10, 1),
+			want: map[core.NodeID]Rect{
+				"cl#0/a#0": rect(2, 0, 2, 1),
+				"cl#0/b#1": rect(6, 0, 2, 1),
+			},
+		},
+		{
+			name: "fill to constraints",
+			build: func(set *ConstraintSet) {
+				a := set.Ref("a").LinkStart(set.Parent().Start(), 1).LinkEnd(set.Parent().End(), 1)
+				a.Width = FillToConstraints()
+			},
+			children:    []*core.Node{withID("a", 0, 1)},
+			constraints: FixedSize(10, 1),
+			want: map[core.NodeID]Rect{
+				"cl#0/a#0": rect(1, 0, 8, 1),
+			},
+		},
+		{
+			name: "start bias keeps the start link when space is short",
+			build: func(set *ConstraintSet) {
+				// 両側のリンクの間に収まらない場合も、偏りが 0 なら左端のリンクを優先する
+				set.Ref("a").LinkStart(set.Parent().Start(), 8).LinkEnd(set.Parent().End(), 0)
+				set.Ref("a").Width = FixedDimension(4)
+				set.Ref("a").HorizontalBias = 0
+			},
+			children:    []*core.Node{withID("a", 4, 1)},
+			constraints: FixedSize(10, 1),
+			want: map[core.NodeID]Rect{
+				"cl#0":     rect(0, 0, 10, 1),
+				"cl#0/a#0": rect(8, 0, 4, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := NewConstraintSet()
+			tt.build(set)
+			root := container(core.ConstraintLayoutNodeType, "cl", core.Props{ConstraintSetKey: set}, tt.children...)
+			expectRects(t, calculate(root, tt.constraints), tt.want)
+		})
+	}
+}
+
+func TestConstraintAxisDropsUnsatisfiableConstraints(t *testing.T) {
+	set := NewConstraintSet()
+	ref := set.Ref("a").LinkStart(set.Parent().Start(), 3)
+
+	// 最小サイズが最大サイズを超える矛盾した範囲では、後から追加した最大サイズの制約を破棄する
+	positions, sizes, size := solveConstraintAxis(set, []*ConstrainedRef{ref}, []float64{2}, false, 12, 10)
+	if size != 12 {
+		t.Errorf("size = %v, want 12", size)
+	}
+	if positions[0] != 3 || sizes[0] != 2 {
+		t.Errorf("position, size = %v, %v, want 3, 2", positions[0], sizes[0])
+	}
+	for _, value := range []float64{positions[0], sizes[0], size} {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			t.Errorf("invalid value %v", value)
+		}
+	}
+}
+
+func TestConstraintLayoutDuplicateLayoutIDs(t *testing.T) {
+	set := NewConstraintSet()
+	set.Ref("a").LinkStart(set.Parent().Start(), 4)
+	root := container(core.ConstraintLayoutNodeType, "cl", core.Props{ConstraintSetKey: set},
+		withID("a", 2, 1),
+		modified(box("second", 3, 1), core.NewModifier().LayoutID("a")),
+	)
+
+	// 制約は最初の子ノードだけに適用され、重複した子ノードは左上に置かれる
+	expectRects(t, calculate(root, Loose(Size{Width: 20, Height: 20})), map[core.NodeID]Rect{
+		"cl#0/a#0":      rect(4, 0, 2, 1),
+		"cl#0/second#1": rect(0, 0, 3, 1),
+	})
+
+	duplicates := FindDuplicateLayoutIDs(root)
+	if len(duplicates) != 1 {
+		t.Fatalf("duplicates = %v, want 1", duplicates)
+	}
+	if got := duplicates[0].String(); got != `duplicate layout ID "a" among children of cl#0 at indices [0 1]` {
+		t.Errorf("duplicate = %s", got)
+	}
+	if keys := core.FindDuplicateKeys(root); len(keys) != 0 {
+		t.Errorf("FindDuplicateKeys = %v, want none", keys)
+	}
+
+	// ConstraintLayout の外の LayoutID は検査しない
+	outside := container(core.BoxNodeType, "box", nil, withID("a", 1, 1), modified(box("second", 1, 1), core.NewModifier().LayoutID("a")))
+	if duplicates := FindDuplicateLayoutIDs(outside); len(duplicates) != 0 {
+		t.Errorf("duplicates outside a constraint layout = %v", duplicates)
+	}
+}
@@ -0,0 +1,180 @@
+package layout
+
+import (
+	"github.com/tak/goui/core"
+)
+
+// FlowPolicy は子ノードを主軸方向に並べ、最大サイズを超える場合は次の行（列）に折り返す MeasurePolicy です
+//
+// 各行の中では Arrangement に従って子ノードを並べ、行の高さ（列の幅）の中で
+// Alignment（子ノードの Align があればそれ）に従って交差軸方向の位置を決めます。
+type FlowPolicy struct {
+	// Vertical が true の場合は垂直方向に並べて列に折り返します（FlowColumn）
+	Vertical bool
+	// Arrangement は行の中での主軸方向の並べ方です
+	Arrangement core.Arrangement
+	// Alignment は行の中での交差軸方向の配置です
+	Alignment core.Alignment
+	// Spacing は行の中の子ノードの間隔です
+	Spacing float64
+	// CrossAxisSpacing は行と行の間隔です
+	CrossAxisSpacing float64
+	// MaxItemsPerLine は1行に並べる子ノードの最大数です（0 以下は無制限）
+	MaxItemsPerLine int
+}
+
+// flowLine は折り返した1行に含まれる子ノードの範囲とサイズです
+type flowLine struct {
+	start, end int
+	main       float64
+	cross      float64
+}
+
+// axis はこのレイアウトの主軸を返します
+func (p FlowPolicy) axis() axis {
+	if p.Vertical {
+		return verticalAxis
+	}
+	return horizontalAxis
+}
+
+// breakLines は主軸方向のサイズが mainSizes の子ノードを maxMain の長さの行に折り返します
+func (p FlowPolicy) breakLines(mainSizes, crossSizes []float64, maxMain float64) []flowLine {
+	var lines []flowLine
+	current := flowLine{}
+	for i, size := range mainSizes {
+		count := i - current.start
+		if count > 0 {
+			full := p.MaxItemsPerLine > 0 && count >= p.MaxItemsPerLine
+			if full || current.main+p.Spacing+size > maxMain {
+				current.end = i
+				lines = append(lines, current)
+				current = flowLine{start: i}
+				count = 0
+			}
+		}
+		if count > 0 {
+			current.main += p.Spacing
+		}
+		current.main += size
+		current.cross = max(current.cross, crossSizes[i])
+	}
+	if len(mainSizes) > 0 {
+		current.end = len(mainSizes)
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// Measure は子ノードを測定して行に折り返します
+func (p FlowPolicy) Measure(measurables []Measurable, constraints Constraints) MeasureResult {
+	axis := p.axis()
+	maxMain := axis.main(constraints.MaxSize)
+	childConstraints := Loose(constraints.MaxSize)
+
+	placeables := make([]Placeable, len(measurables))
+	mainSizes := make([]float64, len(measurables))
+	crossSizes := make([]float64, len(measurables))
+	for i, child := range measurables {
+		placeables[i] = child.Measure(childConstraints)
+		mainSizes[i] = axis.main(placeables[i].Size())
+		crossSizes[i] = axis.cross(placeables[i].Size())
+	}
+
+	lines := p.breakLines(mainSizes, crossSizes, maxMain)
+	mainSize := axis.main(constraints.MinSize)
+	crossSize := p.CrossAxisSpacing * float64(max(len(lines)-1, 0))
+	for _, line := range lines {
+		mainSize = max(mainSize, line.main)
+		crossSize += line.cross
+	}
+	crossSize = max(crossSize, axis.cross(constraints.MinSize))
+
+	return MeasureResult{
+		Size: axis.size(mainSize, crossSize),
+		Place: func() {
+			crossPosition := 0.0
+			for _, line := range lines {
+				positions := p.Arrangement.Arrange(mainSizes[line.start:line.end], mainSize, p.Spacing)
+				for i := line.start; i < line.end; i++ {
+					alignment := p.Alignment
+					if align, ok := childAlignment(measurables[i]); ok {
+						alignment = align
+					}
+					offset := alignment.Offset(line.cross - crossSizes[i])
+					position := axis.offset(Position{}, positions[i-line.start], crossPosition+offset)
+					placeables[i].Place(position.X, position.Y)
+				}
+				crossPosition += line.cross + p.CrossAxisSpacing
+			}
+		},
+	}
+}
+
+// IntrinsicSize は折り返したときの固有サイズを返します
+//
+// 主軸方向の最小固有サイズは最も大きい子ノードのサイズ（1行に1つずつ並べた場合）、
+// 最大固有サイズは折り返さずに並べた場合の長さです。
+// 交差軸方向の固有サイズは、子ノードを最大固有サイズで other の長さの行に折り返したときの合計です。
+func (p FlowPolicy) IntrinsicSize(measurables []Measurable, query IntrinsicQuery, other float64) float64 {
+	axis := p.axis()
+	if len(measurables) == 0 {
+		return 0
+	}
+
+	if query.axis() == axis {
+		if query.IsMin() {
+			result := 0.0
+			for _, child := range measurables {
+				result = max(result, child.IntrinsicSize(query, Unbounded))
+			}
+			return result
+		}
+		sizes := make([]float64, len(measurables))
+		for i, child := range measurables {
+			sizes[i] = child.IntrinsicSize(query, Unbounded)
+		}
+		result := 0.0
+		for _, line := range p.breakLines(sizes, make([]float64, len(sizes)), Unbounded) {
+			result = max(result, line.main)
+		}
+		return result
+	}
+
+	mainQuery := intrinsicQuery(axis, false)
+	mainSizes := make([]float64, len(measurables))
+	crossSizes := make([]float64, len(measurables))
+	for i, child := range measurables {
+		mainSizes[i] = min(child.IntrinsicSize(mainQuery, Unbounded), other)
+		crossSizes[i] = child.IntrinsicSize(query, mainSizes[i])
+	}
+	lines := p.breakLines(mainSizes, crossSizes, other)
+	result := p.CrossAxisSpacing * float64(len(lines)-1)
+	for _, line := range lines {
+		result += line.cross
+	}
+	return result
+}
+
+// flowRowPolicyFromProps は FlowRow のプロパティから FlowPolicy を作成します
+func flowRowPolicyFromProps(props core.Props) MeasurePolicy {
+	return FlowPolicy{
+		Arrangement:      props.GetArrangement("horizontalArrangement", core.ArrangeStart),
+		Alignment:        props.GetAlignment("verticalAlignment", core.AlignStart),
+		Spacing:          props.GetFloat("spacing", 0),
+		CrossAxisSpacing: props.GetFloat("crossAxisSpacing", 0),
+		MaxItemsPerLine:  props.GetInt("maxItemsPerLine", 0),
+	}
+}
+
+// flowColumnPolicyFromProps は FlowColumn のプロパティから FlowPolicy を作成します
+func flowColumnPolicyFromProps(props core.Props) MeasurePolicy {
+	return FlowPolicy{
+		Vertical:         true,
+		Arrangement:      props.GetArrangement("verticalArrangement", core.ArrangeStart),
+		Alignment:        props.GetAlignment("horizontalAlignment", core.AlignStart),
+		Spacing:          props.GetFloat("spacing", 0),
+		CrossAxisSpacing: props.GetFloat("crossAxisSpacing", 0),
+		MaxItemsPerLine:  props.GetInt("maxItemsPerLine", 0),
+	}
+}
@@ -0,0 +1,125 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+func TestFlowBreakLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  FlowPolicy
+		sizes   []float64
+		maxMain float64
+		want    []flowLine
+	}{
+		{"empty", FlowPolicy{}, nil, 10, nil},
+		{"single line", FlowPolicy{}, []float64{3, 3, 3}, 10, []flowLine{{start: 0, end: 3, main: 9}}},
+		{"exact fit", FlowPolicy{Spacing: 1}, []float64{4, 5}, 10, []flowLine{{start: 0, end: 2, main: 10}}},
+		{
+			"spacing causes a break",
+			FlowPolicy{Spacing: 2},
+			[]float64{4, 5},
+			10,
+			[]flowLine{{start: 0, end: 1, main: 4}, {start: 1, end: 2, main: 5}},
+		},
+		{
+			"oversized item gets its own line",
+			FlowPolicy{},
+			[]float64{2, 12, 2},
+			10,
+			[]flowLine{{start: 0, end: 1, main: 2}, {start: 1, end: 2, main: 12}, {start: 2, end: 3, main: 2}},
+		},
+		{
+			"max items per line",
+			FlowPolicy{MaxItemsPerLine: 2},
+			[]float64{1, 1, 1, 1, 1},
+			10,
+			[]flowLine{{start: 0, end: 2, main: 2}, {start: 2, end: 4, main: 2}, {start: 4, end: 5, main: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.breakLines(tt.sizes, make([]float64, len(tt.sizes)), tt.maxMain)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("breakLines = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlowLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodeType core.NodeType
+		props    core.Props
+		children []*core.Node
+		want     map[core.NodeID]Rect
+	}{
+		{
+			name:     "row wraps with spacing",
+			nodeType: core.FlowRowNodeType,
+			props:    core.Props{"spacing": 1.0, "crossAxisSpacing": 1.0},
+			children: []*core.Node{box("a", 4, 1), box("b", 4, 2), box("c", 4, 1)},
+			want: map[core.NodeID]Rect{
+				"flow#0":     rect(0, 0, 9, 4),
+				"flow#0/a#0": rect(0, 0, 4, 1),
+				"flow#0/b#1": rect(5, 0, 4, 2),
+				"flow#0/c#2": rect(0, 3, 4, 1),
+			},
+		},
+		{
+			name:     "cross axis alignment within a line",
+			nodeType: core.FlowRowNodeType,
+			props:    core.Props{"verticalAlignment": core.AlignEnd},
+			children: []*core.Node{
+				box("a", 2, 1),
+				box("b", 2, 3),
+				modified(box("c", 2, 1), core.NewModifier().Align(core.AlignCenter)),
+			},
+			want: map[core.NodeID]Rect{
+				"flow#0/a#0": rect(0, 2, 2, 1),
+				"flow#0/b#1": rect(2, 0, 2, 3),
+				"flow#0/c#2": rect(4, 1, 2, 1),
+			},
+		},
+		{
+			name:     "column wraps into columns",
+			nodeType: core.FlowColumnNodeType,
+			children: []*core.Node{box("a", 1, 4), box("b", 2, 4), box("c", 1, 4)},
+			want: map[core.NodeID]Rect{
+				"flow#0":     rect(0, 0, 3, 8),
+				"flow#0/a#0": rect(0, 0, 1, 4),
+				"flow#0/b#1": rect(0, 4, 2, 4),
+				"flow#0/c#2": rect(2, 0, 1, 4),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := container(tt.nodeType, "flow", tt.props, tt.children...)
+			expectRects(t, calculate(root, Loose(Size{Width: 10, Height: 10})), tt.want)
+		})
+	}
+}
+
+func TestFlowIntrinsicSize(t *testing.T) {
+	lm := NewLayoutManager()
+	lm.SetTextMeasurer(TerminalTextMeasurer{})
+	root := container(core.FlowRowNodeType, "flow", core.Props{"spacing": 1.0, "crossAxisSpacing": 1.0},
+		box("a", 4, 1), box("b", 4, 2), box("c", 4, 1))
+
+	if got := lm.MinIntrinsicWidth(root, Unbounded); got != 4 {
+		t.Errorf("MinIntrinsicWidth = %v, want 4", got)
+	}
+	if got := lm.MaxIntrinsicWidth(root, Unbounded); got != 14 {
+		t.Errorf("MaxIntrinsicWidth = %v, want 14", got)
+	}
+	if got := lm.MinIntrinsicHeight(root, 9); got != 4 {
+		t.Errorf("MinIntrinsicHeight(9) = %v, want 4", got)
+	}
+}
@@ -0,0 +1,416 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/tak/goui/core"
+)
+
+// TrackKind は Grid の行または列（トラック）のサイズの決め方です
+type TrackKind int
+
+const (
+	// TrackAuto はトラックに含まれる子ノードの大きさに合わせます
+	TrackAuto TrackKind = iota
+	// TrackFixed は Value の固定サイズにします
+	TrackFixed
+	// TrackFraction は固定サイズと自動サイズのトラックを除いた残りを Value の比率で分け合います
+	TrackFraction
+	// TrackAdaptive は Value 以上のサイズで収まるだけのトラックを作り、残りを均等に分け合います
+	TrackAdaptive
+)
+
+// Track は Grid の行または列の定義です
+type Track struct {
+	Kind  TrackKind
+	Value float64
+}
+
+// AutoTrack は子ノードの大きさに合わせるトラックを返します
+func AutoTrack() Track {
+	return Track{Kind: TrackAuto}
+}
+
+// FixedTrack は固定サイズのトラックを返します
+func FixedTrack(size float64) Track {
+	return Track{Kind: TrackFixed, Value: size}
+}
+
+// FractionTrack は残りの空きを fraction の比率で受け取るトラックを返します
+func FractionTrack(fraction float64) Track {
+	return Track{Kind: TrackFraction, Value: fraction}
+}
+
+// AdaptiveTrack は minSize 以上のサイズで収まるだけ並べるトラックを返します
+// 最大サイズが無限の場合は自動サイズのトラック1つになります
+func AdaptiveTrack(minSize float64) Track {
+	return Track{Kind: TrackAdaptive, Value: minSize}
+}
+
+// GridPolicy は子ノードを行と列のトラックで区切られたセルに配置する MeasurePolicy です
+//
+// 子ノードは GridCell で指定したセル、指定がなければ空いているセルに行方向の順で配置され、
+// GridSpan で複数の行や列にまたがることができます。
+// 行の定義が足りない場合は自動サイズの行が追加されます。
+type GridPolicy struct {
+	// Columns は列の定義です（空の場合は自動サイズの1列）
+	Columns []Track
+	// Rows は行の定義です
+	Rows []Track
+	// ColumnGap は列の間隔です
+	ColumnGap float64
+	// RowGap は行の間隔です
+	RowGap float64
+	// CellAlignment はセルの中での子ノードの配置です（子ノードの Align で上書きできます）
+	CellAlignment core.Alignment
+}
+
+// gridItem は子ノードが占めるセルの範囲です
+type gridItem struct {
+	row, column         int
+	rowSpan, columnSpan int
+}
+
+// trackSpan は子ノードが占めるトラックの範囲と、そこに必要なサイズです
+type trackSpan struct {
+	start, count int
+	content      float64
+}
+
+// gridCellOf は子ノードのモディファイアからセルの指定とスパンを取得します
+func gridCellOf(child Measurable) (cell core.GridCellModifier, explicit bool, span core.GridSpanModifier) {
+	span = core.GridSpanModifier{RowSpan: 1, ColumnSpan: 1}
+	for _, element := range child.Props().GetModifier().Elements() {
+		switch e := element.(type) {
+		case core.GridCellModifier:
+			cell, explicit = e, true
+		case core.GridSpanModifier:
+			span.RowSpan = max(e.RowSpan, 1)
+			span.ColumnSpan = max(e.ColumnSpan, 1)
+		}
+	}
+	return cell, explicit, span
+}
+
+// expandTracks は AdaptiveTrack を available に収まる数の FractionTrack に展開します
+func expandTracks(tracks []Track, available, gap float64) []Track {
+	// 展開するトラック以外が使うサイズを除いた空きを求める
+	space := available
+	for _, track := range tracks {
+		if track.Kind == TrackFixed {
+			space -= track.Value + gap
+		}
+	}
+
+	result := make([]Track, 0, len(tracks))
+	for _, track := range tracks {
+		if track.Kind != TrackAdaptive {
+			result = append(result, track)
+			continue
+		}
+		if math.IsInf(available, 1) {
+			result = append(result, AutoTrack())
+			continue
+		}
+		count := 1
+		if track.Value+gap > 0 {
+			count = max(int(math.Floor((space+gap)/(track.Value+gap))), 1)
+		}
+		for i := 0; i < count; i++ {
+			result = append(result, FractionTrack(1))
+		}
+	}
+	return result
+}
+
+// placeItems は子ノードを columns 列のセルに割り当て、使用する行数を返します
+func placeItems(measurables []Measurable, columns int) ([]gridItem, int) {
+	items := make([]gridItem, len(measurables))
+	occupied := make(map[[2]int]bool)
+	fits := func(row, column, rowSpan, columnSpan int) bool {
+		for r := row; r < row+rowSpan; r++ {
+			for c := column; c < column+columnSpan; c++ {
+				if occupied[[2]int{r, c}] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	occupy := func(item gridItem) {
+		for r := item.row; r < item.row+item.rowSpan; r++ {
+			for c := item.column; c < item.column+item.columnSpan; c++ {
+				occupied[[2]int{r, c}] = true
+			}
+		}
+	}
+
+	// セルが指定された子ノードを先に配置する
+	automatic := make([]int, 0, len(measurables))
+	for i, child := range measurables {
+		cell, explicit, span := gridCellOf(child)
+		if !explicit {
+			items[i] = gridItem{rowSpan: span.RowSpan, columnSpan: min(span.ColumnSpan, columns)}
+			automatic = append(automatic, i)
+			continue
+		}
+		column := min(max(cell.Column, 0), columns-1)
+		items[i] = gridItem{
+			row:        max(cell.Row, 0),
+			column:     column,
+			rowSpan:    span.RowSpan,
+			columnSpan: min(span.ColumnSpan, columns-column),
+		}
+		occupy(items[i])
+	}
+
+	// 残りの子ノードを空いているセルに行方向の順で配置する
+	row, column := 0, 0
+	for _, i := range automatic {
+		item := items[i]
+		for {
+			if column+item.columnSpan > columns {
+				row, column = row+1, 0
+				continue
+			}
+			if fits(row, column, item.rowSpan, item.columnSpan) {
+				break
+			}
+			column++
+		}
+		item.row, item.column = row, column
+		items[i] = item
+		occupy(item)
+		column += item.columnSpan
+	}
+
+	rows := 0
+	for _, item := range items {
+		rows = max(rows, item.row+item.rowSpan)
+	}
+	return items, rows
+}
+
+// sizeTracks は子ノードに必要なサイズと available からトラックのサイズを決定します
+// available が無限の場合、比率のトラックは自動サイズとして扱います
+func sizeTracks(tracks []Track, spans []trackSpan, available, gap float64) []float64 {
+	unbounded := math.IsInf(available, 1)
+	isAuto := func(i int) bool {
+		return tracks[i].Kind == TrackAuto || (unbounded && tracks[i].Kind == TrackFraction)
+	}
+
+	sizes := make([]float64, len(tracks))
+	fractions := 0.0
+	for i, track := range tracks {
+		switch {
+		case track.Kind == TrackFixed:
+			sizes[i] = track.Value
+		case track.Kind == TrackFraction && !unbounded:
+			fractions += track.Value
+		}
+	}
+
+	// 1つのトラックに収まる子ノードに合わせる
+	for _, span := range spans {
+		if span.count == 1 && isAuto(span.start) {
+			sizes[span.start] = max(sizes[span.start], span.content)
+		}
+	}
+
+	// 複数のトラックにまたがる子ノードは、足りない分を最後の自動サイズのトラックで補う
+	for _, span := range spans {
+		if span.count <= 1 {
+			continue
+		}
+		end := span.start + span.count
+		total := gap * float64(span.count-1)
+		for i := span.start; i < end; i++ {
+			total += sizes[i]
+		}
+		if span.content <= total {
+			continue
+		}
+		for i := end - 1; i >= span.start; i-- {
+			if isAuto(i) {
+				sizes[i] += span.content - total
+				break
+			}
+		}
+	}
+
+	// 残りの空きを比率で分け合う
+	if fractions > 0 {
+		remaining := available - gap*float64(max(len(tracks)-1, 0))
+		for i, size := range sizes {
+			if tracks[i].Kind != TrackFraction {
+				remaining -= size
+			}
+		}
+		unit := max(remaining, 0) / fractions
+		for i, track := range tracks {
+			if track.Kind == TrackFraction {
+				sizes[i] = unit * track.Value
+			}
+		}
+	}
+	return sizes
+}
+
+// trackOffsets は各トラックの開始位置と、全体の長さを返します
+func trackOffsets(sizes []float64, gap float64) ([]float64, float64) {
+	offsets := make([]float64, len(sizes))
+	position := 0.0
+	for i, size := range sizes {
+		if i > 0 {
+			position += gap
+		}
+		offsets[i] = position
+		position += size
+	}
+	return offsets, position
+}
+
+// spanSize は start から count 個のトラックとその間の間隔を合わせた長さを返します
+func spanSize(sizes []float64, start, count int, gap float64) float64 {
+	result := gap * float64(count-1)
+	for i := start; i < start+count; i++ {
+		result += sizes[i]
+	}
+	return result
+}
+
+// columns は最大幅 width に対する列の定義を返します
+func (p GridPolicy) columns(width float64) []Track {
+	if len(p.Columns) == 0 {
+		return []Track{AutoTrack()}
+	}
+	return expandTracks(p.Columns, width, p.ColumnGap)
+}
+
+// rows は最大の高さ height と使用する行数に対する行の定義を返します
+func (p GridPolicy) rows(height float64, count int) []Track {
+	rows := expandTracks(p.Rows, height, p.RowGap)
+	for len(rows) < count {
+		rows = append(rows, AutoTrack())
+	}
+	return rows
+}
+
+// columnSizes は子ノードの幅の固有サイズから列の幅を決定します
+func (p GridPolicy) columnSizes(measurables []Measurable, items []gridItem, columns []Track, query IntrinsicQuery, width float64) []float64 {
+	spans := make([]trackSpan, len(items))
+	for i, item := range items {
+		spans[i] = trackSpan{
+			start:   item.column,
+			count:   item.columnSpan,
+			content: measurables[i].IntrinsicSize(query, Unbounded),
+		}
+	}
+	return sizeTracks(columns, spans, width, p.ColumnGap)
+}
+
+// Measure は子ノードをセルに割り当てて測定します
+func (p GridPolicy) Measure(measurables []Measurable, constraints Constraints) MeasureResult {
+	maxSize := constraints.MaxSize
+	columns := p.columns(maxSize.Width)
+	items, rowCount := placeItems(measurables, len(columns))
+	rows := p.rows(maxSize.Height, rowCount)
+	columnSizes := p.columnSizes(measurables, items, columns, MaxIntrinsicWidth, maxSize.Width)
+
+	// 子ノードはセルの幅で測定し、高さは固定サイズの行だけにまたがる場合にその高さに制限する
+	placeables := make([]Placeable, len(measurables))
+	spans := make([]trackSpan, len(measurables))
+	for i, child := range measurables {
+		item := items[i]
+		maxHeight := maxSize.Height
+		fixed := 0.0
+		for r := item.row; r < item.row+item.rowSpan; r++ {
+			if rows[r].Kind != TrackFixed {
+				fixed = -1
+				break
+			}
+			fixed += rows[r].Value
+		}
+		if fixed >= 0 {
+			maxHeight = fixed + p.RowGap*float64(item.rowSpan-1)
+		}
+		cellWidth := spanSize(columnSizes, item.column, item.columnSpan, p.ColumnGap)
+		placeables[i] = child.Measure(Loose(Size{Width: cellWidth, Height: maxHeight}))
+		spans[i] = trackSpan{start: item.row, count: item.rowSpan, content: placeables[i].Size().Height}
+	}
+	rowSizes := sizeTracks(rows, spans, maxSize.Height, p.RowGap)
+
+	columnOffsets, width := trackOffsets(columnSizes, p.ColumnGap)
+	rowOffsets, height := trackOffsets(rowSizes, p.RowGap)
+	size := constraints.Constrain(Size{Width: width, Height: height})
+
+	return MeasureResult{
+		Size: size,
+		Place: func() {
+			for i, child := range measurables {
+				item := items[i]
+				alignment := p.CellAlignment
+				if align, ok := childAlignment(child); ok {
+					alignment = align
+				}
+				horizontal, vertical := alignment.Split()
+				childSize := placeables[i].Size()
+				cellWidth := spanSize(columnSizes, item.column, item.columnSpan, p.ColumnGap)
+				cellHeight := spanSize(rowSizes, item.row, item.rowSpan, p.RowGap)
+				placeables[i].Place(
+					columnOffsets[item.column]+horizontal.Offset(cellWidth-childSize.Width),
+					rowOffsets[item.row]+vertical.Offset(cellHeight-childSize.Height),
+				)
+			}
+		},
+	}
+}
+
+// IntrinsicSize はトラックのサイズから固有サイズを返します
+//
+// 幅の固有サイズは比率のトラックを自動サイズとして扱ったときの列の幅の合計です。
+// 高さの固有サイズは other の幅で列を決め、各子ノードをセルの幅に収めたときの行の高さの合計です。
+func (p GridPolicy) IntrinsicSize(measurables []Measurable, query IntrinsicQuery, other float64) float64 {
+	if query.IsWidth() {
+		columns := p.columns(Unbounded)
+		items, _ := placeItems(measurables, len(columns))
+		_, width := trackOffsets(p.columnSizes(measurables, items, columns, query, Unbounded), p.ColumnGap)
+		return width
+	}
+
+	columns := p.columns(other)
+	items, rowCount := placeItems(measurables, len(columns))
+	columnSizes := p.columnSizes(measurables, items, columns, MaxIntrinsicWidth, other)
+	rows := p.rows(Unbounded, rowCount)
+	spans := make([]trackSpan, len(items))
+	for i, item := range items {
+		cellWidth := spanSize(columnSizes, item.column, item.columnSpan, p.ColumnGap)
+		spans[i] = trackSpan{
+			start:   item.row,
+			count:   item.rowSpan,
+			content: measurables[i].IntrinsicSize(query, cellWidth),
+		}
+	}
+	_, height := trackOffsets(sizeTracks(rows, spans, Unbounded, p.RowGap), p.RowGap)
+	return height
+}
+
+// tracksFromProps はプロパティからトラックの定義を取得します
+func tracksFromProps(props core.Props, key string) []Track {
+	if tracks, ok := props[key].([]Track); ok {
+		return tracks
+	}
+	return nil
+}
+
+// gridPolicyFromProps は Grid のプロパティから GridPolicy を作成します
+func gridPolicyFromProps(props core.Props) MeasurePolicy {
+	gap := props.GetFloat("gap", 0)
+	return GridPolicy{
+		Columns:       tracksFromProps(props, "columns"),
+		Rows:          tracksFromProps(props, "rows"),
+		ColumnGap:     props.GetFloat("columnGap", gap),
+		RowGap:        props.GetFloat("rowGap", gap),
+		CellAlignment: props.GetAlignment("cellAlignment", core.TopStart),
+	}
+}
@@ -0,0 +1,180 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+func TestExpandTracks(t *testing.T) {
+	tests := []struct {
+		name      string
+		tracks    []Track
+		available float64
+		gap       float64
+		want      []Track
+	}{
+		{"non adaptive tracks are kept", []Track{FixedTrack(2), AutoTrack()}, 10, 0, []Track{FixedTrack(2), AutoTrack()}},
+		{"adaptive fills the space", []Track{AdaptiveTrack(3)}, 10, 1, []Track{FractionTrack(1), FractionTrack(1)}},
+		{
+			"fixed tracks reduce the space",
+			[]Track{FixedTrack(2), AdaptiveTrack(2)},
+			10, 0,
+			[]Track{FixedTrack(2), FractionTrack(1), FractionTrack(1), FractionTrack(1), FractionTrack(1)},
+		},
+		{"at least one track", []Track{AdaptiveTrack(30)}, 10, 0, []Track{FractionTrack(1)}},
+		{"unbounded becomes auto", []Track{AdaptiveTrack(3)}, Unbounded, 0, []Track{AutoTrack()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandTracks(tt.tracks, tt.available, tt.gap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandTracks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSizeTracks(t *testing.T) {
+	tests := []struct {
+		name      string
+		tracks    []Track
+		spans     []trackSpan
+		available float64
+		gap       float64
+		want      []float64
+	}{
+		{
+			"fixed, auto and fractions",
+			[]Track{FixedTrack(2), AutoTrack(), FractionTrack(1), FractionTrack(3)},
+			[]trackSpan{{start: 1, count: 1, content: 3}},
+			20, 1,
+			[]float64{2, 3, 3, 9},
+		},
+		{
+			"fractions are auto when unbounded",
+			[]Track{FractionTrack(1), FractionTrack(1)},
+			[]trackSpan{{start: 0, count: 1, content: 4}, {start: 1, count: 1, content: 2}},
+			Unbounded, 0,
+			[]float64{4, 2},
+		},
+		{
+			"span grows the last auto track",
+			[]Track{AutoTrack(), AutoTrack()},
+			[]trackSpan{{start: 0, count: 1, content: 2}, {start: 0, count: 2, content: 7}},
+			Unbounded, 1,
+			[]float64{2, 4},
+		},
+		{
+			"span that already fits",
+			[]Track{FixedTrack(3), FixedTrack(3)},
+			[]trackSpan{{start: 0, count: 2, content: 5}},
+			20, 0,
+			[]float64{3, 3},
+		},
+		{
+			"no space left for fractions",
+			[]Track{FixedTrack(8), FractionTrack(1)},
+			nil,
+			5, 0,
+			[]float64{8, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sizeTracks(tt.tracks, tt.spans, tt.available, tt.gap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sizeTracks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGridLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		props    core.Props
+		children []*core.Node
+		want     map[core.NodeID]Rect
+	}{
+		{
+			name:  "fixed and fraction columns with a spanning row",
+			props: core.Props{"columns": []Track{FixedTrack(3), FractionTrack(1)}, "gap": 1.0},
+			children: []*core.Node{
+				box("a", 2, 1),
+				box("b", 2, 2),
+				modified(box("c", 5, 1), core.NewModifier().GridSpan(1, 2)),
+			},
+			want: map[core.NodeID]Rect{
+				"grid#0":     rect(0, 0, 10, 4),
+				"grid#0/a#0": rect(0, 0, 2, 1),
+				"grid#0/b#1": rect(4, 0, 2, 2),
+				"grid#0/c#2": rect(0, 3, 5, 1),
+			},
+		},
+		{
+			name:  "explicit cells are placed first",
+			props: core.Props{"columns": []Track{FixedTrack(2), FixedTrack(2)}},
+			children: []*core.Node{
+				box("a", 1, 1),
+				box("b", 1, 1),
+				modified(box("c", 1, 1), core.NewModifier().GridCell(0, 0)),
+			},
+			want: map[core.NodeID]Rect{
+				"grid#0":     rect(0, 0, 10, 2),
+				"grid#0/c#2": rect(0, 0, 1, 1),
+				"grid#0/a#0": rect(2, 0, 1, 1),
+				"grid#0/b#1": rect(0, 1, 1, 1),
+			},
+		},
+		{
+			name: "cell alignment",
+			props: core.Props{
+				"columns":       []Track{FixedTrack(4)},
+				"rows":          []Track{FixedTrack(3)},
+				"cellAlignment": core.BottomEnd,
+			},
+			children: []*core.Node{box("a", 2, 1)},
+			want: map[core.NodeID]Rect{
+				"grid#0/a#0": rect(2, 2, 2, 1),
+			},
+		},
+		{
+			name:  "adaptive columns",
+			props: core.Props{"columns": []Track{AdaptiveTrack(4)}, "columnGap": 1.0},
+			children: []*core.Node{
+				box("a", 1, 1), box("b", 1, 1), box("c", 1, 1),
+			},
+			want: map[core.NodeID]Rect{
+				"grid#0/a#0": rect(0, 0, 1, 1),
+				"grid#0/b#1": rect(5.5, 0, 1, 1),
+				"grid#0/c#2": rect(0, 1, 1, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := container(core.GridNodeType, "grid", tt.props, tt.children...)
+			expectRects(t, calculate(root, NewConstraints(10, 0, 10, 10)), tt.want)
+		})
+	}
+}
+
+func TestGridIntrinsicSize(t *testing.T) {
+	lm := NewLayoutManager()
+	lm.SetTextMeasurer(TerminalTextMeasurer{})
+	root := container(core.GridNodeType, "grid", core.Props{"columns": []Track{AutoTrack(), FractionTrack(1)}, "gap": 1.0},
+		text("a", "ab cd"), box("b", 3, 1), text("c", "x"))
+
+	if got := lm.MaxIntrinsicWidth(root, Unbounded); got != 9 {
+		t.Errorf("MaxIntrinsicWidth = %v, want 9", got)
+	}
+	if got := lm.MinIntrinsicWidth(root, Unbounded); got != 6 {
+		t.Errorf("MinIntrinsicWidth = %v, want 6", got)
+	}
+	if got := lm.MinIntrinsicHeight(root, 9); got != 3 {
+		t.Errorf("MinIntrinsicHeight(9) = %v, want 3", got)
+	}
+}
@@ -0,0 +1,50 @@
+package layout
+
+import (
+	"github.com/tak/goui/core"
+)
+
+// EdgeInsets は矩形の各辺に対する余白の量です
+type EdgeInsets struct {
+	Start  float64
+	Top    float64
+	End    float64
+	Bottom float64
+}
+
+// Horizontal は左右の余白の合計を返します
+func (e EdgeInsets) Horizontal() float64 {
+	return e.Start + e.End
+}
+
+// Vertical は上下の余白の合計を返します
+func (e EdgeInsets) Vertical() float64 {
+	return e.Top + e.Bottom
+}
+
+// IsZero は余白がないかを判定します
+func (e EdgeInsets) IsZero() bool {
+	return e == EdgeInsets{}
+}
+
+// EdgeInsetsFromProps はプロパティから name（"padding" や "margin"）の余白を取得します
+//
+// name で全辺、name+"Horizontal" と name+"Vertical" で軸ごと、
+// name+"Start"、name+"Top"、name+"End"、name+"Bottom" で辺ごとの値を指定でき、
+// より個別の指定が優先されます。
+func EdgeInsetsFromProps(props core.Props, name string) EdgeInsets {
+	all := props.GetFloat(name, 0)
+	horizontal := props.GetFloat(name+"Horizontal", all)
+	vertical := props.GetFloat(name+"Vertical", all)
+	return EdgeInsets{
+		Start:  props.GetFloat(name+"Start", horizontal),
+		Top:    props.GetFloat(name+"Top", vertical),
+		End:    props.GetFloat(name+"End", horizontal),
+		Bottom: props.GetFloat(name+"Bottom", vertical),
+	}
+}
+
+// Deflate は余白の分だけ内側に縮めた矩形を返します
+func (r Rect) Deflate(insets EdgeInsets) Rect {
+	return r.Inset(insets.Start, insets.Top, insets.End, insets.Bottom)
+}
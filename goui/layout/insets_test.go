@@ -0,0 +1,86 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+func TestEdgeInsetsFromProps(t *testing.T) {
+	tests := []struct {
+		name  string
+		props core.Props
+		want  EdgeInsets
+	}{
+		{"none", core.Props{}, EdgeInsets{}},
+		{"all sides", core.Props{"padding": 2.0}, EdgeInsets{Start: 2, Top: 2, End: 2, Bottom: 2}},
+		{"per axis", core.Props{"paddingHorizontal": 1.0, "paddingVertical": 3.0}, EdgeInsets{Start: 1, Top: 3, End: 1, Bottom: 3}},
+		{"axis overrides all", core.Props{"padding": 2.0, "paddingVertical": 0.0}, EdgeInsets{Start: 2, End: 2}},
+		{"side overrides axis", core.Props{"paddingHorizontal": 1.0, "paddingEnd": 4.0}, EdgeInsets{Start: 1, End: 4}},
+		{"other name is ignored", core.Props{"margin": 5.0}, EdgeInsets{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EdgeInsetsFromProps(tt.props, "padding"); got != tt.want {
+				t.Errorf("EdgeInsetsFromProps = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaddingAndMarginLayout(t *testing.T) {
+	tests := []struct {
+		name  string
+		props core.Props
+		want  map[core.NodeID]Rect
+	}{
+		{
+			name:  "padding is inside the node",
+			props: core.Props{"paddingStart": 1.0, "paddingTop": 2.0},
+			want: map[core.NodeID]Rect{
+				"column#0":         rect(0, 0, 5, 5),
+				"column#0/child#0": rect(0, 0, 5, 4),
+				"column#0/next#1":  rect(0, 4, 1, 1),
+			},
+		},
+		{
+			name:  "margin is outside the node",
+			props: core.Props{"marginStart": 1.0, "marginTop": 2.0},
+			want: map[core.NodeID]Rect{
+				"column#0":         rect(0, 0, 5, 5),
+				"column#0/child#0": rect(1, 2, 4, 2),
+				"column#0/next#1":  rect(0, 4, 1, 1),
+			},
+		},
+		{
+			name:  "margin outside padding",
+			props: core.Props{"margin": 1.0, "padding": 1.0},
+			want: map[core.NodeID]Rect{
+				"column#0":         rect(0, 0, 8, 7),
+				"column#0/child#0": rect(1, 1, 6, 4),
+				"column#0/next#1":  rect(0, 6, 1, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child := container(core.BoxNodeType, "child", tt.props, box("content", 4, 2))
+			root := container(core.ColumnNodeType, "column", nil, child, box("next", 1, 1))
+			expectRects(t, calculate(root, Loose(Size{Width: 20, Height: 20})), tt.want)
+		})
+	}
+}
+
+func TestPaddingModifierInsideMargin(t *testing.T) {
+	node := modified(
+		container(core.BoxNodeType, "child", core.Props{"margin": 1.0}, box("content", 2, 1)),
+		core.NewModifier().Padding(2),
+	)
+	layout := calculate(container(core.ColumnNodeType, "column", nil, node), Loose(Size{Width: 20, Height: 20}))
+	expectRects(t, layout, map[core.NodeID]Rect{
+		"column#0/child#0":           rect(1, 1, 6, 5),
+		"column#0/child#0/content#0": rect(3, 3, 2, 1),
+	})
+}
@@ -0,0 +1,353 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/tak/goui/core"
+)
+
+// IntrinsicQuery は固有サイズの問い合わせの種類です
+//
+// 固有サイズはレイアウトを実行せずに求める、内容に応じたサイズです。
+// 最小固有幅は内容を崩さずに縮められる最小の幅（テキストでは最も長い単語の幅）、
+// 最大固有幅は内容を折り返さずに表示できる幅です。
+// 高さの問い合わせは与えられた幅で内容を表示したときの高さを返します。
+type IntrinsicQuery int
+
+const (
+	// MinIntrinsicWidth は与えられた高さでの最小固有幅です
+	MinIntrinsicWidth IntrinsicQuery = iota
+	// MaxIntrinsicWidth は与えられた高さでの最大固有幅です
+	MaxIntrinsicWidth
+	// MinIntrinsicHeight は与えられた幅での最小固有高さです
+	MinIntrinsicHeight
+	// MaxIntrinsicHeight は与えられた幅での最大固有高さです
+	MaxIntrinsicHeight
+)
+
+// Unbounded は制限のない長さです（固有サイズの問い合わせで反対の軸を制限しない場合に使用します）
+var Unbounded = math.Inf(1)
+
+// IsWidth は幅の問い合わせかを判定します
+func (q IntrinsicQuery) IsWidth() bool {
+	return q == MinIntrinsicWidth || q == MaxIntrinsicWidth
+}
+
+// IsMin は最小固有サイズの問い合わせかを判定します
+func (q IntrinsicQuery) IsMin() bool {
+	return q == MinIntrinsicWidth || q == MinIntrinsicHeight
+}
+
+// intrinsicQuery は軸と最小・最大の別から問い合わせの種類を返します
+func intrinsicQuery(axis axis, min bool) IntrinsicQuery {
+	switch {
+	case axis == horizontalAxis && min:
+		return MinIntrinsicWidth
+	case axis == horizontalAxis:
+		return MaxIntrinsicWidth
+	case min:
+		return MinIntrinsicHeight
+	default:
+		return MaxIntrinsicHeight
+	}
+}
+
+// axis は問い合わせの対象の軸を返します
+func (q IntrinsicQuery) axis() axis {
+	if q.IsWidth() {
+		return horizontalAxis
+	}
+	return verticalAxis
+}
+
+// IntrinsicMeasurePolicy は固有サイズの計算方法を持つ MeasurePolicy です
+//
+// 実装しない MeasurePolicy の固有サイズは、反対の軸だけを制限した制約で Measure を実行して求めます。
+type IntrinsicMeasurePolicy interface {
+	MeasurePolicy
+
+	// IntrinsicSize は other（幅の問い合わせでは高さ、高さの問い合わせでは幅）に対する固有サイズを返します
+	IntrinsicSize(measurables []Measurable, query IntrinsicQuery, other float64) float64
+}
+
+// MinIntrinsicWidth はノードを height の高さで表示するときの最小固有幅を返します
+func (lm *LayoutManager) MinIntrinsicWidth(node *core.Node, height float64) float64 {
+	return lm.nodeIntrinsic(node, core.RootNodeID(node), MinIntrinsicWidth, height)
+}
+
+// MaxIntrinsicWidth はノードを height の高さで表示するときの最大固有幅を返します
+func (lm *LayoutManager) MaxIntrinsicWidth(node *core.Node, height float64) float64 {
+	return lm.nodeIntrinsic(node, core.RootNodeID(node), MaxIntrinsicWidth, height)
+}
+
+// MinIntrinsicHeight はノードを width の幅で表示するときの最小固有高さを返します
+func (lm *LayoutManager) MinIntrinsicHeight(node *core.Node, width float64) float64 {
+	return lm.nodeIntrinsic(node, core.RootNodeID(node), MinIntrinsicHeight, width)
+}
+
+// MaxIntrinsicHeight はノードを width の幅で表示するときの最大固有高さを返します
+func (lm *LayoutManager) MaxIntrinsicHeight(node *core.Node, width float64) float64 {
+	return lm.nodeIntrinsic(node, core.RootNodeID(node), MaxIntrinsicHeight, width)
+}
+
+// IntrinsicSize は子ノードの固有サイズを返します
+func (m *measurable) IntrinsicSize(query IntrinsicQuery, other float64) float64 {
+	return m.manager.nodeIntrinsic(m.node, m.id, query, other)
+}
+
+// insetsAlong は問い合わせの軸方向の余白の合計を返します
+func insetsAlong(query IntrinsicQuery, insets EdgeInsets) float64 {
+	if query.IsWidth() {
+		return insets.Horizontal()
+	}
+	return insets.Vertical()
+}
+
+// insetsAcross は問い合わせと反対の軸方向の余白の合計を返します
+func insetsAcross(query IntrinsicQuery, insets EdgeInsets) float64 {
+	if query.IsWidth() {
+		return insets.Vertical()
+	}
+	return insets.Horizontal()
+}
+
+// nodeIntrinsic は margin、モディファイア、padding を考慮してノードの固有サイズを計算します
+func (lm *LayoutManager) nodeIntrinsic(node *core.Node, id core.NodeID, query IntrinsicQuery, other float64) float64 {
+	margin := EdgeInsetsFromProps(node.Props, "margin")
+	other = max(other-insetsAcross(query, margin), 0)
+	return lm.modifierIntrinsic(node.Modifier().Elements(), node, id, query, other) + insetsAlong(query, margin)
+}
+
+// modifierIntrinsic はモディファイアを外側から順に適用して固有サイズを計算します
+func (lm *LayoutManager) modifierIntrinsic(
+	elements []core.ModifierElement,
+	node *core.Node,
+	id core.NodeID,
+	query IntrinsicQuery,
+	other float64,
+) float64 {
+	if len(elements) == 0 {
+		return lm.paddedIntrinsic(node, id, query, other)
+	}
+
+	rest := elements[1:]
+	switch element := elements[0].(type) {
+	case core.PaddingModifier:
+		insets := EdgeInsets(element)
+		inner := lm.modifierIntrinsic(rest, node, id, query, max(other-insetsAcross(query, insets), 0))
+		return inner + insetsAlong(query, insets)
+
+	case core.SizeModifier:
+		// 固定されたサイズがあればそれが固有サイズになる
+		along, across := element.Width, element.Height
+		if !query.IsWidth() {
+			along, across = across, along
+		}
+		if along >= 0 {
+			return along
+		}
+		if across >= 0 {
+			other = across
+		}
+		return lm.modifierIntrinsic(rest, node, id, query, other)
+
+	case core.IntrinsicSizeModifier:
+		// 固有サイズに固定された軸は、指定された最小・最大の固有サイズを問い合わせる
+		size := element.Width
+		if !query.IsWidth() {
+			size = element.Height
+		}
+		if size != core.IntrinsicUnspecified {
+			query = intrinsicQuery(query.axis(), size == core.IntrinsicMin)
+		}
+		return lm.modifierIntrinsic(rest, node, id, query, other)
+
+	default:
+		return lm.modifierIntrinsic(rest, node, id, query, other)
+	}
+}
+
+// paddedIntrinsic はプロパティの padding を考慮して内容の固有サイズを計算します
+func (lm *LayoutManager) paddedIntrinsic(node *core.Node, id core.NodeID, query IntrinsicQuery, other float64) float64 {
+	padding := EdgeInsetsFromProps(node.Props, "padding")
+	other = max(other-insetsAcross(query, padding), 0)
+	return lm.contentIntrinsic(node, id, query, other) + insetsAlong(query, padding)
+}
+
+// contentIntrinsic はノードの内容の固有サイズを計算します
+func (lm *LayoutManager) contentIntrinsic(node *core.Node, id core.NodeID, query IntrinsicQuery, other float64) float64 {
+	switch node.Type {
+	case core.TextNodeType:
+		return lm.textIntrinsic(node.Props, query, other)
+
+	case core.CustomNodeType:
+		if node.Component == nil {
+			return lm.firstChildIntrinsic(node, id, query, other)
+		}
+		var content *core.Node
+		if len(node.Children) > 0 {
+			content = node.Children[0]
+		} else {
+			content = node.Component.Render(node.Props)
+		}
+		if content == nil {
+			return 0
+		}
+		contentID := core.ChildNodeID(id, content.Key, 0)
+		if policy, ok := node.Component.(MeasurePolicy); ok {
+			return lm.policyIntrinsic(policy, content, contentID, query, other)
+		}
+		return lm.nodeIntrinsic(content, contentID, query, other)
+
+	case core.LazyColumnNodeType, core.LazyRowNodeType:
+		return lm.lazyListIntrinsic(node, id, query, other)
+
+	case core.VerticalScrollNodeType, core.HorizontalScrollNodeType:
+		policy, _ := scrollContentPolicy(node)
+		return lm.policyIntrinsic(policy, node, id, query, other)
+
+	default:
+		if policy := lm.measurePolicyFor(node); policy != nil {
+			return lm.policyIntrinsic(policy, node, id, query, other)
+		}
+		return lm.firstChildIntrinsic(node, id, query, other)
+	}
+}
+
+// firstChildIntrinsic は最初の子ノードの固有サイズを返します（calculateChildrenLayout に対応）
+func (lm *LayoutManager) firstChildIntrinsic(node *core.Node, id core.NodeID, query IntrinsicQuery, other float64) float64 {
+	if len(node.Children) == 0 {
+		return 0
+	}
+	child := node.Children[0]
+	return lm.nodeIntrinsic(child, core.ChildNodeID(id, child.Key, 0), query, other)
+}
+
+// policyIntrinsic は MeasurePolicy の固有サイズを計算します
+func (lm *LayoutManager) policyIntrinsic(
+	policy MeasurePolicy,
+	node *core.Node,
+	id core.NodeID,
+	query IntrinsicQuery,
+	other float64,
+) float64 {
+	measurables := make([]Measurable, len(node.Children))
+	for i, child := range node.Children {
+		measurables[i] = &measurable{manager: lm, node: child, id: core.ChildNodeID(id, child.Key, i)}
+	}
+
+	if intrinsic, ok := policy.(IntrinsicMeasurePolicy); ok {
+		return intrinsic.IntrinsicSize(measurables, query, other)
+	}
+
+	// 反対の軸だけを制限して測定する
+	constraints := Constraints{MaxSize: query.axis().size(Unbounded, other)}
+	size := policy.Measure(measurables, constraints).Size
+	return query.axis().main(size)
+}
+
+// textIntrinsic はテキストの固有サイズを計算します
+// 最小固有幅は最も長い単語の幅、最大固有幅は折り返さない場合の幅です
+func (lm *LayoutManager) textIntrinsic(props core.Props, query IntrinsicQuery, other float64) float64 {
+	text := props.GetString("text", "")
+	switch query {
+	case MinIntrinsicWidth:
+		if !props.GetBool("softWrap", true) {
+			return LayoutText(text, props, Unbounded, lm.textMeasurer).Size.Width
+		}
+		return minTextWidth(text, props, lm.textMeasurer)
+	case MaxIntrinsicWidth:
+		return LayoutText(text, props, Unbounded, lm.textMeasurer).Size.Width
+	default:
+		return LayoutText(text, props, other, lm.textMeasurer).Size.Height
+	}
+}
+
+// IntrinsicSize は子ノードを並べたときの固有サイズを返します
+func (p RowPolicy) IntrinsicSize(measurables []Measurable, query IntrinsicQuery, other float64) float64 {
+	return linearIntrinsic(measurables, horizontalAxis, p.Spacing, query, other)
+}
+
+// IntrinsicSize は子ノードを並べたときの固有サイズを返します
+func (p ColumnPolicy) IntrinsicSize(measurables []Measurable, query IntrinsicQuery, other float64) float64 {
+	return linearIntrinsic(measurables, verticalAxis, p.Spacing, query, other)
+}
+
+// linearIntrinsic は子ノードを主軸方向に並べたときの固有サイズを計算します
+//
+// 主軸方向の問い合わせでは子ノードの固有サイズと間隔の合計を返します。
+// 重み付きの子ノードは、固有サイズを重みで割った値が最大のものに合わせて全体を割り当てたものとします。
+// 交差軸方向の問い合わせでは、重みのない子ノードに最大固有サイズ、
+// 重み付きの子ノードに残りの空きを割り当てたときの、子ノードの固有サイズの最大値を返します。
+func linearIntrinsic(measurables []Measurable, axis axis, spacing float64, query IntrinsicQuery, other float64) float64 {
+	if len(measurables) == 0 {
+		return 0
+	}
+	totalSpacing := spacing * float64(len(measurables)-1)
+
+	if query.axis() == axis {
+		total := totalSpacing
+		totalWeight, weightedUnit := 0.0, 0.0
+		for _, child := range measurables {
+			size := child.IntrinsicSize(query, other)
+			if weight, ok := childWeight(child); ok {
+				totalWeight += weight.Weight
+				weightedUnit = max(weightedUnit, size/weight.Weight)
+				continue
+			}
+			total += size
+		}
+		return total + weightedUnit*totalWeight
+	}
+
+	// 交差軸方向の問い合わせでは、other は主軸方向の長さになる
+	mainQuery := intrinsicQuery(axis, false)
+	remaining := max(other-totalSpacing, 0)
+	mainSizes := make([]float64, len(measurables))
+	totalWeight := 0.0
+	for i, child := range measurables {
+		if weight, ok := childWeight(child); ok {
+			totalWeight += weight.Weight
+			continue
+		}
+		mainSizes[i] = min(child.IntrinsicSize(mainQuery, Unbounded), remaining)
+		remaining -= mainSizes[i]
+	}
+
+	result := 0.0
+	for i, child := range measurables {
+		if weight, ok := childWeight(child); ok {
+			mainSizes[i] = remaining * weight.Weight / totalWeight
+		}
+		result = max(result, child.IntrinsicSize(query, mainSizes[i]))
+	}
+	return result
+}
+
+// IntrinsicSize は子ノードを重ねたときの固有サイズ（子ノードの固有サイズの最大値）を返します
+func (p BoxPolicy) IntrinsicSize(measurables []Measurable, query IntrinsicQuery, other float64) float64 {
+	fixed, across := p.Width, p.Height
+	if !query.IsWidth() {
+		fixed, across = across, fixed
+	}
+	if fixed > 0 {
+		return fixed
+	}
+	if across > 0 {
+		other = across
+	}
+
+	result := 0.0
+	for _, child := range measurables {
+		result = max(result, child.IntrinsicSize(query, other))
+	}
+	return result
+}
+
+// IntrinsicSize は最初の子ノードの固有サイズを返します
+func (ContainerPolicy) IntrinsicSize(measurables []Measurable, query IntrinsicQuery, other float64) float64 {
+	if len(measurables) == 0 {
+		return 0
+	}
+	return measurables[0].IntrinsicSize(query, other)
+}
@@ -0,0 +1,121 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+// text は Text ノードを作成します
+func text(key, value string) *core.Node {
+	return core.NewNode(core.TextNodeType, key, core.Props{"text": value})
+}
+
+func TestIntrinsicSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		node  *core.Node
+		query IntrinsicQuery
+		other float64
+		want  float64
+	}{
+		{"text min width is the longest word", text("t", "hello world"), MinIntrinsicWidth, Unbounded, 5},
+		{"text max width is unwrapped", text("t", "hello world"), MaxIntrinsicWidth, Unbounded, 11},
+		{"text height at width", text("t", "hello world"), MinIntrinsicHeight, 5, 2},
+		{
+			"row sums widths and spacing",
+			container(core.RowNodeType, "row", core.Props{"spacing": 1.0}, text("t", "ab cd"), box("b", 3, 1)),
+			MaxIntrinsicWidth, Unbounded, 9,
+		},
+		{
+			"row min width",
+			container(core.RowNodeType, "row", core.Props{"spacing": 1.0}, text("t", "ab cd"), box("b", 3, 1)),
+			MinIntrinsicWidth, Unbounded, 6,
+		},
+		{
+			"row height is the tallest child",
+			container(core.RowNodeType, "row", nil, text("t", "ab cd"), box("b", 3, 1)),
+			MaxIntrinsicHeight, 5, 1,
+		},
+		{
+			"weighted children keep their ratio",
+			container(core.RowNodeType, "row", nil,
+				modified(box("a", 2, 1), core.NewModifier().Weight(1)),
+				modified(box("b", 3, 1), core.NewModifier().Weight(2)),
+			),
+			MaxIntrinsicWidth, Unbounded, 6,
+		},
+		{
+			"column stacks heights",
+			container(core.ColumnNodeType, "column", core.Props{"spacing": 2.0}, box("a", 1, 2), box("b", 1, 3)),
+			MinIntrinsicHeight, Unbounded, 7,
+		},
+		{
+			"padding prop",
+			container(core.ColumnNodeType, "column", core.Props{"padding": 1.0}, text("t", "hello world")),
+			MaxIntrinsicWidth, Unbounded, 13,
+		},
+		{
+			"padding reduces the other axis",
+			container(core.ColumnNodeType, "column", core.Props{"padding": 1.0}, text("t", "hello world")),
+			MinIntrinsicHeight, 7, 4,
+		},
+		{
+			"margin and padding modifier",
+			modified(container(core.ColumnNodeType, "column", core.Props{"margin": 1.0}, box("a", 2, 2)), core.NewModifier().Padding(1)),
+			MaxIntrinsicWidth, Unbounded, 6,
+		},
+		{
+			"size modifier fixes the axis",
+			modified(text("t", "hello world"), core.NewModifier().Width(8)),
+			MinIntrinsicWidth, Unbounded, 8,
+		},
+		{
+			"size modifier limits the other axis",
+			modified(text("t", "hello world"), core.NewModifier().Width(5)),
+			MaxIntrinsicHeight, Unbounded, 2,
+		},
+		{
+			"intrinsic modifier changes the query",
+			modified(text("t", "hello world"), core.NewModifier().IntrinsicWidth(core.IntrinsicMin)),
+			MaxIntrinsicWidth, Unbounded, 5,
+		},
+	}
+
+	lm := NewLayoutManager()
+	lm.SetTextMeasurer(TerminalTextMeasurer{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got float64
+			switch tt.query {
+			case MinIntrinsicWidth:
+				got = lm.MinIntrinsicWidth(tt.node, tt.other)
+			case MaxIntrinsicWidth:
+				got = lm.MaxIntrinsicWidth(tt.node, tt.other)
+			case MinIntrinsicHeight:
+				got = lm.MinIntrinsicHeight(tt.node, tt.other)
+			case MaxIntrinsicHeight:
+				got = lm.MaxIntrinsicHeight(tt.node, tt.other)
+			}
+			if got != tt.want {
+				t.Errorf("intrinsic size = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntrinsicWidthModifierMatchesWidestChild(t *testing.T) {
+	// 子ノードを列の幅いっぱいに広げても、列の幅は最も広い子ノードの固有幅になる
+	root := modified(
+		container(core.ColumnNodeType, "column", nil,
+			modified(text("short", "ab"), core.NewModifier().FillMaxWidth(1)),
+			modified(text("long", "hello"), core.NewModifier().FillMaxWidth(1)),
+		),
+		core.NewModifier().IntrinsicWidth(core.IntrinsicMax),
+	)
+	expectRects(t, calculate(root, Loose(Size{Width: 20, Height: 20})), map[core.NodeID]Rect{
+		"column#0":         rect(0, 0, 5, 2),
+		"column#0/short#0": rect(0, 0, 5, 1),
+		"column#0/long#1":  rect(0, 1, 5, 1),
+	})
+}
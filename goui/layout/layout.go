@@ -1,6 +1,8 @@
 package layout
 
 import (
+	"math"
+
 	"github.com/tak/goui/core"
 )
 
@@ -81,210 +83,335 @@ func (c Constraints) Constrain(size Size) Size {
 	return Size{Width: width, Height: height}
 }
 
+// Deflate は制約を指定した量だけ縮小します（0 未満にはなりません）
+func (c Constraints) Deflate(horizontal, vertical float64) Constraints {
+	return Constraints{
+		MinSize: Size{
+			Width:  max(c.MinSize.Width-horizontal, 0),
+			Height: max(c.MinSize.Height-vertical, 0),
+		},
+		MaxSize: Size{
+			Width:  max(c.MaxSize.Width-horizontal, 0),
+			Height: max(c.MaxSize.Height-vertical, 0),
+		},
+	}
+}
+
+// Tighten は指定した軸のサイズを制約内で固定した制約を返します
+// 負の値（core.Unspecified）を指定した軸は変更しません
+func (c Constraints) Tighten(width, height float64) Constraints {
+	result := c
+	if width >= 0 {
+		fixed := c.Constrain(Size{Width: width}).Width
+		result.MinSize.Width = fixed
+		result.MaxSize.Width = fixed
+	}
+	if height >= 0 {
+		fixed := c.Constrain(Size{Height: height}).Height
+		result.MinSize.Height = fixed
+		result.MaxSize.Height = fixed
+	}
+	return result
+}
+
+// Inset は各辺を指定した量だけ内側に縮めた矩形を返します
+func (r Rect) Inset(start, top, end, bottom float64) Rect {
+	return Rect{
+		Position: Position{X: r.Position.X + start, Y: r.Position.Y + top},
+		Size: Size{
+			Width:  max(r.Size.Width-start-end, 0),
+			Height: max(r.Size.Height-top-bottom, 0),
+		},
+	}
+}
+
+// Contains は位置が矩形の内側にあるかを判定します
+func (r Rect) Contains(position Position) bool {
+	return position.X >= r.Position.X && position.X < r.Position.X+r.Size.Width &&
+		position.Y >= r.Position.Y && position.Y < r.Position.Y+r.Size.Height
+}
+
+// Intersect は2つの矩形の共通部分を返します（重ならない場合はサイズが 0 になります）
+func (r Rect) Intersect(other Rect) Rect {
+	x1 := max(r.Position.X, other.Position.X)
+	y1 := max(r.Position.Y, other.Position.Y)
+	x2 := min(r.Position.X+r.Size.Width, other.Position.X+other.Size.Width)
+	y2 := min(r.Position.Y+r.Size.Height, other.Position.Y+other.Size.Height)
+	return Rect{
+		Position: Position{X: x1, Y: y1},
+		Size:     Size{Width: max(x2-x1, 0), Height: max(y2-y1, 0)},
+	}
+}
+
 // LayoutManager はレイアウト計算を担当します
 type LayoutManager struct {
 	// レイアウト計算に必要な状態やキャッシュを保持
+	textMeasurer TextMeasurer
+	policies     map[core.NodeType]MeasurePolicyFactory
 }
 
 // NewLayoutManager は新しいレイアウトマネージャーを作成します
+// テキストはフォントサイズに基づく FontTextMeasurer で計測されます
+// Row、Column、Box、Container、Layout、FlowRow、FlowColumn、Grid、ConstraintLayout の各ノードタイプには組み込みの MeasurePolicy が登録されます
 func NewLayoutManager() *LayoutManager {
-	return &LayoutManager{}
+	return &LayoutManager{
+		textMeasurer: FontTextMeasurer{},
+		policies: map[core.NodeType]MeasurePolicyFactory{
+			core.RowNodeType:    rowPolicyFromProps,
+			core.ColumnNodeType: columnPolicyFromProps,
+			core.BoxNodeType:    boxPolicyFromProps,
+			core.ContainerNodeType: func(core.Props) MeasurePolicy {
+				return ContainerPolicy{}
+			},
+			core.LayoutNodeType:           layoutNodePolicy,
+			core.FlowRowNodeType:          flowRowPolicyFromProps,
+			core.FlowColumnNodeType:       flowColumnPolicyFromProps,
+			core.GridNodeType:             gridPolicyFromProps,
+			core.ConstraintLayoutNodeType: constraintLayoutPolicyFromProps,
+		},
+	}
+}
+
+// SetTextMeasurer はテキストの計測に使用する TextMeasurer を設定します
+// nil を指定した場合は FontTextMeasurer に戻ります
+func (lm *LayoutManager) SetTextMeasurer(measurer TextMeasurer) {
+	if measurer == nil {
+		measurer = FontTextMeasurer{}
+	}
+	lm.textMeasurer = measurer
+}
+
+// TextMeasurer は現在のテキスト計測方法を返します
+func (lm *LayoutManager) TextMeasurer() TextMeasurer {
+	return lm.textMeasurer
 }
 
 // CalculateLayout はノードツリーのレイアウトを計算します
-func (lm *LayoutManager) CalculateLayout(root *core.Node, constraints Constraints) map[string]Rect {
+// 結果はノードの ID（core.NodeID）をキーとするマップで返されます
+func (lm *LayoutManager) CalculateLayout(root *core.Node, constraints Constraints) map[core.NodeID]Rect {
 	// レイアウト計算の結果を格納するマップ
-	layout := make(map[string]Rect)
+	layout := make(map[core.NodeID]Rect)
 	
 	// 再帰的にレイアウトを計算
-	lm.calculateNodeLayout(root, constraints, Position{X: 0, Y: 0}, layout)
+	lm.calculateNodeLayout(root, core.RootNodeID(root), constraints, Position{X: 0, Y: 0}, layout)
 	
 	return layout
 }
 
 // calculateNodeLayout は単一ノードとその子のレイアウトを計算します
+//
+// margin はノードの外側の余白で、ノードの矩形には含まれませんが親に返すサイズには含まれます。
+// padding はモディファイアの内側、ノードの内容の外側の余白で、ノードの矩形に含まれます。
+// どちらもすべてのノードタイプに適用されます。
 func (lm *LayoutManager) calculateNodeLayout(
 	node *core.Node,
+	id core.NodeID,
 	constraints Constraints,
 	position Position,
-	layout map[string]Rect,
+	layout map[core.NodeID]Rect,
 ) Size {
-	// ノードタイプに基づいてレイアウト計算を行う
-	var size Size
+	// 外側の余白の分だけ制約を縮め、位置をずらす
+	margin := EdgeInsetsFromProps(node.Props, "margin")
+	innerConstraints := constraints.Deflate(margin.Horizontal(), margin.Vertical())
+	innerPosition := Position{X: position.X + margin.Start, Y: position.Y + margin.Top}
 	
-	switch node.Type {
-	case core.TextNodeType:
-		// テキストノードのサイズ計算（実際にはフォントメトリクスが必要）
-		text := node.Props.GetString("text", "")
-		fontSize := node.Props.GetFloat("fontSize", 16.0)
-		// 単純化のため、文字数とフォントサイズに基づく簡易計算
-		size = Size{
-			Width:  float64(len(text)) * fontSize * 0.6,
-			Height: fontSize * 1.2,
-		}
+	// モディファイアを外側から順に適用してサイズを計算
+	size := lm.applyLayoutModifiers(node.Modifier().Elements(), node, id, innerConstraints, innerPosition, layout)
+	
+	// レイアウト結果を記録
+	layout[id] = Rect{
+		Position: innerPosition,
+		Size:     size,
+	}
+	
+	return constraints.Constrain(Size{
+		Width:  size.Width + margin.Horizontal(),
+		Height: size.Height + margin.Vertical(),
+	})
+}
+
+// applyLayoutModifiers はレイアウト用のモディファイアを外側から順に適用し、
+// 最も内側でノード自身のレイアウトを計算します
+func (lm *LayoutManager) applyLayoutModifiers(
+	elements []core.ModifierElement,
+	node *core.Node,
+	id core.NodeID,
+	constraints Constraints,
+	position Position,
+	layout map[core.NodeID]Rect,
+) Size {
+	if len(elements) == 0 {
+		return lm.calculatePaddedLayout(node, id, constraints, position, layout)
+	}
+	
+	switch element := elements[0].(type) {
+	case core.PaddingModifier:
+		// 内側の要素は余白の分だけ小さい制約で、余白の分だけずらした位置に配置する
+		horizontal := element.Start + element.End
+		vertical := element.Top + element.Bottom
+		innerPosition := Position{X: position.X + element.Start, Y: position.Y + element.Top}
+		innerConstraints := constraints.Deflate(horizontal, vertical)
+		innerSize := lm.applyLayoutModifiers(elements[1:], node, id, innerConstraints, innerPosition, layout)
+		return constraints.Constrain(Size{
+			Width:  innerSize.Width + horizontal,
+			Height: innerSize.Height + vertical,
+		})
 		
-	case core.BoxNodeType:
-		// ボックスノードのサイズ計算
-		width := node.Props.GetFloat("width", 0)
-		height := node.Props.GetFloat("height", 0)
+	case core.SizeModifier:
+		// 指定された軸のサイズを固定する
+		innerConstraints := constraints.Tighten(element.Width, element.Height)
+		return lm.applyLayoutModifiers(elements[1:], node, id, innerConstraints, position, layout)
 		
-		// 明示的なサイズが指定されている場合はそれを使用
-		if width > 0 && height > 0 {
-			size = Size{Width: width, Height: height}
-		} else {
-			// 子ノードに基づいてサイズを計算
-			size = lm.calculateChildrenLayout(node, constraints, position, layout)
+	case core.FillModifier:
+		// 最大サイズに制限がある軸を、その割合のサイズに固定する
+		width, height := core.Unspecified, core.Unspecified
+		if element.WidthFraction > 0 && !math.IsInf(constraints.MaxSize.Width, 1) {
+			width = constraints.MaxSize.Width * element.WidthFraction
 		}
+		if element.HeightFraction > 0 && !math.IsInf(constraints.MaxSize.Height, 1) {
+			height = constraints.MaxSize.Height * element.HeightFraction
+		}
+		innerConstraints := constraints.Tighten(width, height)
+		return lm.applyLayoutModifiers(elements[1:], node, id, innerConstraints, position, layout)
 		
-		// パディングを追加
-		padding := node.Props.GetFloat("padding", 0)
-		size.Width += padding * 2
-		size.Height += padding * 2
-		
-	case core.RowNodeType:
-		// 行レイアウト（水平方向に子ノードを配置）
-		size = lm.calculateRowLayout(node, constraints, position, layout)
-		
-	case core.ColumnNodeType:
-		// 列レイアウト（垂直方向に子ノードを配置）
-		size = lm.calculateColumnLayout(node, constraints, position, layout)
-		
-	case core.ContainerNodeType:
-		// コンテナは単一の子ノードを持ち、そのサイズに合わせる
-		if len(node.Children) > 0 {
-			size = lm.calculateNodeLayout(node.Children[0], constraints, position, layout)
-		} else {
-			size = Size{Width: 0, Height: 0}
+	case core.IntrinsicSizeModifier:
+		// 内側の要素の固有サイズを問い合わせ、その軸のサイズを固定する
+		innerConstraints := constraints
+		if element.Width != core.IntrinsicUnspecified {
+			query := intrinsicQuery(horizontalAxis, element.Width == core.IntrinsicMin)
+			width := lm.modifierIntrinsic(elements[1:], node, id, query, innerConstraints.MaxSize.Height)
+			innerConstraints = innerConstraints.Tighten(width, core.Unspecified)
+		}
+		if element.Height != core.IntrinsicUnspecified {
+			query := intrinsicQuery(verticalAxis, element.Height == core.IntrinsicMin)
+			height := lm.modifierIntrinsic(elements[1:], node, id, query, innerConstraints.MaxSize.Width)
+			innerConstraints = innerConstraints.Tighten(core.Unspecified, height)
 		}
+		return lm.applyLayoutModifiers(elements[1:], node, id, innerConstraints, position, layout)
 		
 	default:
-		// カスタムノードやその他のノードタイプ
-		// コンポーネントがある場合はそのレンダリング結果を使用
-		if node.Component != nil {
-			// コンポーネントのレンダリング結果に対してレイアウト計算
-			renderedNode := node.Component.Render(node.Props)
-			size = lm.calculateNodeLayout(renderedNode, constraints, position, layout)
-		} else {
-			// デフォルトでは子ノードに基づいてサイズを計算
-			size = lm.calculateChildrenLayout(node, constraints, position, layout)
-		}
+		// 描画用のモディファイアはレイアウトに影響しない
+		return lm.applyLayoutModifiers(elements[1:], node, id, constraints, position, layout)
 	}
-	
-	// 制約に従ってサイズを調整
-	size = constraints.Constrain(size)
-	
-	// レイアウト結果を記録
-	layout[node.Key] = Rect{
-		Position: position,
-		Size:     size,
-	}
-	
-	return size
 }
 
-// calculateChildrenLayout は子ノードのレイアウトを計算します（デフォルト実装）
-func (lm *LayoutManager) calculateChildrenLayout(
+// calculatePaddedLayout はプロパティの padding を適用し、その内側でノードの内容のレイアウトを計算します
+// 内容には余白の分だけ縮めた制約を渡し、余白の分だけずらした位置に配置します
+func (lm *LayoutManager) calculatePaddedLayout(
 	node *core.Node,
+	id core.NodeID,
 	constraints Constraints,
 	position Position,
-	layout map[string]Rect,
+	layout map[core.NodeID]Rect,
 ) Size {
-	if len(node.Children) == 0 {
-		return Size{Width: 0, Height: 0}
+	padding := EdgeInsetsFromProps(node.Props, "padding")
+	if padding.IsZero() {
+		return lm.calculateContentLayout(node, id, constraints, position, layout)
 	}
 	
-	// 単純に最初の子ノードのサイズを使用（実際にはより複雑なロジックが必要）
-	return lm.calculateNodeLayout(node.Children[0], constraints, position, layout)
+	innerConstraints := constraints.Deflate(padding.Horizontal(), padding.Vertical())
+	innerPosition := Position{X: position.X + padding.Start, Y: position.Y + padding.Top}
+	innerSize := lm.calculateContentLayout(node, id, innerConstraints, innerPosition, layout)
+	return constraints.Constrain(Size{
+		Width:  innerSize.Width + padding.Horizontal(),
+		Height: innerSize.Height + padding.Vertical(),
+	})
 }
 
-// calculateRowLayout は行レイアウトを計算します
-func (lm *LayoutManager) calculateRowLayout(
+// calculateContentLayout はモディファイアの内側にあるノード自身のレイアウトを計算します
+func (lm *LayoutManager) calculateContentLayout(
 	node *core.Node,
+	id core.NodeID,
 	constraints Constraints,
 	position Position,
-	layout map[string]Rect,
+	layout map[core.NodeID]Rect,
 ) Size {
-	if len(node.Children) == 0 {
-		return Size{Width: 0, Height: 0}
-	}
-	
-	spacing := node.Props.GetFloat("spacing", 0)
-	x := position.X
-	maxHeight := 0.0
+	// ノードタイプに基づいてレイアウト計算を行う
+	var size Size
 	
-	// 各子ノードを水平方向に配置
-	for _, child := range node.Children {
-		// 子ノードの制約を計算
-		childConstraints := Constraints{
-			MinSize: Size{Width: 0, Height: 0},
-			MaxSize: Size{
-				Width:  constraints.MaxSize.Width - (x - position.X),
-				Height: constraints.MaxSize.Height,
-			},
-		}
+	switch node.Type {
+	case core.TextNodeType:
+		// テキストは最大幅で折り返し、行ごとのサイズは TextMeasurer で計測する
+		text := node.Props.GetString("text", "")
+		size = LayoutText(text, node.Props, constraints.MaxSize.Width, lm.textMeasurer).Size
 		
-		// 子ノードのレイアウトを計算
-		childPos := Position{X: x, Y: position.Y}
-		childSize := lm.calculateNodeLayout(child, childConstraints, childPos, layout)
+	case core.CustomNodeType:
+		// カスタムノードはコンポーネントのレンダリング結果をレイアウトする
+		size = lm.calculateComponentLayout(node, id, constraints, position, layout)
 		
-		// 次の子ノードのX座標を更新
-		x += childSize.Width + spacing
+	case core.LazyColumnNodeType, core.LazyRowNodeType:
+		// 遅延リストは表示範囲の項目だけを構成して子ノードにする
+		size = lm.calculateLazyListLayout(node, id, constraints, position, layout)
 		
-		// 最大の高さを記録
-		if childSize.Height > maxHeight {
-			maxHeight = childSize.Height
+	case core.VerticalScrollNodeType, core.HorizontalScrollNodeType:
+		// スクロールコンテナは子ノードを主軸方向に制約なしで並べ、スクロール位置の分だけずらす
+		size = lm.calculateScrollLayout(node, id, constraints, position, layout)
+		
+	default:
+		// 登録された MeasurePolicy で子ノードを測定・配置する
+		if policy := lm.measurePolicyFor(node); policy != nil {
+			size = lm.measureWithPolicy(policy, node, id, constraints, position, layout)
+		} else {
+			size = lm.calculateChildrenLayout(node, id, constraints, position, layout)
 		}
 	}
 	
-	// 行の合計サイズを返す
-	return Size{
-		Width:  x - position.X - spacing, // 最後のスペースを除く
-		Height: maxHeight,
-	}
+	// 制約に従ってサイズを調整
+	return constraints.Constrain(size)
 }
 
-// calculateColumnLayout は列レイアウトを計算します
-func (lm *LayoutManager) calculateColumnLayout(
+// calculateComponentLayout はコンポーネントのレンダリング結果のレイアウトを計算します
+//
+// Composer で構成済みの場合はレンダリング結果が子ノードになっており、
+// 未構成の場合はここでコンポーネントをレンダリングします。
+// コンポーネントが MeasurePolicy を実装している場合は、レンダリング結果の子ノードをその方法で配置します。
+func (lm *LayoutManager) calculateComponentLayout(
 	node *core.Node,
+	id core.NodeID,
 	constraints Constraints,
 	position Position,
-	layout map[string]Rect,
+	layout map[core.NodeID]Rect,
 ) Size {
-	if len(node.Children) == 0 {
-		return Size{Width: 0, Height: 0}
+	if node.Component == nil {
+		// コンポーネントがない場合は子ノードに基づいてサイズを計算
+		return lm.calculateChildrenLayout(node, id, constraints, position, layout)
 	}
 	
-	spacing := node.Props.GetFloat("spacing", 0)
-	y := position.Y
-	maxWidth := 0.0
+	var content *core.Node
+	if len(node.Children) > 0 {
+		content = node.Children[0]
+	} else {
+		content = node.Component.Render(node.Props)
+	}
+	if content == nil {
+		return Size{Width: 0, Height: 0}
+	}
+	contentID := core.ChildNodeID(id, content.Key, 0)
 	
-	// 各子ノードを垂直方向に配置
-	for _, child := range node.Children {
-		// 子ノードの制約を計算
-		childConstraints := Constraints{
-			MinSize: Size{Width: 0, Height: 0},
-			MaxSize: Size{
-				Width:  constraints.MaxSize.Width,
-				Height: constraints.MaxSize.Height - (y - position.Y),
-			},
-		}
-		
-		// 子ノードのレイアウトを計算
-		childPos := Position{X: position.X, Y: y}
-		childSize := lm.calculateNodeLayout(child, childConstraints, childPos, layout)
-		
-		// 次の子ノードのY座標を更新
-		y += childSize.Height + spacing
-		
-		// 最大の幅を記録
-		if childSize.Width > maxWidth {
-			maxWidth = childSize.Width
-		}
+	policy, ok := node.Component.(MeasurePolicy)
+	if !ok {
+		return lm.calculateNodeLayout(content, contentID, constraints, position, layout)
 	}
 	
-	// 列の合計サイズを返す
-	return Size{
-		Width:  maxWidth,
-		Height: y - position.Y - spacing, // 最後のスペースを除く
+	// レンダリング結果のノードはコンポーネントと同じ領域を占める
+	size := lm.measureWithPolicy(policy, content, contentID, constraints, position, layout)
+	layout[contentID] = Rect{Position: position, Size: size}
+	return size
+}
+
+// calculateChildrenLayout は子ノードのレイアウトを計算します（デフォルト実装）
+func (lm *LayoutManager) calculateChildrenLayout(
+	node *core.Node,
+	id core.NodeID,
+	constraints Constraints,
+	position Position,
+	layout map[core.NodeID]Rect,
+) Size {
+	if len(node.Children) == 0 {
+		return Size{Width: 0, Height: 0}
 	}
+	
+	// 単純に最初の子ノードのサイズを使用（実際にはより複雑なロジックが必要）
+	childID := core.ChildNodeID(id, node.Children[0].Key, 0)
+	return lm.calculateNodeLayout(node.Children[0], childID, constraints, position, layout)
 }
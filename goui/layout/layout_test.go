@@ -0,0 +1,100 @@
+package layout
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+// box は幅と高さを固定した Box を作成します
+func box(key string, width, height float64) *core.Node {
+	return core.NewNode(core.BoxNodeType, key, core.Props{"width": width, "height": height})
+}
+
+// container は子ノードを持つ任意のタイプのノードを作成します
+func container(nodeType core.NodeType, key string, props core.Props, children ...*core.Node) *core.Node {
+	if props == nil {
+		props = core.Props{}
+	}
+	node := core.NewNode(nodeType, key, props)
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	return node
+}
+
+// modified はノードにモディファイアを設定して返します
+func modified(node *core.Node, modifier core.Modifier) *core.Node {
+	node.Props[core.ModifierKey] = modifier
+	return node
+}
+
+// calculate は端末のセル単位でレイアウトを計算します
+func calculate(root *core.Node, constraints Constraints) map[core.NodeID]Rect {
+	lm := NewLayoutManager()
+	lm.SetTextMeasurer(TerminalTextMeasurer{})
+	return lm.CalculateLayout(root, constraints)
+}
+
+// rect は位置とサイズから矩形を作成します
+func rect(x, y, width, height float64) Rect {
+	return Rect{Position: Position{X: x, Y: y}, Size: Size{Width: width, Height: height}}
+}
+
+// expectRects は指定した ID の矩形がレイアウト結果と一致することを確認します
+func expectRects(t *testing.T, layout map[core.NodeID]Rect, want map[core.NodeID]Rect) {
+	t.Helper()
+	for id, wantRect := range want {
+		got, ok := layout[id]
+		if !ok {
+			t.Errorf("%s: no layout", id)
+			continue
+		}
+		if got != wantRect {
+			t.Errorf("%s: rect = %v, want %v", id, got, wantRect)
+		}
+	}
+}
+
+func TestConstraintsConstrain(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints Constraints
+		size        Size
+		want        Size
+	}{
+		{"within range", NewConstraints(0, 0, 10, 10), Size{Width: 5, Height: 5}, Size{Width: 5, Height: 5}},
+		{"clamped to max", NewConstraints(0, 0, 10, 10), Size{Width: 20, Height: 15}, Size{Width: 10, Height: 10}},
+		{"clamped to min", NewConstraints(3, 4, 10, 10), Size{Width: 1, Height: 1}, Size{Width: 3, Height: 4}},
+		{"tight", FixedSize(7, 8), Size{Width: 1, Height: 100}, Size{Width: 7, Height: 8}},
+		{"unbounded", Loose(Size{Width: Unbounded, Height: Unbounded}), Size{Width: 1e9, Height: 2}, Size{Width: 1e9, Height: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.constraints.Constrain(tt.size); got != tt.want {
+				t.Errorf("Constrain = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRectIntersect(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Rect
+		want Rect
+	}{
+		{"overlapping", rect(0, 0, 10, 10), rect(5, 5, 10, 10), rect(5, 5, 5, 5)},
+		{"contained", rect(0, 0, 10, 10), rect(2, 3, 4, 5), rect(2, 3, 4, 5)},
+		{"disjoint", rect(0, 0, 2, 2), rect(5, 5, 2, 2), rect(5, 5, 0, 0)},
+		{"infinite", rect(0, 0, math.Inf(1), math.Inf(1)), rect(1, 1, 3, 3), rect(1, 1, 3, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Intersect(tt.b); got != tt.want {
+				t.Errorf("Intersect = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
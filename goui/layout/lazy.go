@@ -0,0 +1,465 @@
+package layout
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tak/goui/core"
+)
+
+// LazyListItemInfo は遅延リストで表示されている項目の情報です
+type LazyListItemInfo struct {
+	// Index は項目の番号です
+	Index int
+	// Key は itemKey で指定された項目のキーです（指定がなければ空文字列）
+	Key string
+	// Offset は表示領域の先頭（contentPadding の内側）からの主軸方向の位置です
+	Offset float64
+	// Size は主軸方向のサイズです
+	Size float64
+}
+
+// LazyListLayoutInfo は遅延リストの直前のレイアウトの情報です
+type LazyListLayoutInfo struct {
+	// VisibleItems は表示範囲にある項目です（固定ヘッダーを含めて番号順）
+	VisibleItems []LazyListItemInfo
+	// TotalItems は項目の総数です
+	TotalItems int
+	// ViewportSize は contentPadding を除いた表示領域の主軸方向のサイズです
+	ViewportSize float64
+}
+
+// LazyListState は遅延リストのスクロール位置を保持します
+//
+// スクロール位置は先頭に表示する項目の番号と、その項目の先頭からのオフセットで表され、
+// 次のレイアウトで実際の項目のサイズに合わせて補正されます。
+// itemKey を指定したリストでは、前に先頭に表示していた項目のキーを追跡するため、
+// 前に項目が挿入・削除されても同じ項目が表示され続けます。
+// ScrollBy などで位置を変更した後は、再度レンダリングすると反映されます。
+type LazyListState struct {
+	mutex         sync.Mutex
+	firstIndex    int
+	firstOffset   float64
+	firstKey      string
+	pendingScroll float64
+	info          LazyListLayoutInfo
+	canForward    bool
+	canBackward   bool
+	// generation は ScrollToItem のたびに増え、レイアウト中に移動された位置を上書きしないために使用します
+	generation int
+}
+
+// NewLazyListState は index 番目の項目を offset だけスクロールした位置から表示する状態を作成します
+func NewLazyListState(index int, offset float64) *LazyListState {
+	return &LazyListState{firstIndex: index, firstOffset: offset}
+}
+
+// FirstVisibleItemIndex は先頭に表示されている項目の番号を返します
+func (s *LazyListState) FirstVisibleItemIndex() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.firstIndex
+}
+
+// FirstVisibleItemScrollOffset は先頭に表示されている項目がスクロールで隠れている量を返します
+func (s *LazyListState) FirstVisibleItemScrollOffset() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.firstOffset
+}
+
+// LayoutInfo は直前のレイアウトの情報を返します
+func (s *LazyListState) LayoutInfo() LazyListLayoutInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	info := s.info
+	info.VisibleItems = append([]LazyListItemInfo(nil), s.info.VisibleItems...)
+	return info
+}
+
+// CanScrollForward は末尾の方向にスクロールできるかを返します
+func (s *LazyListState) CanScrollForward() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.canForward
+}
+
+// CanScrollBackward は先頭の方向にスクロールできるかを返します
+func (s *LazyListState) CanScrollBackward() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.canBackward
+}
+
+// ScrollToItem は index 番目の項目を offset だけスクロールした位置を先頭にします
+func (s *LazyListState) ScrollToItem(index int, offset float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.firstIndex = max(index, 0)
+	s.firstOffset = offset
+	s.firstKey = ""
+	s.pendingScroll = 0
+	s.generation++
+}
+
+// ScrollBy は delta だけスクロールします（正の値で末尾の方向）
+// 範囲を超えた分は次のレイアウトで先頭または末尾に合わせて切り詰められます
+func (s *LazyListState) ScrollBy(delta float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pendingScroll += delta
+}
+
+// lazyListContent は遅延リストの項目を生成する関数です
+type lazyListContent struct {
+	count  int
+	item   func(index int) *core.Node
+	key    func(index int) string
+	sticky func(index int) bool
+}
+
+// lazyListContentFromProps はプロパティから項目を生成する関数を取得します
+func lazyListContentFromProps(props core.Props) lazyListContent {
+	content := lazyListContent{count: max(props.GetInt("itemCount", 0), 0)}
+	content.item, _ = props["itemContent"].(func(index int) *core.Node)
+	content.key, _ = props["itemKey"].(func(index int) string)
+	content.sticky, _ = props["stickyHeader"].(func(index int) bool)
+	if content.item == nil {
+		content.count = 0
+	}
+	return content
+}
+
+// slotKey は項目を構成するスロットのキーです
+// itemKey があればキー、なければ番号で項目を対応付けます
+func (c lazyListContent) slotKey(index int) interface{} {
+	if c.key == nil {
+		return index
+	}
+	return c.key(index)
+}
+
+// duplicateSlotKey は同じレイアウトの中でほかの項目と重複したキーの項目のスロットのキーです
+// 重複した項目どうしがスロットを共有しないよう、番号で区別します
+type duplicateSlotKey struct {
+	key   interface{}
+	index int
+}
+
+// keyOf は項目のキーを返します
+func (c lazyListContent) keyOf(index int) string {
+	if c.key == nil {
+		return ""
+	}
+	return c.key(index)
+}
+
+// indexOfKey はキーの項目の番号を返します
+// 前回の番号 hint の項目が同じキーであればそれを返し、見つからなければ -1 を返します
+func (c lazyListContent) indexOfKey(key string, hint int) int {
+	if hint >= 0 && hint < c.count && c.key(hint) == key {
+		return hint
+	}
+	for i := 0; i < c.count; i++ {
+		if c.key(i) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// lazyItem は構成・測定済みの項目です
+type lazyItem struct {
+	node   *core.Node
+	id     core.NodeID
+	size   Size
+	layout map[core.NodeID]Rect
+}
+
+// lazyPlacement は表示する項目とその主軸方向の位置です
+type lazyPlacement struct {
+	index    int
+	position float64
+}
+
+// rebaseLayout は from を ID の先頭に持つノードのレイアウトを to の下に移します
+func rebaseLayout(childLayout map[core.NodeID]Rect, from, to core.NodeID) map[core.NodeID]Rect {
+	if from == to {
+		return childLayout
+	}
+	result := make(map[core.NodeID]Rect, len(childLayout))
+	for id, rect := range childLayout {
+		if id == from {
+			result[to] = rect
+		} else if strings.HasPrefix(string(id), string(from)+"/") {
+			result[to+id[len(from):]] = rect
+		}
+	}
+	return result
+}
+
+// calculateLazyListLayout は遅延リストの表示範囲にある項目だけを構成してレイアウトします
+//
+// 項目は itemContent で生成されてノードの Subcomposition で構成され、
+// 表示範囲の項目（と固定ヘッダー）がノードの子ノードになります。
+// 表示範囲の前後 prefetch 個の項目も構成・測定されますが、配置はされません。
+// 項目は主軸方向には制約なしで測定されます。
+//
+// Composer で構成されたリストでは、項目の中のコンポーネントもコンポジションに加わり、
+// RememberState や副作用、状態の読み取りによる再構成を使用できます。
+// 項目は itemKey のキー（なければ番号）で前回の構成と対応付けられ、
+// キーが重複した項目はキーと番号の組で対応付けられます。
+// 表示範囲と prefetch の範囲から外れた項目は破棄されて、保持していた値も失われます。
+// LazyListState のロックは itemContent の呼び出し中には保持しないため、
+// itemContent から状態のゲッターを呼び出すことができます。
+func (lm *LayoutManager) calculateLazyListLayout(
+	node *core.Node,
+	id core.NodeID,
+	constraints Constraints,
+	position Position,
+	layout map[core.NodeID]Rect,
+) Size {
+	vertical := node.Type == core.LazyColumnNodeType
+	axis, alignmentKey := horizontalAxis, "verticalAlignment"
+	if vertical {
+		axis, alignmentKey = verticalAxis, "horizontalAlignment"
+	}
+	content := lazyListContentFromProps(node.Props)
+	state, _ := node.Props["state"].(*LazyListState)
+	if state == nil {
+		state = &LazyListState{}
+	}
+	spacing := node.Props.GetFloat("spacing", 0)
+	prefetch := node.Props.GetInt("prefetch", 2)
+	alignment := node.Props.GetAlignment(alignmentKey, core.AlignStart)
+
+	padding := EdgeInsetsFromProps(node.Props, "contentPadding")
+	paddingStart := Position{X: padding.Start, Y: padding.Top}
+	mainPadding := axis.main(Size{Width: padding.Horizontal(), Height: padding.Vertical()})
+	crossPadding := axis.cross(Size{Width: padding.Horizontal(), Height: padding.Vertical()})
+	viewport := max(axis.main(constraints.MaxSize)-mainPadding, 0)
+	maxCross := max(axis.cross(constraints.MaxSize)-crossPadding, 0)
+	childConstraints := axis.constraints(0, Unbounded, 0, maxCross)
+
+	// 項目は1回のレイアウトの中で一度だけ構成・測定する
+	subcomposition := node.Subcomposition()
+	items := make(map[int]*lazyItem)
+	claimed := make(map[interface{}]int)
+	measure := func(index int) *lazyItem {
+		if item, ok := items[index]; ok {
+			return item
+		}
+		// itemKey が重複していれば、先に構成した項目以外は番号で区別したスロットで構成する
+		slotKey := content.slotKey(index)
+		if owner, ok := claimed[slotKey]; ok && owner != index {
+			slotKey = duplicateSlotKey{key: slotKey, index: index}
+		} else {
+			claimed[slotKey] = index
+		}
+		child := subcomposition.Subcompose(slotKey, func() *core.Node {
+			child := content.item(index)
+			if child == nil {
+				child = core.NewNode(core.ContainerNodeType, "", core.Props{})
+			}
+			if content.key != nil {
+				child.Key = content.keyOf(index)
+			}
+			return child
+		})
+		itemID := core.ChildNodeID(id, child.Key, index)
+		size, childLayout := lm.measureChild(child, itemID, childConstraints)
+		item := &lazyItem{node: child, id: itemID, size: size, layout: childLayout}
+		items[index] = item
+		return item
+	}
+	mainSize := func(index int) float64 {
+		return axis.main(measure(index).size)
+	}
+
+	// スクロール位置を取り出し、項目の構成中はロックを保持しない
+	state.mutex.Lock()
+	first, offset := state.firstIndex, state.firstOffset+state.pendingScroll
+	firstKey, generation := state.firstKey, state.generation
+	state.pendingScroll = 0
+	state.mutex.Unlock()
+
+	// 先頭の項目を前回のキーで追跡し、保留中のスクロールを適用する
+	if content.key != nil && firstKey != "" {
+		if index := content.indexOfKey(firstKey, first); index >= 0 {
+			first = index
+		}
+	}
+
+	var placements []lazyPlacement
+	if content.count > 0 {
+		first = min(max(first, 0), content.count-1)
+		for offset < 0 && first > 0 {
+			first--
+			offset += mainSize(first) + spacing
+		}
+		offset = max(offset, 0)
+		for first < content.count-1 && offset >= mainSize(first)+spacing {
+			offset -= mainSize(first) + spacing
+			first++
+		}
+
+		// 表示領域が埋まるまで項目を並べる
+		start := 0 - offset
+		next, end := first, start
+		for next < content.count && (next == first || end+spacing < viewport) {
+			if next > first {
+				end += spacing
+			}
+			end += mainSize(next)
+			next++
+		}
+
+		// 末尾まで並べても表示領域が余る場合は、前の項目を足して末尾に合わせる
+		if next == content.count && end < viewport && !math.IsInf(viewport, 1) {
+			start += viewport - end
+			for start > 0 && first > 0 {
+				first--
+				start -= mainSize(first) + spacing
+			}
+			if start > 0 {
+				start = 0
+			}
+		}
+
+		// 表示領域の手前に隠れた項目を除いて位置を決める
+		position := start
+		for index := first; index < next; index++ {
+			size := mainSize(index)
+			if position+size > 0 || position >= 0 {
+				placements = append(placements, lazyPlacement{index: index, position: position})
+			}
+			position += size + spacing
+		}
+		// 項目の間隔だけが表示される場合は配置する項目がない
+		if len(placements) > 0 {
+			first, offset = placements[0].index, -placements[0].position
+		}
+	}
+
+	// 表示範囲の前後の項目を先に構成・測定しておく
+	var prefetched []*core.Node
+	if len(placements) > 0 {
+		last := placements[len(placements)-1].index
+		for i := 1; i <= prefetch; i++ {
+			if last+i < content.count {
+				prefetched = append(prefetched, measure(last+i).node)
+			}
+			if first-i >= 0 {
+				prefetched = append(prefetched, measure(first-i).node)
+			}
+		}
+	}
+
+	// 先頭の項目より前にある最も近い固定ヘッダーを、次のヘッダーに押し出されるまで先頭に固定する
+	header, headerPosition := -1, 0.0
+	if content.sticky != nil && len(placements) > 0 {
+		for index := first; index >= 0; index-- {
+			if content.sticky(index) {
+				header = index
+				break
+			}
+		}
+		if header >= 0 {
+			for _, placement := range placements {
+				if placement.index > header && content.sticky(placement.index) {
+					headerPosition = min(headerPosition, placement.position-mainSize(header))
+					break
+				}
+			}
+		}
+	}
+
+	// 子ノードを作り直し、固定ヘッダーは手前に描画されるよう最後に置く
+	ordered := make([]lazyPlacement, 0, len(placements)+1)
+	for _, placement := range placements {
+		if placement.index != header {
+			ordered = append(ordered, placement)
+		}
+	}
+	if header >= 0 {
+		ordered = append(ordered, lazyPlacement{index: header, position: headerPosition})
+	}
+
+	crossSize := 0.0
+	for _, placement := range ordered {
+		crossSize = max(crossSize, axis.cross(measure(placement.index).size))
+	}
+	crossSize = max(crossSize, axis.cross(constraints.MinSize)-crossPadding)
+
+	children := make([]*core.Node, len(ordered))
+	visible := make([]LazyListItemInfo, 0, len(ordered))
+	for i, placement := range ordered {
+		item := measure(placement.index)
+		children[i] = item.node
+		childID := core.ChildNodeID(id, item.node.Key, i)
+		crossOffset := alignment.Offset(crossSize - axis.cross(item.size))
+		childPosition := axis.offset(
+			Position{X: position.X + paddingStart.X, Y: position.Y + paddingStart.Y},
+			placement.position,
+			crossOffset,
+		)
+		placeChild(rebaseLayout(item.layout, item.id, childID), childPosition, layout)
+
+		visible = append(visible, LazyListItemInfo{
+			Index:  placement.index,
+			Key:    content.keyOf(placement.index),
+			Offset: placement.position,
+			Size:   axis.main(item.size),
+		})
+	}
+
+	subcomposition.SetChildren(children, prefetched)
+
+	// スクロール位置と表示の情報を記録する
+	sort.Slice(visible, func(i, j int) bool {
+		return visible[i].Index < visible[j].Index
+	})
+	key := ""
+	if content.count > 0 {
+		key = content.keyOf(first)
+	}
+	canForward, contentEnd := false, 0.0
+	if len(placements) > 0 {
+		last := placements[len(placements)-1]
+		contentEnd = last.position + mainSize(last.index)
+		canForward = last.index < content.count-1 || contentEnd > viewport
+	}
+
+	state.mutex.Lock()
+	// レイアウト中に ScrollToItem で移動された場合はその位置を次のレイアウトに残す
+	if state.generation == generation {
+		state.firstIndex, state.firstOffset = first, offset
+		state.firstKey = key
+	}
+	state.info = LazyListLayoutInfo{VisibleItems: visible, TotalItems: content.count, ViewportSize: viewport}
+	state.canBackward = first > 0 || offset > 0
+	state.canForward = canForward
+	state.mutex.Unlock()
+
+	return constraints.Constrain(axis.size(min(contentEnd, viewport)+mainPadding, crossSize+crossPadding))
+}
+
+// lazyListIntrinsic は遅延リストの固有サイズを返します
+// 主軸方向は与えられた領域を使用するため 0、交差軸方向は直前に構成した項目の最大値です
+func (lm *LayoutManager) lazyListIntrinsic(node *core.Node, id core.NodeID, query IntrinsicQuery, other float64) float64 {
+	axis := horizontalAxis
+	if node.Type == core.LazyColumnNodeType {
+		axis = verticalAxis
+	}
+	if query.axis() == axis {
+		return 0
+	}
+	padding := EdgeInsetsFromProps(node.Props, "contentPadding")
+	result := 0.0
+	for i, child := range node.Children {
+		result = max(result, lm.nodeIntrinsic(child, core.ChildNodeID(id, child.Key, i), query, Unbounded))
+	}
+	return result + axis.cross(Size{Width: padding.Horizontal(), Height: padding.Vertical()})
+}
@@ -0,0 +1,361 @@
+package layout
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+// expectUnlocked は項目の構成中に LazyListState のロックが保持されていないことを確認します
+// ロックが保持されていれば、項目の中で状態のゲッターを呼び出すとデッドロックします
+func expectUnlocked(t *testing.T, state *LazyListState) bool {
+	t.Helper()
+	if !state.mutex.TryLock() {
+		t.Error("LazyListState is locked while composing an item")
+		return false
+	}
+	state.mutex.Unlock()
+	return true
+}
+
+// lazyColumn は高さ 1 の項目を count 個並べる LazyColumn を作成します
+func lazyColumn(count int, state *LazyListState, props core.Props, item func(index int) *core.Node) *core.Node {
+	if item == nil {
+		item = func(index int) *core.Node {
+			return box(fmt.Sprintf("item%d", index), 2, 1)
+		}
+	}
+	merged := core.Props{"itemCount": count, "itemContent": item, "state": state}
+	for key, value := range props {
+		merged[key] = value
+	}
+	return core.NewNode(core.LazyColumnNodeType, "list", merged)
+}
+
+// visibleIndices は表示されている項目の番号を返します
+func visibleIndices(state *LazyListState) []int {
+	indices := []int{}
+	for _, item := range state.LayoutInfo().VisibleItems {
+		indices = append(indices, item.Index)
+	}
+	return indices
+}
+
+func TestLazyColumnScroll(t *testing.T) {
+	tests := []struct {
+		name        string
+		props       core.Props
+		scroll      []float64
+		wantFirst   int
+		wantOffset  float64
+		wantVisible []int
+		wantForward bool
+	}{
+		{"initial", nil, nil, 0, 0, []int{0, 1, 2}, true},
+		{"scroll by items", nil, []float64{2}, 2, 0, []int{2, 3, 4}, true},
+		{"partial item", nil, []float64{1.5}, 1, 0.5, []int{1, 2, 3, 4}, true},
+		{"clamped at the end", nil, []float64{100}, 7, 0, []int{7, 8, 9}, false},
+		{"clamped at the start", nil, []float64{3, -100}, 0, 0, []int{0, 1, 2}, true},
+		{"spacing", core.Props{"spacing": 1.0}, []float64{2}, 1, 0, []int{1, 2}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewLazyListState(0, 0)
+			node := lazyColumn(10, state, tt.props, nil)
+			calculate(node, Loose(Size{Width: 5, Height: 3}))
+			for _, delta := range tt.scroll {
+				state.ScrollBy(delta)
+				calculate(node, Loose(Size{Width: 5, Height: 3}))
+			}
+
+			if got := state.FirstVisibleItemIndex(); got != tt.wantFirst {
+				t.Errorf("first index = %d, want %d", got, tt.wantFirst)
+			}
+			if got := state.FirstVisibleItemScrollOffset(); got != tt.wantOffset {
+				t.Errorf("first offset = %v, want %v", got, tt.wantOffset)
+			}
+			if got := visibleIndices(state); !reflect.DeepEqual(got, tt.wantVisible) {
+				t.Errorf("visible = %v, want %v", got, tt.wantVisible)
+			}
+			if got := state.CanScrollForward(); got != tt.wantForward {
+				t.Errorf("CanScrollForward = %v, want %v", got, tt.wantForward)
+			}
+		})
+	}
+}
+
+func TestLazyColumnScrollIntoSpacing(t *testing.T) {
+	// 表示領域に項目の間隔だけが入る位置でも配置する項目がないだけで失敗しない
+	state := NewLazyListState(0, 0)
+	node := lazyColumn(3, state, core.Props{"spacing": 2.0}, func(index int) *core.Node {
+		return box(fmt.Sprintf("item%d", index), 1, 1)
+	})
+	constraints := Loose(Size{Width: 1, Height: 1})
+	calculate(node, constraints)
+
+	state.ScrollBy(1.5)
+	layout := calculate(node, constraints)
+
+	if got := visibleIndices(state); len(got) != 0 {
+		t.Errorf("visible = %v, want none", got)
+	}
+	if len(node.Children) != 0 {
+		t.Errorf("children = %d, want 0", len(node.Children))
+	}
+	if got := layout["list#0"]; got.Size.Height != 0 {
+		t.Errorf("list height = %v, want 0", got.Size.Height)
+	}
+	if !state.CanScrollBackward() {
+		t.Error("CanScrollBackward = false, want true")
+	}
+}
+
+func TestLazyColumnItemContentCanReadState(t *testing.T) {
+	state := NewLazyListState(0, 0)
+	node := lazyColumn(10, state, nil, func(index int) *core.Node {
+		// 項目の構成中に状態のゲッターを呼び出してもデッドロックしない
+		if !expectUnlocked(t, state) {
+			return box("locked", 2, 1)
+		}
+		label := fmt.Sprintf("%d/%d", index, state.FirstVisibleItemIndex())
+		_ = state.LayoutInfo()
+		return box(label, 2, 1)
+	})
+
+	state.ScrollBy(4)
+	calculate(node, Loose(Size{Width: 5, Height: 3}))
+
+	if got := state.FirstVisibleItemIndex(); got != 4 {
+		t.Errorf("first index = %d, want 4", got)
+	}
+}
+
+func TestLazyColumnScrollToItemDuringLayout(t *testing.T) {
+	state := NewLazyListState(0, 0)
+	moved := false
+	node := lazyColumn(10, state, nil, func(index int) *core.Node {
+		if !moved && expectUnlocked(t, state) {
+			moved = true
+			state.ScrollToItem(6, 0)
+		}
+		return box(fmt.Sprintf("item%d", index), 2, 1)
+	})
+
+	// レイアウト中の ScrollToItem は上書きされず、次のレイアウトで反映される
+	calculate(node, Loose(Size{Width: 5, Height: 3}))
+	if got := state.FirstVisibleItemIndex(); got != 6 {
+		t.Errorf("first index after layout = %d, want 6", got)
+	}
+	calculate(node, Loose(Size{Width: 5, Height: 3}))
+	if got := visibleIndices(state); !reflect.DeepEqual(got, []int{6, 7, 8}) {
+		t.Errorf("visible = %v, want [6 7 8]", got)
+	}
+}
+
+func TestLazyColumnKeepsFirstItemByKey(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	state := NewLazyListState(0, 0)
+	build := func() *core.Node {
+		return lazyColumn(len(keys), state, core.Props{
+			"itemKey": func(index int) string { return keys[index] },
+		}, nil)
+	}
+
+	calculate(build(), Loose(Size{Width: 5, Height: 2}))
+	state.ScrollBy(2)
+	calculate(build(), Loose(Size{Width: 5, Height: 2}))
+	if got := state.LayoutInfo().VisibleItems[0].Key; got != "c" {
+		t.Fatalf("first key = %q, want c", got)
+	}
+
+	// 前に項目を挿入しても同じ項目が先頭に表示される
+	keys = append([]string{"x", "y"}, keys...)
+	calculate(build(), Loose(Size{Width: 5, Height: 2}))
+	info := state.LayoutInfo()
+	if got := info.VisibleItems[0]; got.Key != "c" || got.Index != 4 {
+		t.Errorf("first item = %+v, want key c at index 4", got)
+	}
+}
+
+func TestLazyColumnLayout(t *testing.T) {
+	state := NewLazyListState(0, 0)
+	node := lazyColumn(10, state, core.Props{"contentPadding": 1.0, "horizontalAlignment": core.AlignEnd}, func(index int) *core.Node {
+		return box(fmt.Sprintf("item%d", index), float64(index+1), 1)
+	})
+	state.ScrollBy(1)
+	expectRects(t, calculate(node, Loose(Size{Width: 10, Height: 4})), map[core.NodeID]Rect{
+		"list#0":         rect(0, 0, 5, 4),
+		"list#0/item1#0": rect(2, 1, 2, 1),
+		"list#0/item2#1": rect(1, 2, 3, 1),
+	})
+}
+
+func TestLazyColumnItemsComposedThroughComposer(t *testing.T) {
+	manager := core.NewStateManager()
+	manager.SetState("version", 0)
+	composer := core.NewComposer(manager)
+
+	// 各項目は RememberState で値を保持し、破棄されると cleanup を記録する
+	states := map[int]*core.MutableState[int]{}
+	var cleanups []int
+	item := func(index int) *core.Node {
+		return core.NewComponentNode(fmt.Sprintf("item%d", index), core.NewFunctionComponent(func(props core.Props) *core.Node {
+			count := core.RememberState(props, 0)
+			states[index] = count
+			core.DisposableEffect(props, func() func() {
+				return func() { cleanups = append(cleanups, index) }
+			})
+			return core.NewNode(core.TextNodeType, "text", core.Props{"text": fmt.Sprint(count.Get())})
+		}), core.Props{})
+	}
+	state := NewLazyListState(0, 0)
+	list := func(props core.Props) *core.Node {
+		manager.GetState("version")
+		return lazyColumn(10, state, core.Props{"prefetch": 1}, item)
+	}
+	composer.SetContent(core.NewComponentNode("root", core.NewFunctionComponent(list), core.Props{}))
+
+	lm := NewLayoutManager()
+	lm.SetTextMeasurer(TerminalTextMeasurer{})
+	layoutList := func() *core.Node {
+		root := composer.Root()
+		lm.CalculateLayout(root, Loose(Size{Width: 5, Height: 3}))
+		return root.Children[0]
+	}
+	layoutList()
+
+	// 項目の状態の変更で項目だけが再構成される
+	states[0].Set(5)
+	if !composer.Recompose() {
+		t.Fatal("item state change did not invalidate the composition")
+	}
+	node := layoutList()
+	if got := node.Children[0].Children[0].Props.GetString("text", ""); got != "5" {
+		t.Errorf("item text = %q, want 5", got)
+	}
+
+	// リストを含むコンポーネントが再構成されても項目は引き継がれる
+	manager.SetState("version", 1)
+	composer.Recompose()
+	node = layoutList()
+	if got := node.Children[0].Children[0].Props.GetString("text", ""); got != "5" || len(cleanups) != 0 {
+		t.Errorf("item text after recomposing the list = %q with cleanups %v, want 5 and none", got, cleanups)
+	}
+
+	// prefetch の範囲にある間は状態が保たれ、範囲から外れた項目だけが破棄される
+	state.ScrollBy(1)
+	layoutList()
+	state.ScrollBy(-1)
+	layoutList()
+	if got := states[0].Get(); got != 5 {
+		t.Errorf("state after scrolling within prefetch = %d, want 5", got)
+	}
+	if !reflect.DeepEqual(cleanups, []int{4}) {
+		t.Errorf("cleanups = %v, want [4]", cleanups)
+	}
+
+	// 測定のために通り過ぎた項目も含め、範囲から外れた項目は破棄され、戻ると作り直される
+	cleanups = nil
+	state.ScrollBy(5)
+	layoutList()
+	sort.Ints(cleanups)
+	if !reflect.DeepEqual(cleanups, []int{0, 1, 2, 3}) {
+		t.Errorf("cleanups = %v, want [0 1 2 3]", cleanups)
+	}
+	state.ScrollBy(-5)
+	layoutList()
+	if got := states[0].Get(); got != 0 {
+		t.Errorf("state after scrolling back = %d, want 0", got)
+	}
+
+	// リストが破棄されると表示されていない項目も含めて破棄される
+	cleanups = nil
+	composer.Dispose()
+	sort.Ints(cleanups)
+	if !reflect.DeepEqual(cleanups, []int{0, 1, 2, 3}) {
+		t.Errorf("cleanups on Dispose = %v, want [0 1 2 3]", cleanups)
+	}
+}
+
+func TestLazyColumnDuplicateItemKeys(t *testing.T) {
+	composer := core.NewComposer(core.NewStateManager())
+
+	// 項目0と1は同じキーを持つ。各項目は Remember で生成したインスタンスの番号を保持し、
+	// 副作用の開始と後始末を記録する
+	instances := map[int]int{}
+	created := 0
+	var started, cleanups []int
+	item := func(index int) *core.Node {
+		return core.NewComponentNode("item", core.NewFunctionComponent(func(props core.Props) *core.Node {
+			instance := core.Remember(props, func() int {
+				created++
+				return created
+			})
+			instances[index] = instance
+			core.DisposableEffect(props, func() func() {
+				started = append(started, instance)
+				return func() { cleanups = append(cleanups, instance) }
+			})
+			return core.NewNode(core.TextNodeType, "text", core.Props{"text": fmt.Sprint(instance)})
+		}), core.Props{})
+	}
+	key := func(index int) string {
+		if index < 2 {
+			return "same"
+		}
+		return fmt.Sprint(index)
+	}
+	state := NewLazyListState(0, 0)
+	composer.SetContent(core.NewComponentNode("root", core.NewFunctionComponent(func(props core.Props) *core.Node {
+		return lazyColumn(10, state, core.Props{"prefetch": 0, "itemKey": key}, item)
+	}), core.Props{}))
+
+	lm := NewLayoutManager()
+	lm.SetTextMeasurer(TerminalTextMeasurer{})
+	layoutList := func() {
+		lm.CalculateLayout(composer.Root(), Loose(Size{Width: 5, Height: 3}))
+	}
+	layoutList()
+
+	// キーが重複した項目は別々のスロットで構成され、保持した値を共有しない
+	first, second := instances[0], instances[1]
+	if first == second {
+		t.Errorf("items with the same key share instance %d", first)
+	}
+
+	// 次のレイアウトでもそれぞれのスロットが引き継がれる
+	layoutList()
+	if instances[0] != first || instances[1] != second || len(cleanups) != 0 {
+		t.Errorf("instances after relayout = %d, %d with cleanups %v, want %d, %d and none",
+			instances[0], instances[1], cleanups, first, second)
+	}
+
+	// 範囲から外れると重複した項目はどちらも破棄される
+	state.ScrollBy(5)
+	layoutList()
+	if !containsInt(cleanups, first) || !containsInt(cleanups, second) {
+		t.Errorf("cleanups after scrolling away = %v, want %d and %d", cleanups, first, second)
+	}
+
+	// 破棄されると開始した副作用はすべて後始末される
+	composer.Dispose()
+	sort.Ints(started)
+	sort.Ints(cleanups)
+	if !reflect.DeepEqual(cleanups, started) {
+		t.Errorf("cleanups = %v, want every started effect %v", cleanups, started)
+	}
+}
+
+// containsInt は values に value が含まれるかを返します
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/tak/goui/core"
+)
+
+// axis は Row や Column の主軸の向きです
+type axis int
+
+const (
+	// horizontalAxis は水平方向を主軸とします（Row）
+	horizontalAxis axis = iota
+	// verticalAxis は垂直方向を主軸とします（Column）
+	verticalAxis
+)
+
+// main はサイズの主軸方向の成分を返します
+func (a axis) main(size Size) float64 {
+	if a == horizontalAxis {
+		return size.Width
+	}
+	return size.Height
+}
+
+// cross はサイズの交差軸方向の成分を返します
+func (a axis) cross(size Size) float64 {
+	if a == horizontalAxis {
+		return size.Height
+	}
+	return size.Width
+}
+
+// size は主軸と交差軸の成分からサイズを作成します
+func (a axis) size(main, cross float64) Size {
+	if a == horizontalAxis {
+		return Size{Width: main, Height: cross}
+	}
+	return Size{Width: cross, Height: main}
+}
+
+// offset は origin から主軸と交差軸の方向に移動した位置を返します
+func (a axis) offset(origin Position, main, cross float64) Position {
+	if a == horizontalAxis {
+		return Position{X: origin.X + main, Y: origin.Y + cross}
+	}
+	return Position{X: origin.X + cross, Y: origin.Y + main}
+}
+
+// constraints は主軸と交差軸の範囲から制約を作成します
+func (a axis) constraints(minMain, maxMain, minCross, maxCross float64) Constraints {
+	return Constraints{
+		MinSize: a.size(minMain, minCross),
+		MaxSize: a.size(maxMain, maxCross),
+	}
+}
+
+// measureChild は子ノードを原点に置いてレイアウトし、結果を子ノード専用のマップに記録します
+// 位置は placeChild で子ノードのサイズが揃ってから決定します
+func (lm *LayoutManager) measureChild(child *core.Node, id core.NodeID, constraints Constraints) (Size, map[core.NodeID]Rect) {
+	childLayout := make(map[core.NodeID]Rect)
+	size := lm.calculateNodeLayout(child, id, constraints, Position{}, childLayout)
+	return size, childLayout
+}
+
+// placeChild は measureChild で計算したレイアウトを position だけ移動して layout に記録します
+func placeChild(childLayout map[core.NodeID]Rect, position Position, layout map[core.NodeID]Rect) {
+	for id, rect := range childLayout {
+		rect.Position.X += position.X
+		rect.Position.Y += position.Y
+		layout[id] = rect
+	}
+}
+
+// childWeight は子ノードに設定された重みを返します
+func childWeight(child Measurable) (core.WeightModifier, bool) {
+	for _, element := range child.Props().GetModifier().Elements() {
+		if weight, ok := element.(core.WeightModifier); ok && weight.Weight > 0 {
+			return weight, true
+		}
+	}
+	return core.WeightModifier{}, false
+}
+
+// childAlignment は子ノードに設定された交差軸方向の配置を返します
+func childAlignment(child Measurable) (core.Alignment, bool) {
+	for _, element := range child.Props().GetModifier().Elements() {
+		if align, ok := element.(core.AlignModifier); ok {
+			return align.Alignment, true
+		}
+	}
+	return "", false
+}
+
+// RowPolicy は子ノードを水平方向に並べる MeasurePolicy です
+type RowPolicy struct {
+	// Arrangement は水平方向の並べ方です
+	Arrangement core.Arrangement
+	// Alignment は垂直方向の配置です（子ノードの Align で上書きできます）
+	Alignment core.Alignment
+	// Spacing は子ノードの間隔です
+	Spacing float64
+}
+
+// Measure は子ノードを水平方向に並べて測定します
+func (p RowPolicy) Measure(measurables []Measurable, constraints Constraints) MeasureResult {
+	return measureLinear(measurables, constraints, horizontalAxis, p.Arrangement, p.Alignment, p.Spacing)
+}
+
+// ColumnPolicy は子ノードを垂直方向に並べる MeasurePolicy です
+type ColumnPolicy struct {
+	// Arrangement は垂直方向の並べ方です
+	Arrangement core.Arrangement
+	// Alignment は水平方向の配置です（子ノードの Align で上書きできます）
+	Alignment core.Alignment
+	// Spacing は子ノードの間隔です
+	Spacing float64
+}
+
+// Measure は子ノードを垂直方向に並べて測定します
+func (p ColumnPolicy) Measure(measurables []Measurable, constraints Constraints) MeasureResult {
+	return measureLinear(measurables, constraints, verticalAxis, p.Arrangement, p.Alignment, p.Spacing)
+}
+
+// rowPolicyFromProps は Row のプロパティから RowPolicy を作成します
+func rowPolicyFromProps(props core.Props) MeasurePolicy {
+	return RowPolicy{
+		Arrangement: props.GetArrangement("horizontalArrangement", core.ArrangeStart),
+		Alignment:   props.GetAlignment("verticalAlignment", core.AlignStart),
+		Spacing:     props.GetFloat("spacing", 0),
+	}
+}
+
+// columnPolicyFromProps は Column のプロパティから ColumnPolicy を作成します
+func columnPolicyFromProps(props core.Props) MeasurePolicy {
+	return ColumnPolicy{
+		Arrangement: props.GetArrangement("verticalArrangement", core.ArrangeStart),
+		Alignment:   props.GetAlignment("horizontalAlignment", core.AlignStart),
+		Spacing:     props.GetFloat("spacing", 0),
+	}
+}
+
+// measureLinear は子ノードを主軸方向に1列に並べるレイアウトを計算します
+//
+// 重みのない子ノードを先に測定し、残りの空きを重み付きの子ノードに重みの比で割り当てます。
+// 重み付きの子ノードがある場合、主軸方向のサイズは最大サイズまで広がります。
+// 主軸方向に制限がない場合（スクロールコンテナの中など）は分配する空きがないため、
+// Compose と同じく重みを無視して重みのない子ノードと同じように測定します。
+// 測定後、arrangement に従って主軸方向の位置を、alignment（子ノードの Align があればそれ）に従って
+// 交差軸方向の位置を決定します。
+func measureLinear(
+	measurables []Measurable,
+	constraints Constraints,
+	axis axis,
+	arrangement core.Arrangement,
+	alignment core.Alignment,
+	spacing float64,
+) MeasureResult {
+	count := len(measurables)
+	if count == 0 {
+		return MeasureResult{Size: constraints.MinSize}
+	}
+
+	maxMain := axis.main(constraints.MaxSize)
+	maxCross := axis.cross(constraints.MaxSize)
+	placeables := make([]Placeable, count)
+	weighted := !math.IsInf(maxMain, 1)
+
+	// 重みのない子ノードを残りの空きの範囲で測定
+	used := spacing * float64(count-1)
+	totalWeight := 0.0
+	for i, child := range measurables {
+		if weight, ok := childWeight(child); ok && weighted {
+			totalWeight += weight.Weight
+			continue
+		}
+		childConstraints := axis.constraints(0, max(maxMain-used, 0), 0, maxCross)
+		placeables[i] = child.Measure(childConstraints)
+		used += axis.main(placeables[i].Size())
+	}
+
+	// 残りの空きを重みに応じて分配
+	mainSize := used
+	if totalWeight > 0 {
+		remaining := max(maxMain-used, 0)
+		for i, child := range measurables {
+			weight, ok := childWeight(child)
+			if !ok {
+				continue
+			}
+			share := remaining * weight.Weight / totalWeight
+			minMain := 0.0
+			if weight.Fill {
+				minMain = share
+			}
+			placeables[i] = child.Measure(axis.constraints(minMain, share, 0, maxCross))
+			mainSize += axis.main(placeables[i].Size())
+		}
+		mainSize = max(mainSize, maxMain)
+	}
+	mainSize = max(mainSize, axis.main(constraints.MinSize))
+
+	crossSize := axis.cross(constraints.MinSize)
+	mainSizes := make([]float64, count)
+	for i, placeable := range placeables {
+		mainSizes[i] = axis.main(placeable.Size())
+		crossSize = max(crossSize, axis.cross(placeable.Size()))
+	}
+
+	return MeasureResult{
+		Size: axis.size(mainSize, crossSize),
+		Place: func() {
+			// 主軸方向は arrangement、交差軸方向は alignment に従って配置
+			mainPositions := arrangement.Arrange(mainSizes, mainSize, spacing)
+			for i, child := range measurables {
+				childAlign := alignment
+				if align, ok := childAlignment(child); ok {
+					childAlign = align
+				}
+				crossPosition := childAlign.Offset(crossSize - axis.cross(placeables[i].Size()))
+				position := axis.offset(Position{}, mainPositions[i], crossPosition)
+				placeables[i].Place(position.X, position.Y)
+			}
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package layout
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+func TestRowLayout(t *testing.T) {
+	tests := []struct {
+		name        string
+		props       core.Props
+		children    []*core.Node
+		constraints Constraints
+		want        map[core.NodeID]Rect
+	}{
+		{
+			name:        "start arrangement with spacing",
+			props:       core.Props{"spacing": 1.0},
+			children:    []*core.Node{box("a", 2, 1), box("b", 3, 2)},
+			constraints: Loose(Size{Width: 20, Height: 5}),
+			want: map[core.NodeID]Rect{
+				"row#0":     rect(0, 0, 6, 2),
+				"row#0/a#0": rect(0, 0, 2, 1),
+				"row#0/b#1": rect(3, 0, 3, 2),
+			},
+		},
+		{
+			name:        "cross axis alignment",
+			props:       core.Props{"verticalAlignment": core.AlignCenter},
+			children:    []*core.Node{box("a", 2, 1), box("b", 2, 3)},
+			constraints: Loose(Size{Width: 20, Height: 5}),
+			want: map[core.NodeID]Rect{
+				"row#0/a#0": rect(0, 1, 2, 1),
+				"row#0/b#1": rect(2, 0, 2, 3),
+			},
+		},
+		{
+			name: "weights share remaining space",
+			children: []*core.Node{
+				box("a", 2, 1),
+				modified(box("b", 0, 1), core.NewModifier().Weight(1)),
+				modified(box("c", 0, 1), core.NewModifier().Weight(3)),
+			},
+			constraints: Loose(Size{Width: 10, Height: 5}),
+			want: map[core.NodeID]Rect{
+				"row#0":     rect(0, 0, 10, 1),
+				"row#0/b#1": rect(2, 0, 2, 1),
+				"row#0/c#2": rect(4, 0, 6, 1),
+			},
+		},
+		{
+			name: "loose weight does not fill",
+			children: []*core.Node{
+				modified(box("a", 1, 1), core.NewModifier().LooseWeight(1)),
+				box("b", 2, 1),
+			},
+			constraints: Loose(Size{Width: 10, Height: 5}),
+			want: map[core.NodeID]Rect{
+				"row#0":     rect(0, 0, 10, 1),
+				"row#0/a#0": rect(0, 0, 1, 1),
+				"row#0/b#1": rect(1, 0, 2, 1),
+			},
+		},
+		{
+			name: "weights are ignored when the main axis is unbounded",
+			children: []*core.Node{
+				box("a", 2, 1),
+				modified(box("b", 3, 1), core.NewModifier().Weight(1)),
+			},
+			constraints: Loose(Size{Width: Unbounded, Height: 5}),
+			want: map[core.NodeID]Rect{
+				"row#0":     rect(0, 0, 5, 1),
+				"row#0/a#0": rect(0, 0, 2, 1),
+				"row#0/b#1": rect(2, 0, 3, 1),
+			},
+		},
+		{
+			name:        "space between",
+			props:       core.Props{"horizontalArrangement": core.ArrangeSpaceBetween},
+			children:    []*core.Node{box("a", 2, 1), box("b", 2, 1), box("c", 2, 1)},
+			constraints: FixedSize(10, 1),
+			want: map[core.NodeID]Rect{
+				"row#0/a#0": rect(0, 0, 2, 1),
+				"row#0/b#1": rect(4, 0, 2, 1),
+				"row#0/c#2": rect(8, 0, 2, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := container(core.RowNodeType, "row", tt.props, tt.children...)
+			expectRects(t, calculate(root, tt.constraints), tt.want)
+		})
+	}
+}
+
+func TestColumnWeightInsideVerticalScroll(t *testing.T) {
+	column := container(core.ColumnNodeType, "column", nil,
+		box("header", 4, 2),
+		modified(box("body", 4, 3), core.NewModifier().Weight(1)),
+	)
+	root := container(core.VerticalScrollNodeType, "scroll", nil, column)
+	layout := calculate(root, Loose(Size{Width: 10, Height: 4}))
+
+	for id, r := range layout {
+		if math.IsInf(r.Size.Width, 0) || math.IsInf(r.Size.Height, 0) {
+			t.Errorf("%s: infinite size %v", id, r.Size)
+		}
+	}
+	expectRects(t, layout, map[core.NodeID]Rect{
+		"scroll#0":                 rect(0, 0, 4, 4),
+		"scroll#0/column#0":        rect(0, 0, 4, 5),
+		"scroll#0/column#0/body#1": rect(0, 2, 4, 3),
+	})
+}
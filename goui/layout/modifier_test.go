@@ -0,0 +1,88 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+func TestModifierChainLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		modifier core.Modifier
+		want     map[core.NodeID]Rect
+	}{
+		{
+			name:     "padding outside size",
+			modifier: core.NewModifier().Padding(1).Size(4, 3),
+			want: map[core.NodeID]Rect{
+				"column#0/target#0":         rect(0, 0, 6, 5),
+				"column#0/target#0/inner#0": rect(1, 1, 2, 1),
+			},
+		},
+		{
+			name:     "size outside padding",
+			modifier: core.NewModifier().Size(4, 3).Padding(1),
+			want: map[core.NodeID]Rect{
+				"column#0/target#0":         rect(0, 0, 4, 3),
+				"column#0/target#0/inner#0": rect(1, 1, 2, 1),
+			},
+		},
+		{
+			name:     "outer size wins over inner size",
+			modifier: core.NewModifier().Size(6, 4).Size(3, 2),
+			want: map[core.NodeID]Rect{
+				"column#0/target#0":         rect(0, 0, 6, 4),
+				"column#0/target#0/inner#0": rect(0, 0, 2, 1),
+			},
+		},
+		{
+			name:     "stacked paddings add up",
+			modifier: core.NewModifier().Padding(1).PaddingValues(2, 0, 0, 1),
+			want: map[core.NodeID]Rect{
+				"column#0/target#0":         rect(0, 0, 6, 4),
+				"column#0/target#0/inner#0": rect(3, 1, 2, 1),
+			},
+		},
+		{
+			name:     "padding and size inside an outer padding",
+			modifier: core.NewModifier().Padding(1).Size(4, 3).Padding(1),
+			want: map[core.NodeID]Rect{
+				"column#0/target#0":         rect(0, 0, 6, 5),
+				"column#0/target#0/inner#0": rect(2, 2, 2, 1),
+			},
+		},
+		{
+			name:     "padding before background",
+			modifier: core.NewModifier().Padding(1).Background("#FFF"),
+			want: map[core.NodeID]Rect{
+				"column#0/target#0":         rect(0, 0, 4, 3),
+				"column#0/target#0/inner#0": rect(1, 1, 2, 1),
+			},
+		},
+		{
+			name:     "background before padding",
+			modifier: core.NewModifier().Background("#FFF").Padding(1),
+			want: map[core.NodeID]Rect{
+				"column#0/target#0":         rect(0, 0, 4, 3),
+				"column#0/target#0/inner#0": rect(1, 1, 2, 1),
+			},
+		},
+		{
+			name:     "clickable before padding",
+			modifier: core.NewModifier().Clickable(func() {}).Padding(1),
+			want: map[core.NodeID]Rect{
+				"column#0/target#0":         rect(0, 0, 4, 3),
+				"column#0/target#0/inner#0": rect(1, 1, 2, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := modified(container(core.BoxNodeType, "target", nil, box("inner", 2, 1)), tt.modifier)
+			root := container(core.ColumnNodeType, "column", nil, target)
+			expectRects(t, calculate(root, Loose(Size{Width: 20, Height: 20})), tt.want)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package layout
+
+import (
+	"github.com/tak/goui/core"
+)
+
+// MeasurePolicyKey は LayoutNodeType のノードの Props に MeasurePolicy を格納するキーです
+const MeasurePolicyKey = "measurePolicy"
+
+// Measurable は測定前の子ノードです
+type Measurable interface {
+	// Measure は制約の範囲で子ノードを測定し、配置できる状態にします
+	Measure(constraints Constraints) Placeable
+
+	// Props は子ノードのプロパティを返します
+	// 子ノードのモディファイア（Weight や Align など）を親のレイアウトで参照するために使用します
+	Props() core.Props
+
+	// IntrinsicSize は測定を行わずに子ノードの固有サイズを返します
+	// other は幅の問い合わせでは高さ、高さの問い合わせでは幅です（Unbounded で制限なし）
+	IntrinsicSize(query IntrinsicQuery, other float64) float64
+}
+
+// Placeable は測定済みで、親の中に配置できる子ノードです
+type Placeable interface {
+	// Size は測定されたサイズを返します
+	Size() Size
+
+	// Place は親の左上を原点とする位置に子ノードを配置します
+	// 配置されなかった子ノードはレイアウト結果に含まれず、描画されません
+	Place(x, y float64)
+}
+
+// MeasureResult は MeasurePolicy の測定結果です
+type MeasureResult struct {
+	// Size はレイアウトのサイズです（制約に収まるように調整されます）
+	Size Size
+
+	// Place は子ノードを配置する処理です
+	// すべての子ノードの測定とサイズの決定が終わった後に呼び出されます
+	Place func()
+}
+
+// MeasurePolicy は子ノードを測定して配置する方法を定義します
+//
+// Measure では各子ノードを Measurable.Measure で測定して自身のサイズを決め、
+// 返した MeasureResult.Place の中で Placeable.Place を呼び出して子ノードの位置を決めます。
+// コンポーネントが MeasurePolicy を実装した場合は、Render が返したノードの子ノードを
+// そのノード自身のレイアウトの代わりにこの方法で配置します。
+type MeasurePolicy interface {
+	Measure(measurables []Measurable, constraints Constraints) MeasureResult
+}
+
+// MeasurePolicyFunc は関数を MeasurePolicy として使用するためのアダプタです
+type MeasurePolicyFunc func(measurables []Measurable, constraints Constraints) MeasureResult
+
+// Measure は関数を呼び出します
+func (f MeasurePolicyFunc) Measure(measurables []Measurable, constraints Constraints) MeasureResult {
+	return f(measurables, constraints)
+}
+
+// MeasurePolicyFactory はノードのプロパティから MeasurePolicy を作成します
+type MeasurePolicyFactory func(props core.Props) MeasurePolicy
+
+// measurable は LayoutManager が子ノードを測定するための Measurable の実装です
+type measurable struct {
+	manager    *LayoutManager
+	node       *core.Node
+	id         core.NodeID
+	placeables []*placeable
+}
+
+// Measure は子ノードを原点に置いてレイアウトします
+func (m *measurable) Measure(constraints Constraints) Placeable {
+	size, childLayout := m.manager.measureChild(m.node, m.id, constraints)
+	p := &placeable{size: size, layout: childLayout}
+	m.placeables = append(m.placeables, p)
+	return p
+}
+
+// Props は子ノードのプロパティを返します
+func (m *measurable) Props() core.Props {
+	return m.node.Props
+}
+
+// placeable は測定済みの子ノードのレイアウトと配置位置です
+type placeable struct {
+	size     Size
+	layout   map[core.NodeID]Rect
+	position Position
+	placed   bool
+}
+
+// Size は測定されたサイズを返します
+func (p *placeable) Size() Size {
+	return p.size
+}
+
+// Place は配置位置を記録します
+func (p *placeable) Place(x, y float64) {
+	p.position = Position{X: x, Y: y}
+	p.placed = true
+}
+
+// RegisterMeasurePolicy はノードタイプに対する MeasurePolicy を登録します
+// 組み込みの Row、Column、Box、Container の配置方法を置き換えることもできます
+func (lm *LayoutManager) RegisterMeasurePolicy(nodeType core.NodeType, factory MeasurePolicyFactory) {
+	lm.policies[nodeType] = factory
+}
+
+// measurePolicyFor はノードに適用する MeasurePolicy を返します
+func (lm *LayoutManager) measurePolicyFor(node *core.Node) MeasurePolicy {
+	if factory, exists := lm.policies[node.Type]; exists {
+		return factory(node.Props)
+	}
+	return nil
+}
+
+// measureWithPolicy は MeasurePolicy で子ノードを測定・配置し、結果を layout に記録します
+// 子ノードの位置は position を原点として記録されます
+func (lm *LayoutManager) measureWithPolicy(
+	policy MeasurePolicy,
+	node *core.Node,
+	id core.NodeID,
+	constraints Constraints,
+	position Position,
+	layout map[core.NodeID]Rect,
+) Size {
+	measurables := make([]*measurable, len(node.Children))
+	arguments := make([]Measurable, len(node.Children))
+	for i, child := range node.Children {
+		measurables[i] = &measurable{manager: lm, node: child, id: core.ChildNodeID(id, child.Key, i)}
+		arguments[i] = measurables[i]
+	}
+
+	// 測定してサイズを決定してから配置する
+	result := policy.Measure(arguments, constraints)
+	size := constraints.Constrain(result.Size)
+	if result.Place != nil {
+		result.Place()
+	}
+
+	for _, m := range measurables {
+		for _, p := range m.placeables {
+			if p.placed {
+				placeChild(p.layout, Position{X: position.X + p.position.X, Y: position.Y + p.position.Y}, layout)
+			}
+		}
+	}
+	return size
+}
+
+// layoutNodePolicy は LayoutNodeType のノードの Props に格納された MeasurePolicy を返します
+func layoutNodePolicy(props core.Props) MeasurePolicy {
+	if policy, ok := props[MeasurePolicyKey].(MeasurePolicy); ok {
+		return policy
+	}
+	return nil
+}
+
+// ContainerPolicy は最初の子ノードだけを左上に配置し、そのサイズに合わせる MeasurePolicy です
+type ContainerPolicy struct{}
+
+// Measure は最初の子ノードを測定して配置します
+func (ContainerPolicy) Measure(measurables []Measurable, constraints Constraints) MeasureResult {
+	if len(measurables) == 0 {
+		return MeasureResult{}
+	}
+	child := measurables[0].Measure(constraints)
+	return MeasureResult{
+		Size: child.Size(),
+		Place: func() {
+			child.Place(0, 0)
+		},
+	}
+}
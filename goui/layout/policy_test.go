@@ -0,0 +1,99 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+// diagonalPolicy は子ノードを右下方向に斜めに並べ、奇数番目の子ノードを配置しない MeasurePolicy です
+var diagonalPolicy = MeasurePolicyFunc(func(measurables []Measurable, constraints Constraints) MeasureResult {
+	placeables := make([]Placeable, len(measurables))
+	size := Size{}
+	for i, child := range measurables {
+		placeables[i] = child.Measure(Loose(constraints.MaxSize))
+		size.Width += placeables[i].Size().Width
+		size.Height += placeables[i].Size().Height
+	}
+	return MeasureResult{
+		Size: size,
+		Place: func() {
+			x, y := 0.0, 0.0
+			for i, p := range placeables {
+				if i%2 == 0 {
+					p.Place(x, y)
+				}
+				x += p.Size().Width
+				y += p.Size().Height
+			}
+		},
+	}
+})
+
+func TestMeasurePolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints Constraints
+		want        map[core.NodeID]Rect
+		missing     []core.NodeID
+	}{
+		{
+			name:        "places children where the policy says",
+			constraints: Loose(Size{Width: 20, Height: 20}),
+			want: map[core.NodeID]Rect{
+				"layout#0":     rect(0, 0, 6, 6),
+				"layout#0/a#0": rect(0, 0, 1, 1),
+				"layout#0/c#2": rect(3, 3, 3, 3),
+			},
+			missing: []core.NodeID{"layout#0/b#1"},
+		},
+		{
+			name:        "size is constrained",
+			constraints: Loose(Size{Width: 4, Height: 20}),
+			want: map[core.NodeID]Rect{
+				"layout#0":     rect(0, 0, 4, 6),
+				"layout#0/c#2": rect(3, 3, 3, 3),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := container(core.LayoutNodeType, "layout", core.Props{MeasurePolicyKey: diagonalPolicy},
+				box("a", 1, 1), box("b", 2, 2), box("c", 3, 3))
+			layout := calculate(root, tt.constraints)
+			expectRects(t, layout, tt.want)
+			for _, id := range tt.missing {
+				if _, ok := layout[id]; ok {
+					t.Errorf("%s: unplaced child has a layout", id)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterMeasurePolicyReplacesBuiltin(t *testing.T) {
+	lm := NewLayoutManager()
+	lm.SetTextMeasurer(TerminalTextMeasurer{})
+	lm.RegisterMeasurePolicy(core.RowNodeType, func(core.Props) MeasurePolicy {
+		return diagonalPolicy
+	})
+
+	root := container(core.RowNodeType, "row", nil, box("a", 1, 1), box("b", 1, 1), box("c", 1, 1))
+	expectRects(t, lm.CalculateLayout(root, Loose(Size{Width: 20, Height: 20})), map[core.NodeID]Rect{
+		"row#0":     rect(0, 0, 3, 3),
+		"row#0/c#2": rect(2, 2, 1, 1),
+	})
+}
+
+func TestContainerPolicyUsesFirstChild(t *testing.T) {
+	root := container(core.ContainerNodeType, "container", nil, box("a", 3, 2), box("b", 5, 5))
+	layout := calculate(root, Loose(Size{Width: 20, Height: 20}))
+	expectRects(t, layout, map[core.NodeID]Rect{
+		"container#0":     rect(0, 0, 3, 2),
+		"container#0/a#0": rect(0, 0, 3, 2),
+	})
+	if _, ok := layout["container#0/b#1"]; ok {
+		t.Error("second child has a layout")
+	}
+}
@@ -0,0 +1,215 @@
+package layout
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tak/goui/core"
+)
+
+// scrollFrameInterval は AnimateScrollTo がスクロール位置を更新する間隔です
+const scrollFrameInterval = 16 * time.Millisecond
+
+// ScrollState はスクロールコンテナのスクロール位置を保持します
+//
+// 最大のスクロール量と表示領域のサイズはレイアウトのたびに更新され、
+// スクロール位置は 0 から最大のスクロール量の範囲に切り詰められます。
+// 位置が変わると OnScroll で登録した関数が呼ばれるため、そこで再レンダリングを要求します。
+type ScrollState struct {
+	mutex      sync.Mutex
+	offset     float64
+	maxOffset  float64
+	viewport   float64
+	measured   bool
+	generation int
+	listeners  []func()
+}
+
+// NewScrollState は offset の位置から表示するスクロール状態を作成します
+func NewScrollState(offset float64) *ScrollState {
+	return &ScrollState{offset: max(offset, 0)}
+}
+
+// Offset は現在のスクロール位置を返します
+func (s *ScrollState) Offset() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.offset
+}
+
+// MaxOffset は直前のレイアウトでの最大のスクロール量を返します（レイアウト前は +Inf）
+func (s *ScrollState) MaxOffset() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.measured {
+		return math.Inf(1)
+	}
+	return s.maxOffset
+}
+
+// ViewportSize は直前のレイアウトでの表示領域の主軸方向のサイズを返します
+func (s *ScrollState) ViewportSize() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.viewport
+}
+
+// OnScroll はスクロール位置が変わったときに呼ばれる関数を登録します
+func (s *ScrollState) OnScroll(listener func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// ScrollTo はスクロール位置を value にします（実行中のアニメーションは中断されます）
+func (s *ScrollState) ScrollTo(value float64) {
+	s.mutex.Lock()
+	s.generation++
+	changed := s.setOffsetLocked(value)
+	s.mutex.Unlock()
+
+	if changed {
+		s.notify()
+	}
+}
+
+// ScrollBy はスクロール位置を delta だけ移動し、実際に移動した量を返します
+func (s *ScrollState) ScrollBy(delta float64) float64 {
+	s.mutex.Lock()
+	s.generation++
+	before := s.offset
+	changed := s.setOffsetLocked(s.offset + delta)
+	consumed := s.offset - before
+	s.mutex.Unlock()
+
+	if changed {
+		s.notify()
+	}
+	return consumed
+}
+
+// AnimateScrollTo は duration の間に value の位置まで減速しながらスクロールします
+//
+// アニメーションが終わるまでブロックします。コンテキストが終了した場合はその時点の位置で止まり、
+// コンテキストのエラーを返します。ScrollTo や別のアニメーションで中断された場合は nil を返します。
+func (s *ScrollState) AnimateScrollTo(ctx context.Context, value float64, duration time.Duration) error {
+	s.mutex.Lock()
+	s.generation++
+	generation := s.generation
+	start := s.offset
+	s.mutex.Unlock()
+
+	if duration <= 0 {
+		s.ScrollTo(value)
+		return nil
+	}
+
+	ticker := time.NewTicker(scrollFrameInterval)
+	defer ticker.Stop()
+	began := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			progress := min(float64(now.Sub(began))/float64(duration), 1)
+			eased := 1 - math.Pow(1-progress, 3)
+
+			s.mutex.Lock()
+			if s.generation != generation {
+				s.mutex.Unlock()
+				return nil
+			}
+			changed := s.setOffsetLocked(start + (value-start)*eased)
+			s.mutex.Unlock()
+
+			if changed {
+				s.notify()
+			}
+			if progress >= 1 {
+				return nil
+			}
+		}
+	}
+}
+
+// setOffsetLocked はスクロール位置を範囲に切り詰めて設定し、変化したかを返します
+// レイアウト前は最大のスクロール量がわからないため、負の値だけを切り詰めます
+func (s *ScrollState) setOffsetLocked(value float64) bool {
+	value = max(value, 0)
+	if s.measured {
+		value = min(value, s.maxOffset)
+	}
+	if value == s.offset {
+		return false
+	}
+	s.offset = value
+	return true
+}
+
+// notify は登録された関数を呼び出します
+func (s *ScrollState) notify() {
+	s.mutex.Lock()
+	listeners := append([]func(){}, s.listeners...)
+	s.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// update はレイアウトの結果を記録し、切り詰めたスクロール位置を返します
+func (s *ScrollState) update(viewport, maxOffset float64) float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.viewport = viewport
+	s.maxOffset = maxOffset
+	s.measured = true
+	s.offset = min(max(s.offset, 0), maxOffset)
+	return s.offset
+}
+
+// scrollContentPolicy はスクロールコンテナの子ノードを並べる MeasurePolicy を返します
+// VerticalScroll は Column、HorizontalScroll は Row と同じように子ノードを並べます
+func scrollContentPolicy(node *core.Node) (MeasurePolicy, axis) {
+	if node.Type == core.HorizontalScrollNodeType {
+		return rowPolicyFromProps(node.Props), horizontalAxis
+	}
+	return columnPolicyFromProps(node.Props), verticalAxis
+}
+
+// calculateScrollLayout はスクロールコンテナのレイアウトを計算します
+//
+// 子ノードは主軸方向に制約なしで並べられ、コンテナは制約に収まるサイズになります。
+// 子ノードはスクロール位置の分だけ主軸の逆方向にずらして配置され、
+// 表示領域の外に出た部分はレンダラーで切り取られます。
+func (lm *LayoutManager) calculateScrollLayout(
+	node *core.Node,
+	id core.NodeID,
+	constraints Constraints,
+	position Position,
+	layout map[core.NodeID]Rect,
+) Size {
+	policy, axis := scrollContentPolicy(node)
+	contentConstraints := axis.constraints(
+		axis.main(constraints.MinSize), Unbounded,
+		axis.cross(constraints.MinSize), axis.cross(constraints.MaxSize),
+	)
+
+	// 位置はスクロール量が決まってから移動する
+	contentLayout := make(map[core.NodeID]Rect)
+	contentSize := lm.measureWithPolicy(policy, node, id, contentConstraints, Position{}, contentLayout)
+	size := constraints.Constrain(contentSize)
+
+	state, _ := node.Props["state"].(*ScrollState)
+	if state == nil {
+		state = NewScrollState(0)
+	}
+	viewport := axis.main(size)
+	offset := state.update(viewport, max(axis.main(contentSize)-viewport, 0))
+
+	placeChild(contentLayout, axis.offset(position, -offset, 0), layout)
+	return size
+}
@@ -0,0 +1,182 @@
+package layout
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tak/goui/core"
+)
+
+// scrollColumn は高さ 1 の Box を count 個並べた VerticalScroll を作成します
+func scrollColumn(count int, state *ScrollState) *core.Node {
+	children := make([]*core.Node, count)
+	for i := range children {
+		children[i] = box(fmt.Sprintf("item%d", i), 2, 1)
+	}
+	return container(core.VerticalScrollNodeType, "scroll", core.Props{"state": state}, children...)
+}
+
+func TestScrollStateScrollBy(t *testing.T) {
+	tests := []struct {
+		name         string
+		measure      bool
+		initial      float64
+		delta        float64
+		wantOffset   float64
+		wantConsumed float64
+	}{
+		{"before layout only clamps at zero", false, 0, 100, 100, 100},
+		{"negative before layout", false, 2, -5, 0, -2},
+		{"within range", true, 0, 3, 3, 3},
+		{"clamped to max offset", true, 4, 10, 7, 3},
+		{"clamped to zero", true, 4, -10, 0, -4},
+		{"at the end consumes nothing", true, 7, 1, 7, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewScrollState(tt.initial)
+			if tt.measure {
+				// 10 個の項目を高さ 3 の表示領域に並べると最大のスクロール量は 7 になる
+				calculate(scrollColumn(10, state), Loose(Size{Width: 5, Height: 3}))
+			}
+			if got := state.ScrollBy(tt.delta); got != tt.wantConsumed {
+				t.Errorf("consumed = %v, want %v", got, tt.wantConsumed)
+			}
+			if got := state.Offset(); got != tt.wantOffset {
+				t.Errorf("offset = %v, want %v", got, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestScrollStateNotifiesOnlyOnChange(t *testing.T) {
+	state := NewScrollState(0)
+	calculate(scrollColumn(10, state), Loose(Size{Width: 5, Height: 3}))
+
+	calls := 0
+	state.OnScroll(func() { calls++ })
+	state.ScrollTo(2)
+	state.ScrollTo(2)
+	state.ScrollBy(-5)
+	state.ScrollBy(-1)
+	if calls != 2 {
+		t.Errorf("listener called %d times, want 2", calls)
+	}
+}
+
+func TestScrollLayout(t *testing.T) {
+	tests := []struct {
+		name       string
+		count      int
+		offset     float64
+		wantOffset float64
+		want       map[core.NodeID]Rect
+	}{
+		{
+			name:       "content is shifted by the offset",
+			count:      10,
+			offset:     2,
+			wantOffset: 2,
+			want: map[core.NodeID]Rect{
+				"scroll#0":         rect(0, 0, 2, 3),
+				"scroll#0/item0#0": rect(0, -2, 2, 1),
+				"scroll#0/item2#2": rect(0, 0, 2, 1),
+			},
+		},
+		{
+			name:       "offset is clamped when the content shrinks",
+			count:      4,
+			offset:     5,
+			wantOffset: 1,
+			want: map[core.NodeID]Rect{
+				"scroll#0/item1#1": rect(0, 0, 2, 1),
+			},
+		},
+		{
+			name:       "content that fits does not scroll",
+			count:      2,
+			offset:     1,
+			wantOffset: 0,
+			want: map[core.NodeID]Rect{
+				"scroll#0":         rect(0, 0, 2, 2),
+				"scroll#0/item0#0": rect(0, 0, 2, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewScrollState(tt.offset)
+			layout := calculate(scrollColumn(tt.count, state), Loose(Size{Width: 5, Height: 3}))
+			expectRects(t, layout, tt.want)
+			if got := state.Offset(); got != tt.wantOffset {
+				t.Errorf("offset = %v, want %v", got, tt.wantOffset)
+			}
+			if got := state.ViewportSize(); got != min(float64(tt.count), 3) {
+				t.Errorf("viewport = %v, want %v", got, min(float64(tt.count), 3))
+			}
+		})
+	}
+}
+
+func TestHorizontalScrollLayout(t *testing.T) {
+	state := NewScrollState(3)
+	root := container(core.HorizontalScrollNodeType, "scroll", core.Props{"state": state, "spacing": 1.0},
+		box("a", 4, 1), box("b", 4, 1), box("c", 4, 1))
+	expectRects(t, calculate(root, Loose(Size{Width: 6, Height: 2})), map[core.NodeID]Rect{
+		"scroll#0":     rect(0, 0, 6, 1),
+		"scroll#0/a#0": rect(-3, 0, 4, 1),
+		"scroll#0/b#1": rect(2, 0, 4, 1),
+	})
+	if got := state.MaxOffset(); got != 8 {
+		t.Errorf("max offset = %v, want 8", got)
+	}
+}
+
+func TestScrollStateAnimateScrollTo(t *testing.T) {
+	state := NewScrollState(0)
+	calculate(scrollColumn(10, state), Loose(Size{Width: 5, Height: 3}))
+
+	if err := state.AnimateScrollTo(context.Background(), 5, 50*time.Millisecond); err != nil {
+		t.Fatalf("AnimateScrollTo: %v", err)
+	}
+	if got := state.Offset(); got != 5 {
+		t.Errorf("offset = %v, want 5", got)
+	}
+
+	// コンテキストが終了した場合はエラーを返して止まる
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := state.AnimateScrollTo(ctx, 0, time.Second); err != context.Canceled {
+		t.Errorf("AnimateScrollTo(cancelled) = %v, want %v", err, context.Canceled)
+	}
+
+	// ScrollTo で中断された場合は nil を返す
+	moved := make(chan struct{}, 1)
+	state.OnScroll(func() {
+		select {
+		case moved <- struct{}{}:
+		default:
+		}
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- state.AnimateScrollTo(context.Background(), 0, time.Second)
+	}()
+	<-moved
+	state.ScrollTo(7)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("interrupted AnimateScrollTo = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("animation was not interrupted")
+	}
+	if got := state.Offset(); got != 7 {
+		t.Errorf("offset = %v, want 7", got)
+	}
+}
@@ -0,0 +1,36 @@
+package layout
+
+import (
+	"github.com/tak/goui/core"
+)
+
+// TextMeasurer はテキストの表示サイズを計測するインターフェースです
+type TextMeasurer interface {
+	// MeasureText は1行のテキストを描画したときのサイズを返します
+	MeasureText(text string, props core.Props) Size
+}
+
+// FontTextMeasurer はフォントサイズに基づいてテキストのサイズを近似的に計測します
+// 全角文字は半角文字の2倍の幅として扱います
+type FontTextMeasurer struct{}
+
+// MeasureText は表示幅（セル数）とフォントサイズからテキストのサイズを計算します
+func (FontTextMeasurer) MeasureText(text string, props core.Props) Size {
+	fontSize := props.GetFloat("fontSize", 16.0)
+	return Size{
+		Width:  float64(StringWidth(text)) * fontSize * 0.6,
+		Height: fontSize * 1.2,
+	}
+}
+
+// TerminalTextMeasurer は端末のセルを単位としてテキストのサイズを計測します
+// 書記素クラスタごとに East Asian Width に基づく幅（1 または 2 セル）を合計し、高さは1行です
+type TerminalTextMeasurer struct{}
+
+// MeasureText はテキストが端末で占めるセル数を返します
+func (TerminalTextMeasurer) MeasureText(text string, props core.Props) Size {
+	return Size{
+		Width:  float64(StringWidth(text)),
+		Height: 1,
+	}
+}
@@ -0,0 +1,211 @@
+package layout
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// runeRange は Unicode のコードポイントの範囲です
+type runeRange struct {
+	first, last rune
+}
+
+// wideRanges は East Asian Width が Wide または Fullwidth の主な範囲です
+var wideRanges = []runeRange{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F202}, {0x1F210, 0x1F23B},
+	{0x1F240, 0x1F248}, {0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA},
+	{0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E},
+	{0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4},
+	{0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC}, {0x1F7E0, 0x1F7EB},
+	{0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945}, {0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+const (
+	zeroWidthJoiner   = '\u200D'
+	emojiPresentation = '\uFE0F'
+	regionalFirst     = 0x1F1E6
+	regionalLast      = 0x1F1FF
+	emojiModFirst     = 0x1F3FB
+	emojiModLast      = 0x1F3FF
+	hangulSyllFirst   = 0xAC00
+	hangulSyllLast    = 0xD7A3
+	hangulLeadFirst   = 0x1100
+	hangulTrailLast   = 0x11FF
+	hangulVowelFirst  = 0x1160
+)
+
+// inRanges はコードポイントが範囲のいずれかに含まれるかを二分探索で判定します
+func inRanges(r rune, ranges []runeRange) bool {
+	lo, hi := 0, len(ranges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < ranges[mid].first:
+			hi = mid
+		case r > ranges[mid].last:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// RuneWidth は1つのコードポイントが端末で占めるセル数を返します
+// 制御文字と結合文字は 0、East Asian Wide / Fullwidth の文字は 2 になります
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.IsControl(r):
+		return 0
+	case isExtender(r):
+		return 0
+	case inRanges(r, wideRanges):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// isExtender は直前の書記素クラスタに結合するコードポイントかを判定します
+func isExtender(r rune) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r == zeroWidthJoiner:
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+		// 異体字セレクタ
+		return true
+	case r >= emojiModFirst && r <= emojiModLast:
+		// 絵文字の肌の色の修飾子
+		return true
+	case r >= 0xE0020 && r <= 0xE007F:
+		// タグ文字（旗の絵文字のシーケンス）
+		return true
+	default:
+		return false
+	}
+}
+
+// isRegionalIndicator は国旗を構成する地域指示記号かを判定します
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalFirst && r <= regionalLast
+}
+
+// isHangulJamoContinuation はハングルの中声・終声の字母かを判定します
+func isHangulJamoContinuation(r rune) bool {
+	return r >= hangulVowelFirst && r <= hangulTrailLast
+}
+
+// isHangul はハングルの音節または字母かを判定します
+func isHangul(r rune) bool {
+	return (r >= hangulSyllFirst && r <= hangulSyllLast) || (r >= hangulLeadFirst && r <= hangulTrailLast)
+}
+
+// Graphemes は文字列を書記素クラスタ（利用者が1文字と認識する単位）に分割します
+//
+// 結合文字、異体字セレクタ、ZWJ による絵文字の連結、国旗の地域指示記号の組、
+// ハングルの字母の組み合わせ、CR LF を1つのクラスタとして扱う簡易的な実装です。
+func Graphemes(text string) []string {
+	clusters := []string{}
+	start := 0
+	var previous rune = -1
+	regionalCount := 0
+
+	for i, r := range text {
+		if previous >= 0 && !breaksBetween(previous, r, regionalCount) {
+			previous = r
+			if isRegionalIndicator(r) {
+				regionalCount++
+			}
+			continue
+		}
+
+		if i > start {
+			clusters = append(clusters, text[start:i])
+		}
+		start = i
+		previous = r
+		regionalCount = 0
+		if isRegionalIndicator(r) {
+			regionalCount = 1
+		}
+	}
+
+	if start < len(text) {
+		clusters = append(clusters, text[start:])
+	}
+	return clusters
+}
+
+// breaksBetween は2つのコードポイントの間が書記素クラスタの境界かを判定します
+func breaksBetween(previous, current rune, regionalCount int) bool {
+	switch {
+	case previous == '\r' && current == '\n':
+		return false
+	case previous == '\r' || previous == '\n' || current == '\r' || current == '\n':
+		return true
+	case isExtender(current):
+		return false
+	case previous == zeroWidthJoiner:
+		return false
+	case isRegionalIndicator(previous) && isRegionalIndicator(current):
+		// 地域指示記号は2つずつ組にする
+		return regionalCount%2 == 0
+	case isHangul(previous) && isHangulJamoContinuation(current):
+		return false
+	default:
+		return true
+	}
+}
+
+// GraphemeWidth は書記素クラスタが端末で占めるセル数を返します
+func GraphemeWidth(cluster string) int {
+	first, size := utf8.DecodeRuneInString(cluster)
+	if size == 0 {
+		return 0
+	}
+
+	rest := cluster[size:]
+	for _, r := range rest {
+		if r == emojiPresentation {
+			// 絵文字表示の異体字セレクタが付いた文字は全角として扱う
+			return 2
+		}
+	}
+	if isRegionalIndicator(first) {
+		// 国旗は2セル、単独の地域指示記号は1セル
+		if utf8.RuneCountInString(cluster) >= 2 {
+			return 2
+		}
+		return 1
+	}
+
+	return RuneWidth(first)
+}
+
+// StringWidth は文字列が端末で占めるセル数を返します
+func StringWidth(text string) int {
+	width := 0
+	for _, cluster := range Graphemes(text) {
+		width += GraphemeWidth(cluster)
+	}
+	return width
+}
@@ -0,0 +1,92 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{'a', 1},
+		{' ', 1},
+		{'\t', 0},
+		{0, 0},
+		{'あ', 2},
+		{'\uFF71', 1}, // 半角カタカナ
+		{'漢', 2},
+		{'Ａ', 2}, // 全角英字
+		{'한', 2},
+		{'\u0301', 0}, // 結合アクセント
+		{'\u200D', 0}, // ZWJ
+		{'\uFE0F', 0}, // 異体字セレクタ
+		{'😀', 2},
+		{'\U0001F3FB', 0}, // 肌の色の修飾子
+		{'\U00020000', 2}, // CJK 統合漢字拡張B
+		{'→', 1},
+	}
+	for _, tt := range tests {
+		if got := RuneWidth(tt.r); got != tt.want {
+			t.Errorf("RuneWidth(%U) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{"ascii", "abc", []string{"a", "b", "c"}},
+		{"combining mark", "éx", []string{"é", "x"}},
+		{"crlf", "a\r\nb", []string{"a", "\r\n", "b"}},
+		{"zwj sequence", "👨‍👩‍👧!", []string{"👨‍👩‍👧", "!"}},
+		{"skin tone", "👍\U0001F3FD", []string{"👍\U0001F3FD"}},
+		{"flags pair up", "🇯🇵🇺🇸🇫", []string{"🇯🇵", "🇺🇸", "🇫"}},
+		{"hangul jamo", "각가", []string{"각", "가"}},
+		{"variation selector", "☺️", []string{"☺️"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Graphemes(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Graphemes(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"日本語", 6},
+		{"aあb", 4},
+		{"é", 1},
+		{"👨‍👩‍👧", 2},
+		{"🇯🇵", 2},
+		{"🇯", 1},
+		{"☺", 1},
+		{"☺️", 2},
+		{"각", 2},
+		{"ｶﾀｶﾅ", 4},
+	}
+	for _, tt := range tests {
+		if got := StringWidth(tt.text); got != tt.want {
+			t.Errorf("StringWidth(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTerminalTextMeasurer(t *testing.T) {
+	size := TerminalTextMeasurer{}.MeasureText("日本a", nil)
+	if size != (Size{Width: 5, Height: 1}) {
+		t.Errorf("MeasureText = %v, want {5 1}", size)
+	}
+}
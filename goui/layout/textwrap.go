@@ -0,0 +1,268 @@
+package layout
+
+import (
+	"strings"
+
+	"github.com/tak/goui/core"
+)
+
+// テキストがはみ出したときの扱い（Props の "overflow"）
+const (
+	// TextOverflowClip ははみ出した部分を切り取ります
+	TextOverflowClip = "clip"
+	// TextOverflowEllipsis ははみ出した部分を省略記号（…）に置き換えます
+	TextOverflowEllipsis = "ellipsis"
+	// TextOverflowFade は末尾をフェードアウトさせます
+	// 端末ではフェードを表現できないため、省略記号として扱います
+	TextOverflowFade = "fade"
+)
+
+// ellipsis は省略記号です
+const ellipsis = "…"
+
+// TextLayout はテキストを行に分割した結果です
+type TextLayout struct {
+	// Lines は表示する各行のテキストです
+	Lines []string
+	// LineHeight は1行の高さです
+	LineHeight float64
+	// Size はテキスト全体のサイズです（幅は最も長い行の幅）
+	Size Size
+	// Truncated は maxLines や幅の制限によってテキストが省略されたかを示します
+	Truncated bool
+}
+
+// textLine は書記素クラスタとその幅の並びで表した1行です
+type textLine struct {
+	clusters []string
+	widths   []float64
+}
+
+// LayoutText はテキストを maxWidth の幅に収まるように行に分割します
+//
+// Props の次の値に従います。
+//   - softWrap: false の場合は改行文字でのみ改行します（既定値 true）
+//   - maxLines: 表示する最大行数です。0 以下は無制限です
+//   - overflow: 行数または幅からはみ出したときの扱いです（"clip"、"ellipsis"、"fade"）
+//
+// 折り返しは空白の後、および CJK の文字の間で行い、行頭禁則・行末禁則の文字の前後では改行しません。
+// 1語が1行に収まらない場合は書記素クラスタの境界で改行します。
+func LayoutText(text string, props core.Props, maxWidth float64, measurer TextMeasurer) TextLayout {
+	softWrap := props.GetBool("softWrap", true)
+	maxLines := props.GetInt("maxLines", 0)
+	overflow := props.GetString("overflow", TextOverflowClip)
+
+	measure := func(s string) float64 {
+		return measurer.MeasureText(s, props).Width
+	}
+
+	// 改行文字で段落に分け、段落ごとに折り返す
+	var lines []textLine
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := newTextLine(paragraph, measure)
+		if softWrap {
+			lines = append(lines, wrapLine(line, maxWidth)...)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+
+	truncated := false
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[:maxLines]
+		truncated = true
+		if overflow != TextOverflowClip {
+			last := len(lines) - 1
+			lines[last] = lines[last].ellipsize(maxWidth, measure(ellipsis))
+		}
+	}
+
+	// 幅からはみ出す行を切り取るか省略する
+	for i, line := range lines {
+		if line.width() <= maxWidth {
+			continue
+		}
+		truncated = true
+		if overflow == TextOverflowClip {
+			lines[i] = line.clip(maxWidth)
+		} else {
+			lines[i] = line.ellipsize(maxWidth, measure(ellipsis))
+		}
+	}
+
+	result := TextLayout{
+		LineHeight: measurer.MeasureText("", props).Height,
+		Truncated:  truncated,
+	}
+	for _, line := range lines {
+		result.Lines = append(result.Lines, line.String())
+		result.Size.Width = max(result.Size.Width, line.width())
+	}
+	result.Size.Height = float64(len(result.Lines)) * result.LineHeight
+	return result
+}
+
+// minTextWidth は改行できる位置で区切った単語のうち、最も幅の広いものの幅を返します
+// 折り返しを許す場合に、テキストを崩さずに縮められる最小の幅になります
+func minTextWidth(text string, props core.Props, measurer TextMeasurer) float64 {
+	measure := func(s string) float64 {
+		return measurer.MeasureText(s, props).Width
+	}
+
+	widest := 0.0
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := newTextLine(paragraph, measure)
+		start := 0
+		for i := 1; i <= len(line.clusters); i++ {
+			if i == len(line.clusters) || canBreakBetween(line.clusters[i-1], line.clusters[i]) {
+				widest = max(widest, line.slice(start, i).trimTrailingSpace().width())
+				start = i
+			}
+		}
+	}
+	return widest
+}
+
+// newTextLine はテキストを書記素クラスタに分割し、それぞれの幅を計測します
+func newTextLine(text string, measure func(string) float64) textLine {
+	line := textLine{clusters: Graphemes(text)}
+	line.widths = make([]float64, len(line.clusters))
+	for i, cluster := range line.clusters {
+		line.widths[i] = measure(cluster)
+	}
+	return line
+}
+
+// slice は [start, end) の範囲の書記素クラスタからなる行を返します
+func (l textLine) slice(start, end int) textLine {
+	return textLine{clusters: l.clusters[start:end], widths: l.widths[start:end]}
+}
+
+// width は行の幅を返します
+func (l textLine) width() float64 {
+	total := 0.0
+	for _, width := range l.widths {
+		total += width
+	}
+	return total
+}
+
+// String は行のテキストを返します
+func (l textLine) String() string {
+	return strings.Join(l.clusters, "")
+}
+
+// trimTrailingSpace は行末の空白を取り除きます
+func (l textLine) trimTrailingSpace() textLine {
+	end := len(l.clusters)
+	for end > 0 && isSpace(l.clusters[end-1]) {
+		end--
+	}
+	return l.slice(0, end)
+}
+
+// clip は maxWidth に収まる部分だけを残した行を返します
+func (l textLine) clip(maxWidth float64) textLine {
+	total := 0.0
+	for i, width := range l.widths {
+		if total+width > maxWidth {
+			return l.slice(0, i)
+		}
+		total += width
+	}
+	return l
+}
+
+// ellipsize は末尾に省略記号を付けても maxWidth に収まるように切り詰めた行を返します
+func (l textLine) ellipsize(maxWidth, ellipsisWidth float64) textLine {
+	if ellipsisWidth > maxWidth {
+		return textLine{}
+	}
+	clipped := l.clip(maxWidth - ellipsisWidth).trimTrailingSpace()
+	return textLine{
+		clusters: append(append([]string{}, clipped.clusters...), ellipsis),
+		widths:   append(append([]float64{}, clipped.widths...), ellipsisWidth),
+	}
+}
+
+// wrapLine は1つの段落を maxWidth に収まる行に折り返します
+func wrapLine(line textLine, maxWidth float64) []textLine {
+	var lines []textLine
+	count := len(line.clusters)
+	start := 0
+
+	for {
+		width := 0.0
+		lastBreak := -1
+		i := start
+		for ; i < count; i++ {
+			if i > start && canBreakBetween(line.clusters[i-1], line.clusters[i]) {
+				lastBreak = i
+			}
+			// 空白は行末にぶら下げるため幅を超えても改行しない
+			if i > start && !isSpace(line.clusters[i]) && width+line.widths[i] > maxWidth {
+				break
+			}
+			width += line.widths[i]
+		}
+
+		if i >= count {
+			// 最後の行の空白は幅に収まる限り残す
+			last := line.slice(start, count)
+			if last.width() > maxWidth {
+				last = last.trimTrailingSpace()
+			}
+			return append(lines, last)
+		}
+
+		// 改行できる位置がなければ書記素クラスタの境界で強制的に改行する
+		end := i
+		if lastBreak > start {
+			end = lastBreak
+		}
+		lines = append(lines, line.slice(start, end).trimTrailingSpace())
+
+		// 次の行の先頭の空白は読み飛ばす
+		start = end
+		for start < count && isSpace(line.clusters[start]) {
+			start++
+		}
+		if start >= count {
+			return lines
+		}
+	}
+}
+
+// 行頭禁則文字（行の先頭に置かない文字）
+const noBreakBeforeChars = ")]}.,;:!?%" +
+	"、。，．・：；？！゛゜ヽヾゝゞ々ー―）］｝」』〕〉》】〙〗〟’”｠»" +
+	"ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶㇰㇱㇲㇳㇴㇵㇶㇷㇸㇹㇺㇻㇼㇽㇾㇿ…‥"
+
+// 行末禁則文字（行の末尾に置かない文字）
+const noBreakAfterChars = "([{（［｛「『〔〈《【〘〖〝‘“｟«"
+
+// canBreakBetween は2つの書記素クラスタの間で改行できるかを判定します
+func canBreakBetween(previous, next string) bool {
+	switch {
+	case isSpace(next):
+		return false
+	case isSpace(previous):
+		return true
+	case strings.Contains(noBreakBeforeChars, next), strings.Contains(noBreakAfterChars, previous):
+		return false
+	case previous == "-":
+		return true
+	case GraphemeWidth(previous) >= 2 || GraphemeWidth(next) >= 2:
+		// 全角文字（CJK や絵文字）の前後では単語の区切りがなくても改行できる
+		return true
+	default:
+		return false
+	}
+}
+
+// isSpace は書記素クラスタが空白かを判定します
+func isSpace(cluster string) bool {
+	return cluster == " " || cluster == "\t" || cluster == "　"
+}
@@ -0,0 +1,114 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tak/goui/core"
+)
+
+func TestLayoutText(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		props         core.Props
+		maxWidth      float64
+		want          []string
+		wantTruncated bool
+	}{
+		{"fits on one line", "hello world", nil, 11, []string{"hello world"}, false},
+		{"wraps at spaces", "hello world", nil, 5, []string{"hello", "world"}, false},
+		{"trailing space hangs", "ab cd", nil, 3, []string{"ab", "cd"}, false},
+		{"explicit newlines", "a\nb\r\nc", nil, 10, []string{"a", "b", "c"}, false},
+		{"breaks long words", "abcdefgh", nil, 3, []string{"abc", "def", "gh"}, false},
+		{"breaks after hyphen", "well-known", nil, 6, []string{"well-", "known"}, false},
+		{"breaks between CJK characters", "日本語です", nil, 4, []string{"日本", "語で", "す"}, false},
+		{"no line starts with closing punctuation", "あいう。えお", nil, 6, []string{"あい", "う。え", "お"}, false},
+		{"no line ends with opening bracket", "あい「うえ」", nil, 6, []string{"あい", "「う", "え」"}, false},
+		{"small kana stays with previous", "あいっう", nil, 4, []string{"あ", "いっ", "う"}, false},
+		{
+			name:          "maxLines with ellipsis",
+			text:          "one two three",
+			props:         core.Props{"maxLines": 2, "overflow": TextOverflowEllipsis},
+			maxWidth:      5,
+			want:          []string{"one", "two…"},
+			wantTruncated: true,
+		},
+		{
+			name:          "maxLines with clip",
+			text:          "one two three",
+			props:         core.Props{"maxLines": 1},
+			maxWidth:      5,
+			want:          []string{"one"},
+			wantTruncated: true,
+		},
+		{
+			name:          "softWrap false clips",
+			text:          "hello world",
+			props:         core.Props{"softWrap": false},
+			maxWidth:      5,
+			want:          []string{"hello"},
+			wantTruncated: true,
+		},
+		{
+			name:          "softWrap false with ellipsis",
+			text:          "hello world",
+			props:         core.Props{"softWrap": false, "overflow": TextOverflowEllipsis},
+			maxWidth:      6,
+			want:          []string{"hello…"},
+			wantTruncated: true,
+		},
+		{
+			name:          "fade is treated as ellipsis",
+			text:          "日本語",
+			props:         core.Props{"softWrap": false, "overflow": TextOverflowFade},
+			maxWidth:      4,
+			want:          []string{"日…"},
+			wantTruncated: true,
+		},
+		{
+			name:          "wide character is not split when clipping",
+			text:          "a日本",
+			props:         core.Props{"softWrap": false},
+			maxWidth:      2,
+			want:          []string{"a"},
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LayoutText(tt.text, tt.props, tt.maxWidth, TerminalTextMeasurer{})
+			if !reflect.DeepEqual(got.Lines, tt.want) {
+				t.Errorf("Lines = %q, want %q", got.Lines, tt.want)
+			}
+			if got.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", got.Truncated, tt.wantTruncated)
+			}
+			if got.Size.Height != float64(len(tt.want)) {
+				t.Errorf("Size.Height = %v, want %d", got.Size.Height, len(tt.want))
+			}
+			if got.Size.Width > tt.maxWidth {
+				t.Errorf("Size.Width = %v exceeds %v", got.Size.Width, tt.maxWidth)
+			}
+		})
+	}
+}
+
+func TestMinTextWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"", 0},
+		{"hello wonderful world", 9},
+		{"日本語", 2},
+		{"あい。", 4},
+		{"short\nlongest", 7},
+	}
+	for _, tt := range tests {
+		if got := minTextWidth(tt.text, nil, TerminalTextMeasurer{}); got != tt.want {
+			t.Errorf("minTextWidth(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"github.com/tak/goui/core"
 	"github.com/tak/goui/layout"
 	"github.com/tak/goui/render"
@@ -12,54 +13,50 @@ func main() {
 	// 状態管理マネージャーを作成
 	stateManager := core.NewStateManager()
 	
-	// カウンター状態を作成
-	counterGetter, counterSetter := stateManager.CreateState("counter", 0)
+	// コンポーザーを作成し、コンポジションが確定するたびにUIをレンダリング
+	composer := core.NewComposer(stateManager)
+	composer.OnCommit(renderUI)
 	
-	// カウンターを増加させる関数
-	incrementCounter := func() {
-		currentValue := counterGetter().(int)
-		counterSetter(currentValue + 1)
-		fmt.Printf("カウンター: %d\n", currentValue + 1)
-		
-		// UIを再レンダリング
-		renderUI(counterGetter().(int))
-	}
+	// カウンターアプリを作成
+	// SetState で状態を更新すると、このコンポーネントだけが再構成される
+	app := NewCounterApp()
 	
-	// カウンターを減少させる関数
-	decrementCounter := func() {
-		currentValue := counterGetter().(int)
-		if currentValue > 0 {
-			counterSetter(currentValue - 1)
-			fmt.Printf("カウンター: %d\n", currentValue - 1)
-			
-			// UIを再レンダリング
-			renderUI(counterGetter().(int))
-		}
-	}
-	
-	// 初期UIをレンダリング
-	renderUI(counterGetter().(int))
+	// 初期UIを構成
+	composer.SetContent(core.NewComponentNode("app", app, core.Props{
+		"initialCount": 0,
+	}))
 	
 	// インクリメントボタンをシミュレート
 	fmt.Println("\n[インクリメントボタンがクリックされました]")
-	incrementCounter()
+	app.Increment()
+	composer.Recompose()
 	
 	// インクリメントボタンをシミュレート
 	fmt.Println("\n[インクリメントボタンがクリックされました]")
-	incrementCounter()
+	app.Increment()
+	composer.Recompose()
 	
 	// デクリメントボタンをシミュレート
 	fmt.Println("\n[デクリメントボタンがクリックされました]")
-	decrementCounter()
+	app.Decrement()
+	composer.Recompose()
+	
+	// コンポジションを破棄（CounterApp の Cleanup が呼ばれる）
+	composer.Dispose()
 }
 
-// renderUI はUIツリーを構築してレンダリングします
-func renderUI(counterValue int) {
-	// ルートノードを作成
-	root := buildUI(counterValue)
+// renderUI は構成済みのUIツリーをレンダリングします
+func renderUI(root *core.Node) {
+	// 兄弟間でキーが重複していれば警告を出力
+	for _, duplicate := range core.FindDuplicateKeys(root) {
+		fmt.Println("警告:", duplicate)
+	}
+	for _, duplicate := range layout.FindDuplicateLayoutIDs(root) {
+		fmt.Println("警告:", duplicate)
+	}
 	
-	// コンソールレンダリングターゲットを作成
-	target := render.NewConsoleRenderTarget(80, 20)
+	// ANSI カラー対応の端末レンダリングターゲットを作成
+	target := render.NewAnsiRenderTarget(os.Stdout, 80, 20, render.TrueColor)
 	
 	// レンダラーを作成
 	renderer := render.NewRenderer(target)
@@ -75,7 +72,7 @@ func renderUI(counterValue int) {
 }
 
 // buildUI はUIツリーを構築します
-func buildUI(counterValue int) *core.Node {
+func buildUI(counterValue int, onIncrement, onDecrement func()) *core.Node {
 	// カウンターアプリのUI
 	return widgets.Column("root", core.Props{
 		"spacing": 1.0,
@@ -103,12 +100,12 @@ func buildUI(counterValue int) *core.Node {
 			"spacing": 2.0,
 		},
 			// デクリメントボタン
-			widgets.Button("decrement", "-", func() {}, core.Props{
+			widgets.Button("decrement", "-", onDecrement, core.Props{
 				"backgroundColor": "#F44336",
 			}),
 			
 			// インクリメントボタン
-			widgets.Button("increment", "+", func() {}, core.Props{
+			widgets.Button("increment", "+", onIncrement, core.Props{
 				"backgroundColor": "#4CAF50",
 			}),
 		),
@@ -117,6 +114,7 @@ func buildUI(counterValue int) *core.Node {
 
 // CounterApp はカウンターアプリのコンポーネントです
 type CounterApp struct {
+	core.Invalidator
 	counter int
 }
 
@@ -129,7 +127,21 @@ func NewCounterApp() *CounterApp {
 
 // Render はカウンターアプリのUIを生成します
 func (ca *CounterApp) Render(props core.Props) *core.Node {
-	return buildUI(ca.counter)
+	return buildUI(ca.counter, ca.Increment, ca.Decrement)
+}
+
+// Increment はカウンターを増加させます
+func (ca *CounterApp) Increment() {
+	ca.SetState(ca.counter + 1)
+	fmt.Printf("カウンター: %d\n", ca.counter)
+}
+
+// Decrement はカウンターを減少させます
+func (ca *CounterApp) Decrement() {
+	if ca.counter > 0 {
+		ca.SetState(ca.counter - 1)
+		fmt.Printf("カウンター: %d\n", ca.counter)
+	}
 }
 
 // ShouldUpdate はコンポーネントが更新すべきかを判断します
@@ -142,16 +154,18 @@ func (ca *CounterApp) GetState() interface{} {
 	return ca.counter
 }
 
-// SetState は状態を更新します
+// SetState は状態を更新し、再構成を要求します
 func (ca *CounterApp) SetState(newState interface{}) {
 	if newCounter, ok := newState.(int); ok {
 		ca.counter = newCounter
+		ca.Invalidate()
 	}
 }
 
 // Initialize はコンポーネントを初期化します
 func (ca *CounterApp) Initialize(props core.Props) {
-	// 初期化処理
+	// 初期値をプロパティから設定
+	ca.counter = props.GetInt("initialCount", 0)
 }
 
 // Cleanup はコンポーネントのクリーンアップを行います
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"io"
+	"strings"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+// AnsiRenderTarget は ANSI エスケープシーケンスで色付きの描画を行う端末向けターゲットです
+//
+// backgroundColor で背景を塗りつぶし、color で文字色を設定します。
+// 枠線には Unicode の罫線文字を使い、borderRadius が指定されていれば角を丸くします。
+type AnsiRenderTarget struct {
+	writer io.Writer
+	mode   ColorMode
+	canvas *canvas
+	err    error
+}
+
+// NewAnsiRenderTarget は新しい ANSI レンダリングターゲットを作成します
+func NewAnsiRenderTarget(writer io.Writer, width, height int, mode ColorMode) *AnsiRenderTarget {
+	return &AnsiRenderTarget{
+		writer: writer,
+		mode:   mode,
+		canvas: newCanvas(width, height),
+	}
+}
+
+// TextMeasurer は端末のセル単位でテキストを計測する TerminalTextMeasurer を返します
+func (a *AnsiRenderTarget) TextMeasurer() layout.TextMeasurer {
+	return layout.TerminalTextMeasurer{}
+}
+
+// Clear はレンダリング領域をクリアします
+func (a *AnsiRenderTarget) Clear() {
+	a.canvas.clear()
+}
+
+// DrawRect は矩形を描画します
+func (a *AnsiRenderTarget) DrawRect(rect layout.Rect, props core.Props) {
+	a.canvas.drawRect(rect, props)
+}
+
+// DrawText はテキストを描画します
+func (a *AnsiRenderTarget) DrawText(text string, rect layout.Rect, props core.Props) {
+	a.canvas.drawText(text, rect, props)
+}
+
+// PushClip は以降の描画を rect の内側に制限します
+func (a *AnsiRenderTarget) PushClip(rect layout.Rect) {
+	a.canvas.clips.push(rect)
+}
+
+// PopClip は直前の PushClip で設定したクリップ領域を解除します
+func (a *AnsiRenderTarget) PopClip() {
+	a.canvas.clips.pop()
+}
+
+// DrawScrollbar は罫線文字のトラックと塗りつぶしのつまみでスクロールバーを描画します
+// scrollbarColor でつまみ、scrollbarTrackColor でトラックの色を指定します
+func (a *AnsiRenderTarget) DrawScrollbar(viewport layout.Rect, vertical bool, thumbStart, thumbLength float64, props core.Props) {
+	a.canvas.drawScrollbar(viewport, vertical, thumbStart, thumbLength, props)
+}
+
+// Flush はレンダリング結果を io.Writer に出力します
+// 書き込みに失敗した場合のエラーは Err で取得できます
+func (a *AnsiRenderTarget) Flush() {
+	var builder strings.Builder
+	for _, row := range a.canvas.cells {
+		writeAnsiRow(&builder, row, a.mode)
+		builder.WriteString("\n")
+	}
+
+	_, a.err = io.WriteString(a.writer, builder.String())
+}
+
+// Err は直前の Flush で発生した書き込みエラーを返します
+func (a *AnsiRenderTarget) Err() error {
+	return a.err
+}
+
+// writeAnsiRow は1行分のセルを色の変化があるときだけエスケープシーケンスを挟みながら書き込みます
+func writeAnsiRow(builder *strings.Builder, row []cell, mode ColorMode) {
+	state := sgrState{mode: mode}
+	for _, c := range row {
+		if c.isContinuation() {
+			// 全角文字の右半分は直前の文字の出力で埋まっている
+			continue
+		}
+		state.writeCell(builder, c)
+	}
+	state.reset(builder)
+}
+
+// sgrState は端末に設定済みの文字色と背景色を追跡します
+type sgrState struct {
+	mode ColorMode
+	fg   Color
+	bg   Color
+}
+
+// writeCell は色が変わる場合だけエスケープシーケンスを出力してからセルの文字を書き込みます
+func (s *sgrState) writeCell(builder *strings.Builder, c cell) {
+	if c.fg != s.fg {
+		builder.WriteString(c.fg.foregroundSequence(s.mode))
+		s.fg = c.fg
+	}
+	if c.bg != s.bg {
+		builder.WriteString(c.bg.backgroundSequence(s.mode))
+		s.bg = c.bg
+	}
+	builder.WriteString(c.ch)
+}
+
+// reset は色が設定されていれば既定の色に戻します
+func (s *sgrState) reset(builder *strings.Builder) {
+	if s.fg.Valid || s.bg.Valid {
+		builder.WriteString("\x1b[0m")
+	}
+	s.fg = Color{}
+	s.bg = Color{}
+}
@@ -0,0 +1,169 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+func TestAnsiRenderTargetFlush(t *testing.T) {
+	tests := []struct {
+		name string
+		mode ColorMode
+		draw func(target *AnsiRenderTarget)
+		want string
+	}{
+		{
+			name: "plain text has no sequences",
+			mode: TrueColor,
+			draw: func(target *AnsiRenderTarget) {
+				target.DrawText("ab", textAt(0, 0, 4), core.Props{})
+			},
+			want: "ab  \n",
+		},
+		{
+			name: "colored text resets to the default foreground",
+			mode: TrueColor,
+			draw: func(target *AnsiRenderTarget) {
+				target.DrawText("ab", textAt(0, 0, 4), core.Props{"color": "#FF0000"})
+			},
+			want: "\x1b[38;2;255;0;0mab\x1b[39m  \n",
+		},
+		{
+			name: "256 color mode",
+			mode: Color256,
+			draw: func(target *AnsiRenderTarget) {
+				target.DrawText("ab", textAt(0, 0, 4), core.Props{"color": "red"})
+			},
+			want: "\x1b[38;5;196mab\x1b[39m  \n",
+		},
+		{
+			name: "background to the end of the row is reset",
+			mode: TrueColor,
+			draw: func(target *AnsiRenderTarget) {
+				target.DrawRect(rectAt(1, 0, 3, 1), core.Props{"backgroundColor": "#00F"})
+			},
+			want: " \x1b[48;2;0;0;255m   \x1b[0m\n",
+		},
+		{
+			name: "sequences only on color changes",
+			mode: TrueColor,
+			draw: func(target *AnsiRenderTarget) {
+				target.DrawRect(rectAt(0, 0, 4, 1), core.Props{"backgroundColor": "#00F"})
+				target.DrawText("ab", textAt(1, 0, 2), core.Props{"color": "#FFF"})
+			},
+			want: "\x1b[48;2;0;0;255m \x1b[38;2;255;255;255mab\x1b[39m \x1b[0m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			target := NewAnsiRenderTarget(&output, 4, 1, tt.mode)
+			tt.draw(target)
+			target.Flush()
+			if got := output.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanvasDrawRect(t *testing.T) {
+	blue := Color{B: 0xFF, Valid: true}
+	tests := []struct {
+		name  string
+		props core.Props
+		// rect は描画する領域です（ゼロ値ならキャンバス全体）
+		rect layout.Rect
+		// clip は描画前に設定するクリップ領域です（ゼロ値ならクリップしない）
+		clip  layout.Rect
+		chars []string
+		// bg は背景色で塗られたセルを '#' で表します
+		bg []string
+	}{
+		{
+			name:  "square fill",
+			props: core.Props{"backgroundColor": "#00F"},
+			chars: []string{"    ", "    ", "    "},
+			bg:    []string{"####", "####", "####"},
+		},
+		{
+			name:  "rounded fill leaves the corners",
+			props: core.Props{"backgroundColor": "#00F", "borderRadius": 1.0},
+			chars: []string{"╭  ╮", "    ", "╰  ╯"},
+			bg:    []string{".##.", "####", ".##."},
+		},
+		{
+			name:  "rounded fill with a border",
+			props: core.Props{"backgroundColor": "#00F", "borderRadius": 1.0, "borderWidth": 1.0},
+			chars: []string{"╭──╮", "│  │", "╰──╯"},
+			bg:    []string{".##.", "####", ".##."},
+		},
+		{
+			name:  "rounded border without fill",
+			props: core.Props{"borderRadius": 1.0},
+			chars: []string{"╭──╮", "│  │", "╰──╯"},
+			bg:    []string{"....", "....", "...."},
+		},
+		{
+			name:  "fill larger than the canvas is clamped",
+			props: core.Props{"backgroundColor": "#00F", "borderRadius": 1.0},
+			rect:  rectAt(-2, -1, 100, 100),
+			chars: []string{"    ", "    ", "    "},
+			bg:    []string{"####", "####", "####"},
+		},
+		{
+			name:  "corners of a fill off the canvas stay on the original rect",
+			props: core.Props{"backgroundColor": "#00F", "borderRadius": 1.0},
+			rect:  rectAt(-1, 0, 5, 3),
+			chars: []string{"   ╮", "    ", "   ╯"},
+			bg:    []string{"###.", "####", "###."},
+		},
+		{
+			name:  "fill is clipped",
+			props: core.Props{"backgroundColor": "#00F"},
+			clip:  rectAt(1, 1, 2, 5),
+			chars: []string{"    ", "    ", "    "},
+			bg:    []string{"....", ".##.", ".##."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCanvas(4, 3)
+			if tt.clip != (layout.Rect{}) {
+				c.clips.push(tt.clip)
+			}
+			rect := tt.rect
+			if rect == (layout.Rect{}) {
+				rect = rectAt(0, 0, 4, 3)
+			}
+			c.drawRect(rect, tt.props)
+
+			chars := make([]string, c.height)
+			bg := make([]string, c.height)
+			for y, row := range c.cells {
+				var ch, fill strings.Builder
+				for _, cell := range row {
+					ch.WriteString(cell.ch)
+					if cell.bg == blue {
+						fill.WriteByte('#')
+					} else {
+						fill.WriteByte('.')
+					}
+				}
+				chars[y], bg[y] = ch.String(), fill.String()
+			}
+			if strings.Join(chars, "\n") != strings.Join(tt.chars, "\n") {
+				t.Errorf("chars =\n%s\nwant\n%s", strings.Join(chars, "\n"), strings.Join(tt.chars, "\n"))
+			}
+			if strings.Join(bg, "\n") != strings.Join(tt.bg, "\n") {
+				t.Errorf("background =\n%s\nwant\n%s", strings.Join(bg, "\n"), strings.Join(tt.bg, "\n"))
+			}
+		})
+	}
+}
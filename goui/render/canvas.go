@@ -0,0 +1,330 @@
+package render
+
+import (
+	"math"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+// cell は端末の1文字分のセルです
+// ch には書記素クラスタが入り、全角文字の右半分のセルは空文字列になります
+type cell struct {
+	ch string
+	fg Color
+	bg Color
+}
+
+// blankCell は何も描画されていないセルです
+var blankCell = cell{ch: " "}
+
+// isContinuation は全角文字の右半分のセルかを判定します
+func (c cell) isContinuation() bool {
+	return c.ch == ""
+}
+
+// boxChars は枠線に使う罫線文字の組です
+type boxChars struct {
+	horizontal, vertical                       rune
+	topLeft, topRight, bottomLeft, bottomRight rune
+}
+
+var (
+	// squareBox は角が直角の罫線文字です
+	squareBox = boxChars{'─', '│', '┌', '┐', '└', '┘'}
+	// roundedBox は角が丸い罫線文字です
+	roundedBox = boxChars{'─', '│', '╭', '╮', '╰', '╯'}
+)
+
+// cellRect はセル座標の範囲（両端を含む）です
+type cellRect struct {
+	x1, y1, x2, y2 int
+}
+
+// contains は座標が範囲内にあるかを判定します
+func (r cellRect) contains(x, y int) bool {
+	return x >= r.x1 && y >= r.y1 && x <= r.x2 && y <= r.y2
+}
+
+// intersect は2つの範囲の共通部分を返します
+func (r cellRect) intersect(other cellRect) cellRect {
+	return cellRect{
+		x1: max(r.x1, other.x1),
+		y1: max(r.y1, other.y1),
+		x2: min(r.x2, other.x2),
+		y2: min(r.y2, other.y2),
+	}
+}
+
+// clipStack は入れ子になったクリップ領域です
+// 積まれた領域は外側の領域との共通部分として記録されます
+type clipStack []cellRect
+
+// push は矩形を外側の領域で切り取って積みます
+func (s *clipStack) push(rect layout.Rect) {
+	x1, y1, x2, y2 := rectBounds(rect)
+	clip := cellRect{x1: x1, y1: y1, x2: x2, y2: y2}
+	if len(*s) > 0 {
+		clip = clip.intersect((*s)[len(*s)-1])
+	}
+	*s = append(*s, clip)
+}
+
+// pop は最後に積んだ領域を取り除きます
+func (s *clipStack) pop() {
+	if len(*s) > 0 {
+		*s = (*s)[:len(*s)-1]
+	}
+}
+
+// current は現在のクリップ領域を返します（領域がなければ bounds）
+func (s clipStack) current(bounds cellRect) cellRect {
+	if len(s) == 0 {
+		return bounds
+	}
+	return s[len(s)-1].intersect(bounds)
+}
+
+// contains は座標が現在のクリップ領域の内側にあるかを判定します（領域がなければ常に true）
+func (s clipStack) contains(x, y int) bool {
+	return len(s) == 0 || s[len(s)-1].contains(x, y)
+}
+
+// canvas は色付きの文字セルのグリッドです
+// 端末向けのレンダリングターゲットが描画先として共有します
+type canvas struct {
+	width  int
+	height int
+	cells  [][]cell
+	clips  clipStack
+}
+
+// newCanvas は指定されたサイズの空のキャンバスを作成します
+func newCanvas(width, height int) *canvas {
+	cells := make([][]cell, height)
+	for y := range cells {
+		cells[y] = make([]cell, width)
+	}
+	c := &canvas{width: width, height: height, cells: cells}
+	c.clear()
+	return c
+}
+
+// clear はすべてのセルを空にし、クリップ領域を解除します
+func (c *canvas) clear() {
+	c.clips = nil
+	for y := range c.cells {
+		for x := range c.cells[y] {
+			c.cells[y][x] = blankCell
+		}
+	}
+}
+
+// inBounds は座標がキャンバス内にあるかを判定します
+func (c *canvas) inBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.width && y < c.height
+}
+
+// writable は座標がキャンバス内かつ現在のクリップ領域の内側にあるかを判定します
+func (c *canvas) writable(x, y int) bool {
+	return c.inBounds(x, y) && c.clips.contains(x, y)
+}
+
+// visible はキャンバスの範囲と現在のクリップ領域の共通部分を返します
+func (c *canvas) visible() cellRect {
+	return c.clips.current(cellRect{x1: 0, y1: 0, x2: c.width - 1, y2: c.height - 1})
+}
+
+// setRune は背景色を保ったままセルに文字を書き込みます
+func (c *canvas) setRune(x, y int, ch rune, fg Color) {
+	c.setGrapheme(x, y, string(ch), 1, fg)
+}
+
+// setGrapheme は背景色を保ったまま書記素クラスタを width セル分に書き込みます
+// 全角文字は2セルを占め、右側のセルは続きのセルになります。
+// 全角文字の一部だけが上書きされる場合は、残った半分を空白に置き換えます。
+func (c *canvas) setGrapheme(x, y int, cluster string, width int, fg Color) {
+	if width <= 0 || y < 0 || y >= c.height {
+		return
+	}
+	if !c.writable(x, y) || !c.writable(x+width-1, y) {
+		// 端やクリップ領域にかかって一部しか表示できない文字は空白として描画する
+		for i := 0; i < width; i++ {
+			if c.writable(x+i, y) {
+				c.breakWide(x+i, y)
+				c.cells[y][x+i].ch = " "
+				c.cells[y][x+i].fg = fg
+			}
+		}
+		return
+	}
+
+	for i := 0; i < width; i++ {
+		c.breakWide(x+i, y)
+	}
+	c.cells[y][x].ch = cluster
+	c.cells[y][x].fg = fg
+	for i := 1; i < width; i++ {
+		c.cells[y][x+i].ch = ""
+		c.cells[y][x+i].fg = fg
+	}
+}
+
+// breakWide は上書きされるセルが全角文字の片側であれば、もう片側を空白に置き換えます
+func (c *canvas) breakWide(x, y int) {
+	if c.cells[y][x].isContinuation() {
+		// 右半分が上書きされる場合は左半分を消す
+		if x > 0 {
+			c.cells[y][x-1].ch = " "
+		}
+		return
+	}
+	// 左半分が上書きされる場合は右側の続きのセルを消す
+	for i := x + 1; i < c.width && c.cells[y][i].isContinuation(); i++ {
+		c.cells[y][i].ch = " "
+	}
+}
+
+// drawRect は背景の塗りつぶしと枠線の描画を行います
+//
+// backgroundColor が指定されていれば領域を塗りつぶします。
+// 枠線は borderWidth が正の場合、または背景色がない場合に描画され、
+// borderRadius が正の場合は角丸の罫線文字を使用します。
+// 2×2 セル以上の角丸の背景は四隅を塗らず、枠線がなければ背景色の角丸の罫線文字で角を描きます。
+func (c *canvas) drawRect(rect layout.Rect, props core.Props) {
+	x1, y1, x2, y2 := rectBounds(rect)
+	if x2 < x1 || y2 < y1 {
+		return
+	}
+
+	rounded := props.GetFloat("borderRadius", 0) > 0
+	background := colorProp(props, "backgroundColor")
+	if background.Valid {
+		// 角の判定は元の領域で行い、塗りつぶしは描画できる範囲に限る
+		roundedCorners := rounded && x1 < x2 && y1 < y2
+		fill := cellRect{x1: x1, y1: y1, x2: x2, y2: y2}.intersect(c.visible())
+		for y := fill.y1; y <= fill.y2; y++ {
+			for x := fill.x1; x <= fill.x2; x++ {
+				if roundedCorners && (x == x1 || x == x2) && (y == y1 || y == y2) {
+					continue
+				}
+				c.breakWide(x, y)
+				c.cells[y][x] = cell{ch: " ", bg: background}
+			}
+		}
+
+		if props.GetFloat("borderWidth", 0) <= 0 {
+			if roundedCorners {
+				c.setRune(x1, y1, roundedBox.topLeft, background)
+				c.setRune(x2, y1, roundedBox.topRight, background)
+				c.setRune(x1, y2, roundedBox.bottomLeft, background)
+				c.setRune(x2, y2, roundedBox.bottomRight, background)
+			}
+			return
+		}
+	}
+
+	chars := squareBox
+	if rounded {
+		chars = roundedBox
+	}
+	borderColor := colorProp(props, "borderColor")
+
+	switch {
+	case x1 == x2 && y1 == y2:
+		c.setRune(x1, y1, chars.horizontal, borderColor)
+	case y1 == y2:
+		// 高さ1の場合は水平線
+		for x := x1; x <= x2; x++ {
+			c.setRune(x, y1, chars.horizontal, borderColor)
+		}
+	case x1 == x2:
+		// 幅1の場合は垂直線
+		for y := y1; y <= y2; y++ {
+			c.setRune(x1, y, chars.vertical, borderColor)
+		}
+	default:
+		for x := x1 + 1; x < x2; x++ {
+			c.setRune(x, y1, chars.horizontal, borderColor)
+			c.setRune(x, y2, chars.horizontal, borderColor)
+		}
+		for y := y1 + 1; y < y2; y++ {
+			c.setRune(x1, y, chars.vertical, borderColor)
+			c.setRune(x2, y, chars.vertical, borderColor)
+		}
+		c.setRune(x1, y1, chars.topLeft, borderColor)
+		c.setRune(x2, y1, chars.topRight, borderColor)
+		c.setRune(x1, y2, chars.bottomLeft, borderColor)
+		c.setRune(x2, y2, chars.bottomRight, borderColor)
+	}
+}
+
+// drawText は textAlign に従ってテキストを1行描画します
+// 書記素クラスタ単位で描画し、全角文字は2セルを占めます
+func (c *canvas) drawText(text string, rect layout.Rect, props core.Props) {
+	x := int(rect.Position.X)
+	y := int(rect.Position.Y)
+	color := colorProp(props, "color")
+
+	x += alignOffset(props.GetString("textAlign", "start"), int(rect.Size.Width), layout.StringWidth(text))
+
+	for _, cluster := range layout.Graphemes(text) {
+		width := layout.GraphemeWidth(cluster)
+		c.setGrapheme(x, y, cluster, width, color)
+		x += width
+	}
+}
+
+// drawScrollbar は表示領域の右端（水平方向の場合は下端）にスクロールバーを描画します
+// thumbStart と thumbLength は表示領域の先頭からのつまみの位置と長さです
+func (c *canvas) drawScrollbar(viewport layout.Rect, vertical bool, thumbStart, thumbLength float64, props core.Props) {
+	x1, y1, x2, y2 := rectBounds(viewport)
+	trackColor := colorProp(props, "scrollbarTrackColor")
+	thumbColor := colorProp(props, "scrollbarColor")
+	start := int(math.Round(thumbStart))
+	end := start + max(int(math.Round(thumbLength)), 1)
+
+	length := x2 - x1 + 1
+	if vertical {
+		length = y2 - y1 + 1
+	}
+	for i := 0; i < length; i++ {
+		ch, color := '│', trackColor
+		if !vertical {
+			ch = '─'
+		}
+		if i >= start && i < end {
+			ch, color = '█', thumbColor
+		}
+		if vertical {
+			c.setRune(x2, y1+i, ch, color)
+		} else {
+			c.setRune(x1+i, y2, ch, color)
+		}
+	}
+}
+
+// alignOffset はテキストの配置に応じた水平方向のずれを返します
+func alignOffset(textAlign string, available, textWidth int) int {
+	space := available - textWidth
+	if space <= 0 {
+		return 0
+	}
+	switch textAlign {
+	case "center":
+		return space / 2
+	case "end", "right":
+		return space
+	default:
+		return 0
+	}
+}
+
+// rectBounds は矩形をセル座標の範囲（両端を含む）に変換します
+func rectBounds(rect layout.Rect) (x1, y1, x2, y2 int) {
+	x1 = int(rect.Position.X)
+	y1 = int(rect.Position.Y)
+	x2 = int(rect.Position.X+rect.Size.Width) - 1
+	y2 = int(rect.Position.Y+rect.Size.Height) - 1
+	return x1, y1, x2, y2
+}
@@ -0,0 +1,167 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tak/goui/core"
+)
+
+// Color は端末に出力する色です
+type Color struct {
+	R, G, B uint8
+	// Valid が false の場合は端末の既定色を使用します
+	Valid bool
+}
+
+// ColorMode は端末の色表現の方式です
+type ColorMode int
+
+const (
+	// TrueColor は 24 ビットカラーのエスケープシーケンスを出力します
+	TrueColor ColorMode = iota
+	// Color256 は xterm の 256 色パレットに近似して出力します
+	Color256
+)
+
+// namedColors はよく使われる色名の対応表です
+var namedColors = map[string]Color{
+	"black":   {R: 0x00, G: 0x00, B: 0x00, Valid: true},
+	"white":   {R: 0xFF, G: 0xFF, B: 0xFF, Valid: true},
+	"red":     {R: 0xFF, G: 0x00, B: 0x00, Valid: true},
+	"green":   {R: 0x00, G: 0x80, B: 0x00, Valid: true},
+	"blue":    {R: 0x00, G: 0x00, B: 0xFF, Valid: true},
+	"yellow":  {R: 0xFF, G: 0xFF, B: 0x00, Valid: true},
+	"cyan":    {R: 0x00, G: 0xFF, B: 0xFF, Valid: true},
+	"magenta": {R: 0xFF, G: 0x00, B: 0xFF, Valid: true},
+	"gray":    {R: 0x80, G: 0x80, B: 0x80, Valid: true},
+	"grey":    {R: 0x80, G: 0x80, B: 0x80, Valid: true},
+}
+
+// ParseColor は "#RGB"、"#RRGGBB"、"#AARRGGBB" 形式または色名の文字列を色に変換します
+// 完全に透明な色や解釈できない文字列の場合は false を返します
+func ParseColor(value string) (Color, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if color, ok := namedColors[value]; ok {
+		return color, true
+	}
+	if !strings.HasPrefix(value, "#") {
+		return Color{}, false
+	}
+
+	hex := value[1:]
+	switch len(hex) {
+	case 3:
+		// #RGB は各桁を2回繰り返して展開する
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	case 6:
+	case 8:
+		// #AARRGGBB のアルファは透明かどうかだけを判定する
+		alpha, err := strconv.ParseUint(hex[:2], 16, 8)
+		if err != nil || alpha == 0 {
+			return Color{}, false
+		}
+		hex = hex[2:]
+	default:
+		return Color{}, false
+	}
+
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+	return Color{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), Valid: true}, true
+}
+
+// colorProp はプロパティから色を取得します
+func colorProp(props core.Props, key string) Color {
+	value, ok := props[key].(string)
+	if !ok {
+		return Color{}
+	}
+	color, _ := ParseColor(value)
+	return color
+}
+
+// Hex は色を "#RRGGBB" 形式の文字列で返します
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// foregroundSequence は文字色を設定するエスケープシーケンスを返します
+func (c Color) foregroundSequence(mode ColorMode) string {
+	if !c.Valid {
+		return "\x1b[39m"
+	}
+	if mode == Color256 {
+		return fmt.Sprintf("\x1b[38;5;%dm", c.to256())
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", c.R, c.G, c.B)
+}
+
+// backgroundSequence は背景色を設定するエスケープシーケンスを返します
+func (c Color) backgroundSequence(mode ColorMode) string {
+	if !c.Valid {
+		return "\x1b[49m"
+	}
+	if mode == Color256 {
+		return fmt.Sprintf("\x1b[48;5;%dm", c.to256())
+	}
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B)
+}
+
+// cubeLevels は xterm の 6x6x6 カラーキューブの各段階の値です
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// to256 は色を xterm の 256 色パレットで最も近い番号に変換します
+func (c Color) to256() int {
+	r, g, b := int(c.R), int(c.G), int(c.B)
+
+	// カラーキューブで最も近い色
+	ri, gi, bi := nearestCubeLevel(r), nearestCubeLevel(g), nearestCubeLevel(b)
+	cubeIndex := 16 + 36*ri + 6*gi + bi
+	cubeDistance := colorDistance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	// グレースケールで最も近い色（232〜255 は 8, 18, ..., 238）
+	average := (r + g + b) / 3
+	grayStep := (average - 8 + 5) / 10
+	if grayStep < 0 {
+		grayStep = 0
+	}
+	if grayStep > 23 {
+		grayStep = 23
+	}
+	grayLevel := 8 + grayStep*10
+	grayDistance := colorDistance(r, g, b, grayLevel, grayLevel, grayLevel)
+
+	if grayDistance < cubeDistance {
+		return 232 + grayStep
+	}
+	return cubeIndex
+}
+
+// nearestCubeLevel はカラーキューブで最も近い段階の番号を返します
+func nearestCubeLevel(value int) int {
+	nearest := 0
+	for i, level := range cubeLevels {
+		if abs(level-value) < abs(cubeLevels[nearest]-value) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+// colorDistance は2つの色の距離の2乗を返します
+func colorDistance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return dr*dr + dg*dg + db*db
+}
+
+// abs は整数の絶対値を返します
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
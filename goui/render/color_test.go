@@ -0,0 +1,89 @@
+package render
+
+import (
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  Color
+		ok    bool
+	}{
+		{"short hex", "#fff", Color{R: 0xFF, G: 0xFF, B: 0xFF, Valid: true}, true},
+		{"short hex digits", "#1a2", Color{R: 0x11, G: 0xAA, B: 0x22, Valid: true}, true},
+		{"long hex", "#1A2B3C", Color{R: 0x1A, G: 0x2B, B: 0x3C, Valid: true}, true},
+		{"opaque alpha", "#FF1A2B3C", Color{R: 0x1A, G: 0x2B, B: 0x3C, Valid: true}, true},
+		{"translucent alpha", "#801A2B3C", Color{R: 0x1A, G: 0x2B, B: 0x3C, Valid: true}, true},
+		{"transparent", "#001A2B3C", Color{}, false},
+		{"named", "red", Color{R: 0xFF, Valid: true}, true},
+		{"named with case and spaces", "  Gray ", Color{R: 0x80, G: 0x80, B: 0x80, Valid: true}, true},
+		{"unknown name", "purple", Color{}, false},
+		{"missing hash", "123456", Color{}, false},
+		{"wrong length", "#12345", Color{}, false},
+		{"invalid digits", "#ggg", Color{}, false},
+		{"invalid alpha", "#zz123456", Color{}, false},
+		{"empty", "", Color{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseColor(tt.value)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ParseColor(%q) = %+v, %v, want %+v, %v", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestColorTo256(t *testing.T) {
+	tests := []struct {
+		name  string
+		color Color
+		want  int
+	}{
+		{"black", Color{Valid: true}, 16},
+		{"white", Color{R: 0xFF, G: 0xFF, B: 0xFF, Valid: true}, 231},
+		{"red", Color{R: 0xFF, Valid: true}, 196},
+		{"exact cube color", Color{R: 0x5F, G: 0x87, B: 0xAF, Valid: true}, 67},
+		{"nearest cube color", Color{R: 0xF0, G: 0x10, B: 0x10, Valid: true}, 196},
+		{"darkest gray", Color{R: 0x08, G: 0x08, B: 0x08, Valid: true}, 232},
+		{"middle gray", Color{R: 0x80, G: 0x80, B: 0x80, Valid: true}, 244},
+		{"lightest gray", Color{R: 0xEE, G: 0xEE, B: 0xEE, Valid: true}, 255},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.color.to256(); got != tt.want {
+				t.Errorf("to256 = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorSequences(t *testing.T) {
+	color := Color{R: 1, G: 2, B: 3, Valid: true}
+	red := Color{R: 0xFF, Valid: true}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"true color foreground", color.foregroundSequence(TrueColor), "\x1b[38;2;1;2;3m"},
+		{"true color background", color.backgroundSequence(TrueColor), "\x1b[48;2;1;2;3m"},
+		{"256 color foreground", red.foregroundSequence(Color256), "\x1b[38;5;196m"},
+		{"256 color background", red.backgroundSequence(Color256), "\x1b[48;5;196m"},
+		{"default foreground", Color{}.foregroundSequence(TrueColor), "\x1b[39m"},
+		{"default background", Color{}.backgroundSequence(Color256), "\x1b[49m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("sequence = %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+// DiffRenderTarget は前回の出力との差分だけを端末に書き込むダブルバッファのターゲットです
+//
+// 描画は裏画面に対して行われ、Flush で表画面（端末に表示済みの内容）と比較して
+// 変化したセルだけをカーソル移動のエスケープシーケンスとともに出力します。
+// 色の指定と罫線の描画は AnsiRenderTarget と同じです。
+type DiffRenderTarget struct {
+	writer io.Writer
+	mode   ColorMode
+	front  *canvas
+	back   *canvas
+
+	fullRepaint       bool
+	cellsWritten      int
+	totalCellsWritten int
+	err               error
+}
+
+// NewDiffRenderTarget は新しい差分出力のレンダリングターゲットを作成します
+// 最初の Flush では画面を消去してから描画します
+func NewDiffRenderTarget(writer io.Writer, width, height int, mode ColorMode) *DiffRenderTarget {
+	return &DiffRenderTarget{
+		writer:      writer,
+		mode:        mode,
+		front:       newCanvas(width, height),
+		back:        newCanvas(width, height),
+		fullRepaint: true,
+	}
+}
+
+// TextMeasurer は端末のセル単位でテキストを計測する TerminalTextMeasurer を返します
+func (d *DiffRenderTarget) TextMeasurer() layout.TextMeasurer {
+	return layout.TerminalTextMeasurer{}
+}
+
+// Clear は裏画面をクリアします
+func (d *DiffRenderTarget) Clear() {
+	d.back.clear()
+}
+
+// DrawRect は裏画面に矩形を描画します
+func (d *DiffRenderTarget) DrawRect(rect layout.Rect, props core.Props) {
+	d.back.drawRect(rect, props)
+}
+
+// DrawText は裏画面にテキストを描画します
+func (d *DiffRenderTarget) DrawText(text string, rect layout.Rect, props core.Props) {
+	d.back.drawText(text, rect, props)
+}
+
+// PushClip は以降の描画を rect の内側に制限します
+func (d *DiffRenderTarget) PushClip(rect layout.Rect) {
+	d.back.clips.push(rect)
+}
+
+// PopClip は直前の PushClip で設定したクリップ領域を解除します
+func (d *DiffRenderTarget) PopClip() {
+	d.back.clips.pop()
+}
+
+// DrawScrollbar は裏画面にスクロールバーを描画します
+func (d *DiffRenderTarget) DrawScrollbar(viewport layout.Rect, vertical bool, thumbStart, thumbLength float64, props core.Props) {
+	d.back.drawScrollbar(viewport, vertical, thumbStart, thumbLength, props)
+}
+
+// Flush は表画面と異なるセルだけを端末に出力し、裏画面の内容を表画面に反映します
+// 端末への書き込みに失敗した場合は、次の Flush で画面全体を描き直します
+func (d *DiffRenderTarget) Flush() {
+	var builder strings.Builder
+	if d.fullRepaint {
+		// 画面を消去して表画面を空の状態として扱う
+		builder.WriteString("\x1b[H\x1b[2J")
+		d.front.clear()
+		d.fullRepaint = false
+	}
+
+	state := sgrState{mode: d.mode}
+	cursorX, cursorY := -1, -1
+	written := 0
+	for y := 0; y < d.back.height; y++ {
+		for x := 0; x < d.back.width; x++ {
+			c := d.back.cells[y][x]
+			if c.isContinuation() {
+				// 全角文字の右半分は左半分と一緒に出力する
+				d.front.cells[y][x] = c
+				continue
+			}
+
+			// 全角文字は続きのセルも含めて変化を判定する
+			width := 1
+			for x+width < d.back.width && d.back.cells[y][x+width].isContinuation() {
+				width++
+			}
+			if !d.changed(x, y, width) {
+				continue
+			}
+
+			// 直前に書き込んだセルの隣でなければカーソルを移動する
+			if x != cursorX || y != cursorY {
+				builder.WriteString(fmt.Sprintf("\x1b[%d;%dH", y+1, x+1))
+			}
+			state.writeCell(&builder, c)
+			d.front.cells[y][x] = c
+			cursorX, cursorY = x+width, y
+			written++
+		}
+	}
+	state.reset(&builder)
+
+	if written > 0 {
+		// カーソルを描画領域の下に移動しておく
+		builder.WriteString(fmt.Sprintf("\x1b[%d;1H", d.back.height+1))
+	}
+
+	d.cellsWritten = written
+	d.totalCellsWritten += written
+	if builder.Len() > 0 {
+		_, d.err = io.WriteString(d.writer, builder.String())
+		if d.err != nil {
+			// 端末の内容が表画面と一致しているとは限らないため、次の Flush で画面全体を描き直す
+			d.fullRepaint = true
+		}
+	}
+}
+
+// changed は x から width セル分のいずれかが表画面と異なるかを判定します
+func (d *DiffRenderTarget) changed(x, y, width int) bool {
+	for i := x; i < x+width; i++ {
+		if d.back.cells[y][i] != d.front.cells[y][i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Invalidate は次の Flush で画面全体を再描画させます
+// 端末の内容が外部から書き換えられた場合などに使用します
+func (d *DiffRenderTarget) Invalidate() {
+	d.fullRepaint = true
+}
+
+// CellsWritten は直前の Flush で書き込んだセルの数を返します
+func (d *DiffRenderTarget) CellsWritten() int {
+	return d.cellsWritten
+}
+
+// TotalCellsWritten はこれまでの Flush で書き込んだセルの総数を返します
+func (d *DiffRenderTarget) TotalCellsWritten() int {
+	return d.totalCellsWritten
+}
+
+// Err は直前の Flush で発生した書き込みエラーを返します
+func (d *DiffRenderTarget) Err() error {
+	return d.err
+}
@@ -0,0 +1,172 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+// textAt は (x, y) から始まる1行分の矩形です
+func textAt(x, y, width float64) layout.Rect {
+	return layout.Rect{
+		Position: layout.Position{X: x, Y: y},
+		Size:     layout.Size{Width: width, Height: 1},
+	}
+}
+
+func TestDiffRenderTargetCellsWritten(t *testing.T) {
+	type draw struct {
+		text  string
+		x, y  float64
+		props core.Props
+	}
+	tests := []struct {
+		name   string
+		frames [][]draw
+		want   []int
+	}{
+		{
+			name:   "first frame writes only non-blank cells",
+			frames: [][]draw{{{text: "hello", x: 0, y: 0}}},
+			want:   []int{5},
+		},
+		{
+			name: "unchanged frame writes nothing",
+			frames: [][]draw{
+				{{text: "hello", x: 0, y: 0}},
+				{{text: "hello", x: 0, y: 0}},
+			},
+			want: []int{5, 0},
+		},
+		{
+			name: "single changed cell",
+			frames: [][]draw{
+				{{text: "hello", x: 0, y: 0}},
+				{{text: "hallo", x: 0, y: 0}},
+			},
+			want: []int{5, 1},
+		},
+		{
+			name: "moved text rewrites old and new cells",
+			frames: [][]draw{
+				{{text: "ab", x: 0, y: 0}},
+				{{text: "ab", x: 0, y: 1}},
+			},
+			want: []int{2, 4},
+		},
+		{
+			name: "color change counts as a change",
+			frames: [][]draw{
+				{{text: "ab", x: 0, y: 0}},
+				{{text: "ab", x: 0, y: 0, props: core.Props{"color": "red"}}},
+			},
+			want: []int{2, 2},
+		},
+		{
+			name: "wide character is written as one cell",
+			frames: [][]draw{
+				{{text: "日本", x: 0, y: 0}},
+				{{text: "日木", x: 0, y: 0}},
+			},
+			want: []int{2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			target := NewDiffRenderTarget(&out, 10, 3, TrueColor)
+			total := 0
+			for i, frame := range tt.frames {
+				target.Clear()
+				for _, d := range frame {
+					target.DrawText(d.text, textAt(d.x, d.y, 10), d.props)
+				}
+				target.Flush()
+				if got := target.CellsWritten(); got != tt.want[i] {
+					t.Errorf("frame %d: CellsWritten = %d, want %d", i, got, tt.want[i])
+				}
+				total += tt.want[i]
+			}
+			if got := target.TotalCellsWritten(); got != total {
+				t.Errorf("TotalCellsWritten = %d, want %d", got, total)
+			}
+		})
+	}
+}
+
+func TestDiffRenderTargetInvalidateRepaintsScreen(t *testing.T) {
+	var out bytes.Buffer
+	target := NewDiffRenderTarget(&out, 10, 2, TrueColor)
+	target.DrawText("hi", textAt(0, 0, 10), nil)
+	target.Flush()
+	if !strings.HasPrefix(out.String(), "\x1b[H\x1b[2J") {
+		t.Errorf("first flush does not clear the screen: %q", out.String())
+	}
+
+	out.Reset()
+	target.Invalidate()
+	target.Clear()
+	target.DrawText("hi", textAt(0, 0, 10), nil)
+	target.Flush()
+	if !strings.HasPrefix(out.String(), "\x1b[H\x1b[2J") {
+		t.Errorf("flush after Invalidate does not clear the screen: %q", out.String())
+	}
+	if got := target.CellsWritten(); got != 2 {
+		t.Errorf("CellsWritten after Invalidate = %d, want 2", got)
+	}
+
+	out.Reset()
+	target.Clear()
+	target.DrawText("hi", textAt(0, 0, 10), nil)
+	target.Flush()
+	if out.Len() != 0 {
+		t.Errorf("unchanged frame wrote %q", out.String())
+	}
+}
+
+// failingWriter は書き込みに失敗する io.Writer です
+type failingWriter struct {
+	fail bool
+	out  bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("write failed")
+	}
+	return w.out.Write(p)
+}
+
+func TestDiffRenderTargetRepaintsAfterWriteError(t *testing.T) {
+	writer := &failingWriter{}
+	target := NewDiffRenderTarget(writer, 10, 2, TrueColor)
+	target.DrawText("hi", textAt(0, 0, 10), nil)
+	target.Flush()
+
+	// 書き込みに失敗した Flush の内容は端末に届いていない
+	writer.fail = true
+	target.Clear()
+	target.DrawText("ok", textAt(0, 0, 10), nil)
+	target.Flush()
+	if target.Err() == nil {
+		t.Fatal("Err after a failed write = nil")
+	}
+
+	// 次の Flush は変化がなくても画面全体を描き直す
+	writer.fail = false
+	writer.out.Reset()
+	target.Clear()
+	target.DrawText("ok", textAt(0, 0, 10), nil)
+	target.Flush()
+	if target.Err() != nil {
+		t.Errorf("Err after a successful write = %v", target.Err())
+	}
+	if got := writer.out.String(); !strings.HasPrefix(got, "\x1b[H\x1b[2J") || !strings.Contains(got, "ok") {
+		t.Errorf("flush after a failed write = %q, want a full repaint", got)
+	}
+}
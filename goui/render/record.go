@@ -0,0 +1,276 @@
+package render
+
+import (
+	"math"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+// DisplayOpKind は描画命令の種類です
+type DisplayOpKind string
+
+const (
+	// OpDrawRect は DrawRect の呼び出しです
+	OpDrawRect DisplayOpKind = "drawRect"
+	// OpDrawText は DrawText の呼び出しです
+	OpDrawText DisplayOpKind = "drawText"
+	// OpPushClip は PushClip の呼び出しです
+	OpPushClip DisplayOpKind = "pushClip"
+	// OpPopClip は PopClip の呼び出しです
+	OpPopClip DisplayOpKind = "popClip"
+	// OpDrawScrollbar は DrawScrollbar の呼び出しです
+	OpDrawScrollbar DisplayOpKind = "drawScrollbar"
+	// OpBeginNode はノードとその子孫の描画の開始です
+	OpBeginNode DisplayOpKind = "beginNode"
+	// OpEndNode はノードとその子孫の描画の終了です
+	OpEndNode DisplayOpKind = "endNode"
+)
+
+// DisplayOp は記録された1つの描画命令です
+type DisplayOp struct {
+	// Kind は命令の種類です
+	Kind DisplayOpKind `json:"kind"`
+	// NodeID は命令を発行したノードの ID です（ノードの外で発行された場合は空文字列）
+	NodeID core.NodeID `json:"nodeId,omitempty"`
+	// Rect は描画する矩形（スクロールバーの場合は表示領域、クリップの場合はクリップ領域）です
+	Rect layout.Rect `json:"rect"`
+	// Text は OpDrawText で描画するテキストです
+	Text string `json:"text,omitempty"`
+	// Props は描画に使用するプロパティです（関数などの記録できない値は除かれます）
+	Props core.Props `json:"props,omitempty"`
+	// Vertical は OpDrawScrollbar のスクロール方向です
+	Vertical bool `json:"vertical,omitempty"`
+	// ThumbStart は OpDrawScrollbar のつまみの位置です
+	ThumbStart float64 `json:"thumbStart,omitempty"`
+	// ThumbLength は OpDrawScrollbar のつまみの長さです
+	ThumbLength float64 `json:"thumbLength,omitempty"`
+}
+
+// DisplayList は1回のレンダリングで記録された描画命令の列です
+//
+// encoding/json でそのまま保存・読み込みできます。
+// 読み込んだプロパティの数値はすべて float64 になります。
+// JSON は無限大を表せないため、記録するときに矩形や数値の ±Inf は ±math.MaxFloat64 に、
+// NaN は 0 に置き換えられます。
+type DisplayList struct {
+	// Ops は描画された順の命令です
+	Ops []DisplayOp `json:"ops"`
+}
+
+// Subtree は id のノードとその子孫が発行した命令を、開始と終了の命令を含めて返します
+//
+// 祖先のノードが設定したクリップ領域は、そのクリップ領域の設定を先頭に、解除を末尾に加えて引き継ぎます。
+// ノードが描画されていなければ空のリストを返します
+func (l DisplayList) Subtree(id core.NodeID) DisplayList {
+	var clips []DisplayOp
+	for start, op := range l.Ops {
+		switch op.Kind {
+		case OpPushClip:
+			clips = append(clips, op)
+			continue
+		case OpPopClip:
+			if len(clips) > 0 {
+				clips = clips[:len(clips)-1]
+			}
+			continue
+		}
+		if op.Kind != OpBeginNode || op.NodeID != id {
+			continue
+		}
+		for end := start + 1; end < len(l.Ops); end++ {
+			if l.Ops[end].Kind != OpEndNode || l.Ops[end].NodeID != id {
+				continue
+			}
+			ops := make([]DisplayOp, 0, len(clips)*2+end+1-start)
+			ops = append(ops, clips...)
+			ops = append(ops, l.Ops[start:end+1]...)
+			for i := len(clips) - 1; i >= 0; i-- {
+				ops = append(ops, DisplayOp{Kind: OpPopClip, NodeID: clips[i].NodeID})
+			}
+			return DisplayList{Ops: ops}
+		}
+	}
+	return DisplayList{}
+}
+
+// NodeTrackingTarget は描画中のノードを知る必要があるレンダリングターゲットです
+// Renderer は各ノードの描画の前後で BeginNode と EndNode を呼び出します
+type NodeTrackingTarget interface {
+	RenderTarget
+
+	// BeginNode は id のノードとその子孫の描画を開始するときに呼ばれます
+	BeginNode(id core.NodeID)
+
+	// EndNode は id のノードとその子孫の描画が終わったときに呼ばれます
+	EndNode(id core.NodeID)
+}
+
+// RecordingRenderTarget は描画の呼び出しを DisplayList に記録するレンダリングターゲットです
+//
+// Clear で記録を始め、Flush で記録したリストを確定します。
+// 確定したリストは DisplayList で取得し、Replay で別のターゲットに描画できます。
+type RecordingRenderTarget struct {
+	measurer layout.TextMeasurer
+	ops      []DisplayOp
+	nodes    []core.NodeID
+	list     DisplayList
+}
+
+// NewRecordingRenderTarget は新しい記録用のレンダリングターゲットを作成します
+// measurer には再生先のターゲットと同じテキストの計測方法を指定します（nil の場合は既定の計測方法）
+func NewRecordingRenderTarget(measurer layout.TextMeasurer) *RecordingRenderTarget {
+	return &RecordingRenderTarget{measurer: measurer}
+}
+
+// TextMeasurer はレイアウト計算に使用するテキストの計測方法を返します
+func (r *RecordingRenderTarget) TextMeasurer() layout.TextMeasurer {
+	return r.measurer
+}
+
+// Clear は記録中の命令を破棄して新しい記録を始めます
+func (r *RecordingRenderTarget) Clear() {
+	r.ops = nil
+	r.nodes = nil
+}
+
+// DrawRect は矩形の描画を記録します
+func (r *RecordingRenderTarget) DrawRect(rect layout.Rect, props core.Props) {
+	r.record(DisplayOp{Kind: OpDrawRect, Rect: rect, Props: recordableProps(props)})
+}
+
+// DrawText はテキストの描画を記録します
+func (r *RecordingRenderTarget) DrawText(text string, rect layout.Rect, props core.Props) {
+	r.record(DisplayOp{Kind: OpDrawText, Rect: rect, Text: text, Props: recordableProps(props)})
+}
+
+// PushClip はクリップ領域の設定を記録します
+func (r *RecordingRenderTarget) PushClip(rect layout.Rect) {
+	r.record(DisplayOp{Kind: OpPushClip, Rect: rect})
+}
+
+// PopClip はクリップ領域の解除を記録します
+func (r *RecordingRenderTarget) PopClip() {
+	r.record(DisplayOp{Kind: OpPopClip})
+}
+
+// DrawScrollbar はスクロールバーの描画を記録します
+func (r *RecordingRenderTarget) DrawScrollbar(viewport layout.Rect, vertical bool, thumbStart, thumbLength float64, props core.Props) {
+	r.record(DisplayOp{
+		Kind:        OpDrawScrollbar,
+		Rect:        viewport,
+		Props:       recordableProps(props),
+		Vertical:    vertical,
+		ThumbStart:  thumbStart,
+		ThumbLength: thumbLength,
+	})
+}
+
+// BeginNode はノードの描画の開始を記録します
+func (r *RecordingRenderTarget) BeginNode(id core.NodeID) {
+	r.nodes = append(r.nodes, id)
+	r.record(DisplayOp{Kind: OpBeginNode})
+}
+
+// EndNode はノードの描画の終了を記録します
+func (r *RecordingRenderTarget) EndNode(id core.NodeID) {
+	r.record(DisplayOp{Kind: OpEndNode, NodeID: id})
+	if len(r.nodes) > 0 {
+		r.nodes = r.nodes[:len(r.nodes)-1]
+	}
+}
+
+// Flush は記録中の命令を確定します
+func (r *RecordingRenderTarget) Flush() {
+	r.list = DisplayList{Ops: r.ops}
+	r.ops = nil
+}
+
+// DisplayList は直前の Flush で確定した描画命令のリストを返します
+func (r *RecordingRenderTarget) DisplayList() DisplayList {
+	return DisplayList{Ops: append([]DisplayOp(nil), r.list.Ops...)}
+}
+
+// record は描画中のノードの ID を付けて命令を追加します
+func (r *RecordingRenderTarget) record(op DisplayOp) {
+	if op.NodeID == "" && len(r.nodes) > 0 {
+		op.NodeID = r.nodes[len(r.nodes)-1]
+	}
+	op.Rect = layout.Rect{
+		Position: layout.Position{X: finite(op.Rect.Position.X), Y: finite(op.Rect.Position.Y)},
+		Size:     layout.Size{Width: finite(op.Rect.Size.Width), Height: finite(op.Rect.Size.Height)},
+	}
+	op.ThumbStart = finite(op.ThumbStart)
+	op.ThumbLength = finite(op.ThumbLength)
+	r.ops = append(r.ops, op)
+}
+
+// finite は JSON で表せるように ±Inf を ±math.MaxFloat64 に、NaN を 0 に置き換えます
+func finite(value float64) float64 {
+	switch {
+	case math.IsNaN(value):
+		return 0
+	case math.IsInf(value, 1):
+		return math.MaxFloat64
+	case math.IsInf(value, -1):
+		return -math.MaxFloat64
+	}
+	return value
+}
+
+// recordableProps はプロパティのうち文字列・真偽値・数値だけを複製します
+// モディファイアや関数などは保存も比較もできないため記録しません
+func recordableProps(props core.Props) core.Props {
+	if len(props) == 0 {
+		return nil
+	}
+	result := make(core.Props, len(props))
+	for key, value := range props {
+		switch v := value.(type) {
+		case string, bool, int, int32, int64:
+			result[key] = value
+		case float32:
+			result[key] = float32(finite(float64(v)))
+		case float64:
+			result[key] = finite(v)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Replay は記録された描画命令を順に target に描画します
+//
+// target の Clear と Flush は呼び出さないため、フレーム全体を再生する場合は前後で呼び出します。
+// 変更のない部分木の Subtree を再生すれば、フレームの一部としてキャッシュした描画を再利用できます。
+// スクロールバーとノードの開始・終了の命令は、target が対応している場合だけ再生されます。
+func Replay(list DisplayList, target RenderTarget) {
+	scrollbars, _ := target.(ScrollbarTarget)
+	tracking, _ := target.(NodeTrackingTarget)
+	for _, op := range list.Ops {
+		switch op.Kind {
+		case OpDrawRect:
+			target.DrawRect(op.Rect, op.Props)
+		case OpDrawText:
+			target.DrawText(op.Text, op.Rect, op.Props)
+		case OpPushClip:
+			target.PushClip(op.Rect)
+		case OpPopClip:
+			target.PopClip()
+		case OpDrawScrollbar:
+			if scrollbars != nil {
+				scrollbars.DrawScrollbar(op.Rect, op.Vertical, op.ThumbStart, op.ThumbLength, op.Props)
+			}
+		case OpBeginNode:
+			if tracking != nil {
+				tracking.BeginNode(op.NodeID)
+			}
+		case OpEndNode:
+			if tracking != nil {
+				tracking.EndNode(op.NodeID)
+			}
+		}
+	}
+}
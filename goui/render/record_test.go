@@ -0,0 +1,176 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+// sampleTree は色、枠線、クリップ、スクロールバーを含む描画用のツリーを作成します
+func sampleTree() *core.Node {
+	root := core.NewNode(core.ColumnNodeType, "root", core.Props{})
+	title := textNode("title", "タイトル", core.Props{
+		"color":          "#FF0000",
+		"textAlign":      "center",
+		core.ModifierKey: core.NewModifier().FillMaxWidth(1).Background("#202020"),
+	})
+	card := core.NewNode(core.BoxNodeType, "card", core.Props{
+		"width":           10.0,
+		"height":          3.0,
+		"backgroundColor": "#0000FF",
+		"borderWidth":     1.0,
+		"borderColor":     "#FFFFFF",
+		"padding":         1.0,
+	})
+	card.AddChild(overflow(4, core.Props{"clipToBounds": true}, textNode("clipped", "abcdefgh", core.Props{"color": "#00FF00"})))
+	scroll := core.NewNode(core.VerticalScrollNodeType, "scroll", core.Props{
+		"state":          layout.NewScrollState(1),
+		core.ModifierKey: core.NewModifier().Height(2).FillMaxWidth(1),
+	})
+	for _, line := range []string{"one", "two", "three", "four"} {
+		scroll.AddChild(textNode(line, line, nil))
+	}
+	root.AddChild(title)
+	root.AddChild(card)
+	root.AddChild(scroll)
+	return root
+}
+
+// renderAnsi はツリーを ANSI のターゲットに直接描画した出力を返します
+func renderAnsi(t *testing.T, root *core.Node) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	NewRenderer(NewAnsiRenderTarget(&out, 12, 7, TrueColor)).Render(root, layout.NewConstraints(0, 0, 12, 7))
+	return out.Bytes()
+}
+
+// replayAnsi は描画命令のリストを ANSI のターゲットに再生した出力を返します
+func replayAnsi(list DisplayList) []byte {
+	var out bytes.Buffer
+	target := NewAnsiRenderTarget(&out, 12, 7, TrueColor)
+	target.Clear()
+	Replay(list, target)
+	target.Flush()
+	return out.Bytes()
+}
+
+// record はツリーを記録用のターゲットに描画した命令のリストを返します
+func record(root *core.Node) DisplayList {
+	recorder := NewRecordingRenderTarget(layout.TerminalTextMeasurer{})
+	NewRenderer(recorder).Render(root, layout.NewConstraints(0, 0, 12, 7))
+	return recorder.DisplayList()
+}
+
+func TestDisplayListJSONRoundTrip(t *testing.T) {
+	want := renderAnsi(t, sampleTree())
+	list := record(sampleTree())
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded DisplayList
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(decoded.Ops) != len(list.Ops) {
+		t.Fatalf("decoded %d ops, want %d", len(decoded.Ops), len(list.Ops))
+	}
+
+	if got := replayAnsi(decoded); !bytes.Equal(got, want) {
+		t.Errorf("replayed output differs from direct rendering\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestDisplayListSubtreeKeepsAncestorClips(t *testing.T) {
+	root := row(
+		overflow(3, core.Props{"clipToBounds": true},
+			core.NewNode(core.ColumnNodeType, "inner", core.Props{}),
+		),
+	)
+	root.Children[0].Children[0].AddChild(textNode("label", "abcdef", nil))
+	list := record(root)
+
+	subtree := list.Subtree("row#0/overflow#0/inner#0/label#0")
+	kinds := []DisplayOpKind{}
+	for _, op := range subtree.Ops {
+		kinds = append(kinds, op.Kind)
+	}
+	want := []DisplayOpKind{OpPushClip, OpBeginNode, OpDrawText, OpEndNode, OpPopClip}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("subtree ops = %v, want %v", kinds, want)
+	}
+
+	// 部分木だけを再生しても祖先のクリップ領域が適用される
+	target := NewConsoleRenderTarget(6, 1)
+	target.Clear()
+	Replay(subtree, target)
+	if got := consoleLines(target)[0]; got != "abc   " {
+		t.Errorf("replayed subtree = %q, want %q", got, "abc   ")
+	}
+	if len(target.clips) != 0 {
+		t.Errorf("clip stack has %d entries after replay", len(target.clips))
+	}
+}
+
+func TestDisplayListSubtree(t *testing.T) {
+	list := record(sampleTree())
+	tests := []struct {
+		name     string
+		id       core.NodeID
+		wantText []string
+	}{
+		{"leaf", "root#0/title#0", []string{"タイトル"}},
+		{"container", "root#0/scroll#2", []string{"one", "two", "three", "four"}},
+		{"missing", "root#0/missing#9", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var texts []string
+			for _, op := range list.Subtree(tt.id).Ops {
+				if op.Kind == OpDrawText {
+					texts = append(texts, op.Text)
+				}
+			}
+			if !reflect.DeepEqual(texts, tt.wantText) {
+				t.Errorf("texts = %q, want %q", texts, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestRecordingSanitizesNonFiniteValues(t *testing.T) {
+	recorder := NewRecordingRenderTarget(nil)
+	recorder.Clear()
+	recorder.PushClip(layout.Rect{Size: layout.Size{Width: math.Inf(1), Height: math.Inf(1)}})
+	recorder.DrawRect(layout.Rect{Position: layout.Position{X: math.Inf(-1), Y: math.NaN()}}, core.Props{"borderWidth": math.Inf(1)})
+	recorder.PopClip()
+	recorder.Flush()
+
+	data, err := json.Marshal(recorder.DisplayList())
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded DisplayList
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	clip := decoded.Ops[0].Rect
+	if clip.Size.Width != math.MaxFloat64 || clip.Size.Height != math.MaxFloat64 {
+		t.Errorf("clip size = %v, want MaxFloat64", clip.Size)
+	}
+	rect := decoded.Ops[1]
+	if rect.Rect.Position.X != -math.MaxFloat64 || rect.Rect.Position.Y != 0 {
+		t.Errorf("rect position = %v, want (-MaxFloat64, 0)", rect.Rect.Position)
+	}
+	if got := rect.Props.GetFloat("borderWidth", 0); got != math.MaxFloat64 {
+		t.Errorf("borderWidth = %v, want MaxFloat64", got)
+	}
+}
@@ -1,6 +1,10 @@
 package render
 
 import (
+	"math"
+	"sort"
+	"strings"
+
 	"github.com/tak/goui/core"
 	"github.com/tak/goui/layout"
 )
@@ -16,80 +20,289 @@ type RenderTarget interface {
 	// DrawText はテキストを描画します
 	DrawText(text string, rect layout.Rect, props core.Props)
 	
+	// PushClip は以降の描画を rect（と外側のクリップ領域の共通部分）の内側に制限します
+	// クリップ領域は入れ子にでき、Clear で解除されます
+	PushClip(rect layout.Rect)
+	
+	// PopClip は直前の PushClip で設定したクリップ領域を解除します
+	PopClip()
+	
 	// Flush はレンダリング結果を出力します
 	Flush()
 }
 
+// TextMeasuringTarget はテキストの計測方法を指定するレンダリングターゲットです
+// 端末のようにセル単位で描画するターゲットは、描画と同じ単位でレイアウトを計算させるために実装します
+type TextMeasuringTarget interface {
+	RenderTarget
+	
+	// TextMeasurer はレイアウト計算に使用するテキストの計測方法を返します
+	TextMeasurer() layout.TextMeasurer
+}
+
+// ScrollbarTarget はスクロールコンテナのスクロールバーを描画できるレンダリングターゲットです
+type ScrollbarTarget interface {
+	RenderTarget
+	
+	// DrawScrollbar は viewport の端にスクロールバーを描画します
+	// thumbStart と thumbLength は表示領域の主軸方向の先頭からのつまみの位置と長さです
+	DrawScrollbar(viewport layout.Rect, vertical bool, thumbStart, thumbLength float64, props core.Props)
+}
+
 // Renderer はUIツリーのレンダリングを担当します
 type Renderer struct {
 	target RenderTarget
 	layoutManager *layout.LayoutManager
+	clickables []clickRegion
+	// clips はクリック可能な領域を切り取るための現在のクリップ領域です
+	clips []layout.Rect
+}
+
+// clickRegion はクリック可能な領域です
+type clickRegion struct {
+	rect    layout.Rect
+	onClick func()
 }
 
 // NewRenderer は新しいレンダラーを作成します
+// ターゲットが TextMeasuringTarget を実装していれば、その計測方法でテキストのサイズを計算します
 func NewRenderer(target RenderTarget) *Renderer {
+	layoutManager := layout.NewLayoutManager()
+	if measuring, ok := target.(TextMeasuringTarget); ok {
+		layoutManager.SetTextMeasurer(measuring.TextMeasurer())
+	}
+	
 	return &Renderer{
 		target: target,
-		layoutManager: layout.NewLayoutManager(),
+		layoutManager: layoutManager,
 	}
 }
 
+// LayoutManager はレイアウト計算に使用するレイアウトマネージャーを返します
+// 独自のノードタイプに MeasurePolicy を登録する場合などに使用します
+func (r *Renderer) LayoutManager() *layout.LayoutManager {
+	return r.layoutManager
+}
+
 // Render はUIツリーをレンダリングします
 func (r *Renderer) Render(root *core.Node, constraints layout.Constraints) {
 	// レイアウト計算
 	layoutResult := r.layoutManager.CalculateLayout(root, constraints)
 	
-	// レンダリング領域をクリア
+	// レンダリング領域とクリック可能な領域をクリア
 	r.target.Clear()
+	r.clickables = nil
+	r.clips = nil
 	
 	// ノードツリーを再帰的にレンダリング
-	r.renderNode(root, layoutResult)
+	r.renderNode(root, core.RootNodeID(root), layoutResult)
 	
 	// レンダリング結果を出力
 	r.target.Flush()
 }
 
 // renderNode は単一ノードとその子をレンダリングします
-func (r *Renderer) renderNode(node *core.Node, layout map[string]layout.Rect) {
+func (r *Renderer) renderNode(node *core.Node, id core.NodeID, layoutResult map[core.NodeID]layout.Rect) {
 	// ノードのレイアウト情報を取得
-	rect, exists := layout[node.Key]
+	rect, exists := layoutResult[id]
 	if !exists {
 		return
 	}
 	
+	// ノードを追跡するターゲットには描画の範囲を通知する
+	if tracking, ok := r.target.(NodeTrackingTarget); ok {
+		tracking.BeginNode(id)
+		defer tracking.EndNode(id)
+	}
+
+	// clipToBounds が指定されたノードは、自身と子孫の描画をノードの矩形の内側に制限する
+	if node.Props.GetBool("clipToBounds", false) {
+		r.pushClip(rect)
+		defer r.popClip()
+	}
+	
+	// 描画用のモディファイアを処理し、コンテンツの矩形を求める
+	rect = r.drawModifiers(node.Modifier().Elements(), rect)
+	
 	// ノードタイプに基づいてレンダリング
 	switch node.Type {
 	case core.TextNodeType:
-		text := node.Props.GetString("text", "")
-		r.target.DrawText(text, rect, node.Props)
+		// テキストは padding の内側に描画する
+		r.drawText(node, rect.Deflate(layout.EdgeInsetsFromProps(node.Props, "padding")))
 		
 	case core.BoxNodeType:
 		// ボックスの背景を描画
 		r.target.DrawRect(rect, node.Props)
 		
 		// 子ノードをレンダリング
-		for _, child := range node.Children {
-			r.renderNode(child, layout)
-		}
+		r.renderChildren(node, id, layoutResult)
 		
-	case core.RowNodeType, core.ColumnNodeType, core.ContainerNodeType:
+	case core.RowNodeType, core.ColumnNodeType, core.ContainerNodeType, core.LayoutNodeType,
+		core.FlowRowNodeType, core.FlowColumnNodeType, core.GridNodeType, core.ConstraintLayoutNodeType,
+		core.LazyColumnNodeType, core.LazyRowNodeType:
 		// コンテナタイプのノードは自身は描画せず、子ノードのみレンダリング
-		for _, child := range node.Children {
-			r.renderNode(child, layout)
+		r.renderChildren(node, id, layoutResult)
+		
+	case core.VerticalScrollNodeType, core.HorizontalScrollNodeType:
+		// 子ノードは表示領域の内側だけに描画し、スクロールバーを重ねる
+		viewport := rect.Deflate(layout.EdgeInsetsFromProps(node.Props, "padding"))
+		r.pushClip(viewport)
+		r.renderChildren(node, id, layoutResult)
+		r.popClip()
+		if node.Props.GetBool("scrollbar", true) {
+			r.drawScrollbar(node, id, viewport, layoutResult)
 		}
 		
 	case core.CustomNodeType:
-		// カスタムノードの場合、コンポーネントがあればそのレンダリング結果を使用
-		if node.Component != nil {
-			renderedNode := node.Component.Render(node.Props)
-			r.renderNode(renderedNode, layout)
+		// カスタムノードの場合、未構成のコンポーネントがあればそのレンダリング結果を使用
+		if node.Component != nil && len(node.Children) == 0 {
+			if renderedNode := node.Component.Render(node.Props); renderedNode != nil {
+				r.renderNode(renderedNode, core.ChildNodeID(id, renderedNode.Key, 0), layoutResult)
+			}
 		} else {
 			// コンポーネントがない場合は通常のコンテナとして扱う
-			for _, child := range node.Children {
-				r.renderNode(child, layout)
+			r.renderChildren(node, id, layoutResult)
+		}
+		
+	default:
+		// RegisterMeasurePolicy で登録されたノードタイプはコンテナとして子ノードをレンダリング
+		r.renderChildren(node, id, layoutResult)
+	}
+}
+
+// pushClip は描画を rect の内側に制限します
+// クリック可能な領域も同じ領域で切り取られます
+func (r *Renderer) pushClip(rect layout.Rect) {
+	if len(r.clips) > 0 {
+		rect = rect.Intersect(r.clips[len(r.clips)-1])
+	}
+	r.clips = append(r.clips, rect)
+	r.target.PushClip(rect)
+}
+
+// popClip は pushClip で設定したクリップ領域を解除します
+func (r *Renderer) popClip() {
+	if len(r.clips) > 0 {
+		r.clips = r.clips[:len(r.clips)-1]
+	}
+	r.target.PopClip()
+}
+
+// drawScrollbar は子ノードのレイアウトからスクロール量を求め、スクロールバーを描画します
+// 内容が表示領域に収まっている場合やターゲットが対応していない場合は描画しません
+func (r *Renderer) drawScrollbar(node *core.Node, id core.NodeID, viewport layout.Rect, layoutResult map[core.NodeID]layout.Rect) {
+	target, ok := r.target.(ScrollbarTarget)
+	if !ok {
+		return
+	}
+	
+	vertical := node.Type == core.VerticalScrollNodeType
+	mainRange := func(rect layout.Rect) (float64, float64) {
+		if vertical {
+			return rect.Position.Y, rect.Position.Y + rect.Size.Height
+		}
+		return rect.Position.X, rect.Position.X + rect.Size.Width
+	}
+	
+	// 内容の範囲は表示領域と子ノードの矩形を合わせた範囲とする
+	viewStart, viewEnd := mainRange(viewport)
+	contentStart, contentEnd := viewStart, viewEnd
+	for i, child := range node.Children {
+		if rect, exists := layoutResult[core.ChildNodeID(id, child.Key, i)]; exists {
+			start, end := mainRange(rect)
+			contentStart = min(contentStart, start)
+			contentEnd = max(contentEnd, end)
+		}
+	}
+	
+	viewSize := viewEnd - viewStart
+	total := contentEnd - contentStart
+	if viewSize <= 0 || total <= viewSize {
+		return
+	}
+	thumbLength := max(viewSize*viewSize/total, 1)
+	thumbStart := (viewSize - thumbLength) * (viewStart - contentStart) / (total - viewSize)
+	target.DrawScrollbar(viewport, vertical, thumbStart, thumbLength, node.Props)
+}
+
+// drawText はテキストをレイアウトと同じ規則で行に分割し、1行ずつ描画します
+// 矩形の高さに収まらない行は描画しません
+func (r *Renderer) drawText(node *core.Node, rect layout.Rect) {
+	text := node.Props.GetString("text", "")
+	textLayout := layout.LayoutText(text, node.Props, rect.Size.Width, r.layoutManager.TextMeasurer())
+	
+	bottom := rect.Position.Y + rect.Size.Height
+	for i, line := range textLayout.Lines {
+		top := rect.Position.Y + float64(i)*textLayout.LineHeight
+		if i > 0 && top+textLayout.LineHeight > bottom {
+			break
+		}
+		lineRect := layout.Rect{
+			Position: layout.Position{X: rect.Position.X, Y: top},
+			Size:     layout.Size{Width: rect.Size.Width, Height: textLayout.LineHeight},
+		}
+		r.target.DrawText(line, lineRect, node.Props)
+	}
+}
+
+// drawModifiers は描画用のモディファイアを外側から順に処理し、内側のコンテンツを描画する矩形を返します
+// 余白のモディファイアより後ろの要素は余白の内側の領域に対して描画されます
+func (r *Renderer) drawModifiers(elements []core.ModifierElement, rect layout.Rect) layout.Rect {
+	for _, element := range elements {
+		switch e := element.(type) {
+		case core.PaddingModifier:
+			rect = rect.Inset(e.Start, e.Top, e.End, e.Bottom)
+		case core.BackgroundModifier:
+			r.target.DrawRect(rect, core.Props{
+				"backgroundColor": e.Color,
+				"borderRadius":    e.CornerRadius,
+			})
+		case core.BorderModifier:
+			r.target.DrawRect(rect, core.Props{
+				"borderWidth":  e.Width,
+				"borderColor":  e.Color,
+				"borderRadius": e.CornerRadius,
+			})
+		case core.ClickableModifier:
+			region := rect
+			if len(r.clips) > 0 {
+				region = region.Intersect(r.clips[len(r.clips)-1])
 			}
+			r.clickables = append(r.clickables, clickRegion{rect: region, onClick: e.OnClick})
 		}
 	}
+	return rect
+}
+
+// Click は指定された座標にあるクリック可能な領域のうち、最前面のものの関数を呼び出します
+// 座標は直前の Render のレイアウトに基づいて判定され、呼び出した場合は true を返します
+func (r *Renderer) Click(x, y float64) bool {
+	position := layout.Position{X: x, Y: y}
+	for i := len(r.clickables) - 1; i >= 0; i-- {
+		region := r.clickables[i]
+		if region.rect.Contains(position) && region.onClick != nil {
+			region.onClick()
+			return true
+		}
+	}
+	return false
+}
+
+// renderChildren は子ノードを zIndex の小さい順にレンダリングします
+// zIndex が同じ子ノードはツリーの順に描画され、後に描画したものが手前に表示されます
+func (r *Renderer) renderChildren(node *core.Node, id core.NodeID, layout map[core.NodeID]layout.Rect) {
+	order := make([]int, len(node.Children))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return node.Children[order[a]].Props.GetFloat("zIndex", 0) < node.Children[order[b]].Props.GetFloat("zIndex", 0)
+	})
+	
+	for _, i := range order {
+		child := node.Children[i]
+		r.renderNode(child, core.ChildNodeID(id, child.Key, i), layout)
+	}
 }
 
 // ConsoleRenderTarget はコンソールへのレンダリングを行うターゲットです
@@ -97,16 +310,18 @@ func (r *Renderer) renderNode(node *core.Node, layout map[string]layout.Rect) {
 type ConsoleRenderTarget struct {
 	width  int
 	height int
-	buffer [][]rune
+	// buffer の各セルには書記素クラスタが入り、全角文字の右半分は空文字列になります
+	buffer [][]string
+	clips  clipStack
 }
 
 // NewConsoleRenderTarget は新しいコンソールレンダリングターゲットを作成します
 func NewConsoleRenderTarget(width, height int) *ConsoleRenderTarget {
-	buffer := make([][]rune, height)
+	buffer := make([][]string, height)
 	for i := range buffer {
-		buffer[i] = make([]rune, width)
+		buffer[i] = make([]string, width)
 		for j := range buffer[i] {
-			buffer[i][j] = ' '
+			buffer[i][j] = " "
 		}
 	}
 	
@@ -117,11 +332,17 @@ func NewConsoleRenderTarget(width, height int) *ConsoleRenderTarget {
 	}
 }
 
-// Clear はレンダリング領域をクリアします
+// TextMeasurer は端末のセル単位でテキストを計測する TerminalTextMeasurer を返します
+func (c *ConsoleRenderTarget) TextMeasurer() layout.TextMeasurer {
+	return layout.TerminalTextMeasurer{}
+}
+
+// Clear はレンダリング領域とクリップ領域をクリアします
 func (c *ConsoleRenderTarget) Clear() {
+	c.clips = nil
 	for i := range c.buffer {
 		for j := range c.buffer[i] {
-			c.buffer[i][j] = ' '
+			c.buffer[i][j] = " "
 		}
 	}
 }
@@ -150,40 +371,25 @@ func (c *ConsoleRenderTarget) DrawRect(rect layout.Rect, props core.Props) {
 	
 	// 上下の境界線
 	for x := x1; x <= x2; x++ {
-		if y1 >= 0 && y1 < c.height {
-			c.buffer[y1][x] = '-'
-		}
-		if y2 >= 0 && y2 < c.height {
-			c.buffer[y2][x] = '-'
-		}
+		c.set(x, y1, "-")
+		c.set(x, y2, "-")
 	}
 	
 	// 左右の境界線
 	for y := y1; y <= y2; y++ {
-		if x1 >= 0 && x1 < c.width {
-			c.buffer[y][x1] = '|'
-		}
-		if x2 >= 0 && x2 < c.width {
-			c.buffer[y][x2] = '|'
-		}
+		c.set(x1, y, "|")
+		c.set(x2, y, "|")
 	}
 	
 	// 角
-	if x1 >= 0 && y1 >= 0 && x1 < c.width && y1 < c.height {
-		c.buffer[y1][x1] = '+'
-	}
-	if x2 >= 0 && y1 >= 0 && x2 < c.width && y1 < c.height {
-		c.buffer[y1][x2] = '+'
-	}
-	if x1 >= 0 && y2 >= 0 && x1 < c.width && y2 < c.height {
-		c.buffer[y2][x1] = '+'
-	}
-	if x2 >= 0 && y2 >= 0 && x2 < c.width && y2 < c.height {
-		c.buffer[y2][x2] = '+'
-	}
+	c.set(x1, y1, "+")
+	c.set(x2, y1, "+")
+	c.set(x1, y2, "+")
+	c.set(x2, y2, "+")
 }
 
 // DrawText はテキストを描画します
+// 全角文字は2セルを占め、右端からはみ出す文字は描画しません
 func (c *ConsoleRenderTarget) DrawText(text string, rect layout.Rect, props core.Props) {
 	x := int(rect.Position.X)
 	y := int(rect.Position.Y)
@@ -193,19 +399,87 @@ func (c *ConsoleRenderTarget) DrawText(text string, rect layout.Rect, props core
 		return
 	}
 	
-	// テキストを描画
-	for i, char := range text {
-		posX := x + i
-		if posX < 0 || posX >= c.width {
+	// テキストを書記素クラスタごとに描画
+	for _, cluster := range layout.Graphemes(text) {
+		width := layout.GraphemeWidth(cluster)
+		if width == 0 {
 			continue
 		}
-		c.buffer[y][posX] = char
+		if c.writable(x, y) && c.writable(x+width-1, y) {
+			c.set(x, y, cluster)
+			for i := 1; i < width; i++ {
+				c.set(x+i, y, "")
+			}
+		}
+		x += width
+	}
+}
+
+// PushClip は以降の描画を rect の内側に制限します
+func (c *ConsoleRenderTarget) PushClip(rect layout.Rect) {
+	c.clips.push(rect)
+}
+
+// PopClip は直前の PushClip で設定したクリップ領域を解除します
+func (c *ConsoleRenderTarget) PopClip() {
+	c.clips.pop()
+}
+
+// DrawScrollbar は表示領域の右端（水平方向の場合は下端）に ASCII 文字でスクロールバーを描画します
+func (c *ConsoleRenderTarget) DrawScrollbar(viewport layout.Rect, vertical bool, thumbStart, thumbLength float64, props core.Props) {
+	x1 := int(viewport.Position.X)
+	y1 := int(viewport.Position.Y)
+	x2 := int(viewport.Position.X + viewport.Size.Width - 1)
+	y2 := int(viewport.Position.Y + viewport.Size.Height - 1)
+	start := int(math.Round(thumbStart))
+	end := start + max(int(math.Round(thumbLength)), 1)
+	
+	if vertical {
+		for y := y1; y <= y2; y++ {
+			if y-y1 >= start && y-y1 < end {
+				c.set(x2, y, "#")
+			} else {
+				c.set(x2, y, "|")
+			}
+		}
+		return
+	}
+	for x := x1; x <= x2; x++ {
+		if x-x1 >= start && x-x1 < end {
+			c.set(x, y2, "#")
+		} else {
+			c.set(x, y2, "-")
+		}
+	}
+}
+
+// writable は座標がバッファ内かつ現在のクリップ領域の内側にあるかを判定します
+func (c *ConsoleRenderTarget) writable(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.width && y < c.height && c.clips.contains(x, y)
+}
+
+// set はセルに文字を書き込みます
+// 全角文字の片側だけが上書きされる場合は、もう片側を空白に置き換えます
+func (c *ConsoleRenderTarget) set(x, y int, cluster string) {
+	if !c.writable(x, y) {
+		return
+	}
+	row := c.buffer[y]
+	if row[x] == "" && x > 0 && cluster != "" {
+		row[x-1] = " "
+	}
+	if row[x] != "" {
+		for i := x + 1; i < c.width && row[i] == ""; i++ {
+			row[i] = " "
+		}
 	}
+	row[x] = cluster
 }
 
 // Flush はレンダリング結果を出力します
 func (c *ConsoleRenderTarget) Flush() {
 	for _, line := range c.buffer {
-		println(string(line))
+		// 全角文字の右半分は空文字列なので、連結すると端末上の幅に一致する
+		println(strings.Join(line, ""))
 	}
 }
@@ -0,0 +1,417 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+// consoleLines はコンソールのバッファを行ごとの文字列で返します
+func consoleLines(c *ConsoleRenderTarget) []string {
+	lines := make([]string, len(c.buffer))
+	for i, row := range c.buffer {
+		lines[i] = strings.Join(row, "")
+	}
+	return lines
+}
+
+// renderConsole は width × height のコンソールにノードを描画します
+func renderConsole(root *core.Node, width, height int) (*Renderer, []string) {
+	target := NewConsoleRenderTarget(width, height)
+	renderer := NewRenderer(target)
+	target.Clear()
+	renderer.Render(root, layout.NewConstraints(0, 0, float64(width), float64(height)))
+	return renderer, consoleLines(target)
+}
+
+// textNode は Text ノードを作成します
+func textNode(key, text string, props core.Props) *core.Node {
+	merged := core.Props{"text": text}
+	for k, v := range props {
+		merged[k] = v
+	}
+	return core.NewNode(core.TextNodeType, key, merged)
+}
+
+func TestConsoleScrollbar(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodeType core.NodeType
+		count    int
+		offset   float64
+		props    core.Props
+		want     []string
+	}{
+		{
+			name:     "thumb at the start",
+			nodeType: core.VerticalScrollNodeType,
+			count:    6,
+			want:     []string{"l0   #", "l1   #", "l2   |"},
+		},
+		{
+			name:     "thumb follows the offset",
+			nodeType: core.VerticalScrollNodeType,
+			count:    6,
+			offset:   3,
+			want:     []string{"l3   |", "l4   |", "l5   #"},
+		},
+		{
+			name:     "no scrollbar when the content fits",
+			nodeType: core.VerticalScrollNodeType,
+			count:    2,
+			want:     []string{"l0    ", "l1    ", "      "},
+		},
+		{
+			name:     "scrollbar disabled",
+			nodeType: core.VerticalScrollNodeType,
+			count:    6,
+			props:    core.Props{"scrollbar": false},
+			want:     []string{"l0    ", "l1    ", "l2    "},
+		},
+		{
+			name:     "horizontal",
+			nodeType: core.HorizontalScrollNodeType,
+			count:    6,
+			offset:   2,
+			want:     []string{"l1l2l3", "      ", "-###--"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := core.Props{
+				"state":          layout.NewScrollState(tt.offset),
+				core.ModifierKey: core.NewModifier().FillMaxSize(1),
+			}
+			for k, v := range tt.props {
+				props[k] = v
+			}
+			scroll := core.NewNode(tt.nodeType, "scroll", props)
+			for i := 0; i < tt.count; i++ {
+				scroll.AddChild(textNode(fmt.Sprintf("l%d", i), fmt.Sprintf("l%d", i), nil))
+			}
+
+			_, got := renderConsole(scroll, 6, 3)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("output =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// stack は子ノードを重ねる Box を作成します
+func stack(props core.Props, children ...*core.Node) *core.Node {
+	node := core.NewNode(core.BoxNodeType, "box", props)
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	return node
+}
+
+// overflow は子ノードを幅の制限なしに測定し、自身は width の幅になる Layout ノードを作成します
+func overflow(width float64, props core.Props, children ...*core.Node) *core.Node {
+	policy := layout.MeasurePolicyFunc(func(measurables []layout.Measurable, constraints layout.Constraints) layout.MeasureResult {
+		placeables := make([]layout.Placeable, len(measurables))
+		height := 0.0
+		for i, child := range measurables {
+			placeables[i] = child.Measure(layout.Loose(layout.Size{Width: layout.Unbounded, Height: constraints.MaxSize.Height}))
+			height = max(height, placeables[i].Size().Height)
+		}
+		return layout.MeasureResult{
+			Size: layout.Size{Width: width, Height: height},
+			Place: func() {
+				for _, p := range placeables {
+					p.Place(0, 0)
+				}
+			},
+		}
+	})
+	merged := core.Props{layout.MeasurePolicyKey: policy}
+	for k, v := range props {
+		merged[k] = v
+	}
+	node := core.NewNode(core.LayoutNodeType, "overflow", merged)
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	return node
+}
+
+// row は子ノードを並べる Row を作成します
+func row(children ...*core.Node) *core.Node {
+	node := core.NewNode(core.RowNodeType, "row", core.Props{})
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	return node
+}
+
+func TestRendererClipToBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		root *core.Node
+		want string
+	}{
+		{
+			"overflow is drawn without clipping",
+			overflow(3, nil, textNode("t", "abcdef", nil)),
+			"abcdef",
+		},
+		{
+			"clipped to the node",
+			overflow(3, core.Props{"clipToBounds": true}, textNode("t", "abcdef", nil)),
+			"abc   ",
+		},
+		{
+			"nested clips intersect",
+			overflow(4, core.Props{"clipToBounds": true},
+				overflow(5, core.Props{"clipToBounds": true}, textNode("t", "abcdef", nil)),
+			),
+			"abcd  ",
+		},
+		{
+			"clip ends with the node",
+			row(
+				overflow(2, core.Props{"clipToBounds": true}, textNode("t", "abcdef", nil)),
+				textNode("u", "xy", nil),
+			),
+			"abxy  ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, lines := renderConsole(tt.root, 6, 1)
+			if lines[0] != tt.want {
+				t.Errorf("output = %q, want %q", lines[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestRendererZIndexOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		zIndex []float64
+		want   string
+	}{
+		{"tree order", []float64{0, 0, 0}, "CCC"},
+		{"raised first child", []float64{1, 0, 0}, "AAA"},
+		{"stable for equal z", []float64{1, 1, 0}, "BBB"},
+		{"negative z goes to the back", []float64{0, 0, -1}, "BBB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := stack(core.Props{},
+				textNode("a", "AAA", core.Props{"zIndex": tt.zIndex[0]}),
+				textNode("b", "BBB", core.Props{"zIndex": tt.zIndex[1]}),
+				textNode("c", "CCC", core.Props{"zIndex": tt.zIndex[2]}),
+			)
+			_, lines := renderConsole(root, 3, 1)
+			if lines[0] != tt.want {
+				t.Errorf("output = %q, want %q", lines[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestRendererClickRegions(t *testing.T) {
+	var clicked []string
+	clickable := func(name, text string, props core.Props) *core.Node {
+		node := textNode(name, text, props)
+		node.Props[core.ModifierKey] = core.NewModifier().Clickable(func() {
+			clicked = append(clicked, name)
+		})
+		return node
+	}
+
+	tests := []struct {
+		name string
+		root *core.Node
+		x    float64
+		want []string
+	}{
+		{
+			"inside the clip",
+			overflow(3, core.Props{"clipToBounds": true}, clickable("wide", "abcdef", nil)),
+			1,
+			[]string{"wide"},
+		},
+		{
+			"outside the clip",
+			overflow(3, core.Props{"clipToBounds": true}, clickable("wide", "abcdef", nil)),
+			4,
+			nil,
+		},
+		{
+			"outside without clipping",
+			overflow(3, nil, clickable("wide", "abcdef", nil)),
+			4,
+			[]string{"wide"},
+		},
+		{
+			"topmost by z index",
+			stack(core.Props{},
+				clickable("front", "AAA", core.Props{"zIndex": 1.0}),
+				clickable("back", "BBB", nil),
+			),
+			1,
+			[]string{"front"},
+		},
+		{
+			"topmost by tree order",
+			stack(core.Props{},
+				clickable("back", "AAA", nil),
+				clickable("front", "BBB", nil),
+			),
+			1,
+			[]string{"front"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clicked = nil
+			renderer, _ := renderConsole(tt.root, 6, 1)
+			hit := renderer.Click(tt.x, 0)
+			if hit != (len(tt.want) > 0) {
+				t.Errorf("Click = %v", hit)
+			}
+			if !reflect.DeepEqual(clicked, tt.want) {
+				t.Errorf("clicked = %v, want %v", clicked, tt.want)
+			}
+		})
+	}
+}
+
+func TestRendererCustomPolicyNode(t *testing.T) {
+	target := NewConsoleRenderTarget(6, 1)
+	renderer := NewRenderer(target)
+	renderer.LayoutManager().RegisterMeasurePolicy("Chip", func(core.Props) layout.MeasurePolicy {
+		return layout.ContainerPolicy{}
+	})
+
+	clicked := false
+	chip := core.NewNode("Chip", "chip", core.Props{
+		core.ModifierKey: core.NewModifier().PaddingSymmetric(1, 0).Clickable(func() { clicked = true }),
+	})
+	chip.AddChild(textNode("label", "hi", nil))
+
+	target.Clear()
+	renderer.Render(chip, layout.NewConstraints(0, 0, 6, 1))
+	if got := consoleLines(target)[0]; got != " hi   " {
+		t.Errorf("output = %q, want %q", got, " hi   ")
+	}
+	if !renderer.Click(2, 0) || !clicked {
+		t.Error("click inside the custom node was not handled")
+	}
+}
+
+// modifiedBox は子に "ab" の Text を持ち、モディファイアを設定した Box を Column の中に作成します
+func modifiedBox(modifier core.Modifier) *core.Node {
+	target := core.NewNode(core.BoxNodeType, "target", core.Props{core.ModifierKey: modifier})
+	target.AddChild(textNode("inner", "ab", nil))
+	root := core.NewNode(core.ColumnNodeType, "root", core.Props{})
+	root.AddChild(target)
+	return root
+}
+
+func TestRendererModifierOrderBackground(t *testing.T) {
+	tests := []struct {
+		name     string
+		modifier core.Modifier
+		want     []layout.Rect
+	}{
+		{
+			"padding then background fills inside the padding",
+			core.NewModifier().Padding(1).Background("#FFF"),
+			[]layout.Rect{rectAt(1, 1, 2, 1)},
+		},
+		{
+			"background then padding fills the padding too",
+			core.NewModifier().Background("#FFF").Padding(1),
+			[]layout.Rect{rectAt(0, 0, 4, 3)},
+		},
+		{
+			"backgrounds between paddings",
+			core.NewModifier().Background("#FFF").Padding(1).Background("#000").Padding(1),
+			[]layout.Rect{rectAt(0, 0, 6, 5), rectAt(1, 1, 4, 3)},
+		},
+		{
+			"stacked size and padding",
+			core.NewModifier().Padding(1).Size(4, 3).Background("#FFF").Padding(1),
+			[]layout.Rect{rectAt(1, 1, 4, 3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []layout.Rect{}
+			for _, op := range record(modifiedBox(tt.modifier)).Ops {
+				if op.Kind == OpDrawRect && op.Props["backgroundColor"] != nil {
+					got = append(got, op.Rect)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("background rects = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRendererModifierOrderClickable(t *testing.T) {
+	tests := []struct {
+		name     string
+		modifier func(onClick func()) core.Modifier
+		hits     []layout.Position
+		misses   []layout.Position
+	}{
+		{
+			name: "padding then clickable excludes the padding",
+			modifier: func(onClick func()) core.Modifier {
+				return core.NewModifier().Padding(1).Clickable(onClick)
+			},
+			hits:   []layout.Position{{X: 1, Y: 1}, {X: 2, Y: 1}},
+			misses: []layout.Position{{X: 0, Y: 0}, {X: 3, Y: 1}, {X: 1, Y: 2}},
+		},
+		{
+			name: "clickable then padding includes the padding",
+			modifier: func(onClick func()) core.Modifier {
+				return core.NewModifier().Clickable(onClick).Padding(1)
+			},
+			hits:   []layout.Position{{X: 0, Y: 0}, {X: 3, Y: 2}, {X: 1, Y: 1}},
+			misses: []layout.Position{{X: 4, Y: 0}, {X: 0, Y: 3}},
+		},
+		{
+			name: "stacked size and padding",
+			modifier: func(onClick func()) core.Modifier {
+				return core.NewModifier().Padding(1).Size(4, 3).Clickable(onClick)
+			},
+			hits:   []layout.Position{{X: 1, Y: 1}, {X: 4, Y: 3}},
+			misses: []layout.Position{{X: 0, Y: 0}, {X: 5, Y: 1}, {X: 1, Y: 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clicks := 0
+			renderer, _ := renderConsole(modifiedBox(tt.modifier(func() { clicks++ })), 8, 6)
+			for _, p := range tt.hits {
+				clicks = 0
+				if !renderer.Click(p.X, p.Y) || clicks != 1 {
+					t.Errorf("click at (%v, %v) was not handled", p.X, p.Y)
+				}
+			}
+			for _, p := range tt.misses {
+				if renderer.Click(p.X, p.Y) {
+					t.Errorf("click at (%v, %v) was handled", p.X, p.Y)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+const (
+	// svgDefaultFontSize は fontSize が指定されていないテキストの大きさです（FontTextMeasurer と同じ値）
+	svgDefaultFontSize = 16.0
+	// svgScrollbarThickness はスクロールバーの太さです
+	svgScrollbarThickness = 4.0
+)
+
+// SVGRenderTarget は描画結果を単独の SVG 文書として io.Writer に出力するターゲットです
+//
+// 座標はピクセル単位で、テキストのサイズは既定の FontTextMeasurer で計算されます。
+// DrawRect は backgroundColor を塗り、borderWidth が正の場合は borderColor の枠線を
+// 矩形の内側に描き、borderRadius で角を丸めます。
+// DrawText は color、fontSize、textAlign を反映した text 要素を出力します。
+// クリップ領域は clipPath を参照する g 要素として入れ子にします。
+type SVGRenderTarget struct {
+	writer io.Writer
+	width  float64
+	height float64
+	defs   strings.Builder
+	body   strings.Builder
+	clips  int
+	nextID int
+	err    error
+}
+
+// NewSVGRenderTarget は width × height の SVG を出力するレンダリングターゲットを作成します
+func NewSVGRenderTarget(writer io.Writer, width, height float64) *SVGRenderTarget {
+	return &SVGRenderTarget{
+		writer: writer,
+		width:  width,
+		height: height,
+	}
+}
+
+// Clear は描画した要素とクリップ領域を破棄します
+func (s *SVGRenderTarget) Clear() {
+	s.defs.Reset()
+	s.body.Reset()
+	s.clips = 0
+	s.nextID = 0
+}
+
+// DrawRect は矩形を rect 要素として描画します
+func (s *SVGRenderTarget) DrawRect(rect layout.Rect, props core.Props) {
+	fill := "none"
+	if background := colorProp(props, "backgroundColor"); background.Valid {
+		fill = background.Hex()
+	}
+	radius := props.GetFloat("borderRadius", 0)
+
+	if fill != "none" {
+		s.writeRect(rect, radius, fmt.Sprintf(`fill="%s"`, fill))
+	}
+
+	borderWidth := props.GetFloat("borderWidth", 0)
+	if borderWidth <= 0 {
+		return
+	}
+	stroke := "#000000"
+	if color := colorProp(props, "borderColor"); color.Valid {
+		stroke = color.Hex()
+	}
+	// 枠線は CSS の border と同じく矩形の内側に収める
+	inner := rect.Inset(borderWidth/2, borderWidth/2, borderWidth/2, borderWidth/2)
+	s.writeRect(inner, max(radius-borderWidth/2, 0), fmt.Sprintf(
+		`fill="none" stroke="%s" stroke-width="%s"`, stroke, svgNumber(borderWidth),
+	))
+}
+
+// DrawText はテキストを1行の text 要素として描画します
+// 行の矩形の中央を基準線とし、textAlign に応じて左端・中央・右端に揃えます
+func (s *SVGRenderTarget) DrawText(text string, rect layout.Rect, props core.Props) {
+	fill := "#000000"
+	if color := colorProp(props, "color"); color.Valid {
+		fill = color.Hex()
+	}
+	fontSize := props.GetFloat("fontSize", svgDefaultFontSize)
+
+	x, anchor := rect.Position.X, "start"
+	switch props.GetString("textAlign", "start") {
+	case "center":
+		x, anchor = rect.Position.X+rect.Size.Width/2, "middle"
+	case "end", "right":
+		x, anchor = rect.Position.X+rect.Size.Width, "end"
+	}
+	y := rect.Position.Y + rect.Size.Height/2
+
+	fmt.Fprintf(&s.body,
+		`<text x="%s" y="%s" font-size="%s" fill="%s" text-anchor="%s" dominant-baseline="central" xml:space="preserve">`,
+		svgNumber(x), svgNumber(y), svgNumber(fontSize), fill, anchor,
+	)
+	xml.EscapeText(&s.body, []byte(text))
+	s.body.WriteString("</text>\n")
+}
+
+// PushClip は rect の clipPath を定義し、以降の要素をそれを参照する g 要素で囲みます
+func (s *SVGRenderTarget) PushClip(rect layout.Rect) {
+	s.nextID++
+	id := "clip" + strconv.Itoa(s.nextID)
+	fmt.Fprintf(&s.defs, `<clipPath id="%s">`, id)
+	s.defs.WriteString(svgRectElement(rect, 0, ""))
+	s.defs.WriteString("</clipPath>\n")
+
+	fmt.Fprintf(&s.body, "<g clip-path=\"url(#%s)\">\n", id)
+	s.clips++
+}
+
+// PopClip は直前の PushClip で開いた g 要素を閉じます
+func (s *SVGRenderTarget) PopClip() {
+	if s.clips == 0 {
+		return
+	}
+	s.body.WriteString("</g>\n")
+	s.clips--
+}
+
+// DrawScrollbar は表示領域の右端（水平方向の場合は下端）に細い矩形でスクロールバーを描画します
+// scrollbarColor でつまみ、scrollbarTrackColor でトラックの色を指定します
+func (s *SVGRenderTarget) DrawScrollbar(viewport layout.Rect, vertical bool, thumbStart, thumbLength float64, props core.Props) {
+	trackColor := Color{R: 0xE0, G: 0xE0, B: 0xE0, Valid: true}
+	if color := colorProp(props, "scrollbarTrackColor"); color.Valid {
+		trackColor = color
+	}
+	thumbColor := Color{R: 0x80, G: 0x80, B: 0x80, Valid: true}
+	if color := colorProp(props, "scrollbarColor"); color.Valid {
+		thumbColor = color
+	}
+
+	track, thumb := viewport, viewport
+	if vertical {
+		track.Position.X += viewport.Size.Width - svgScrollbarThickness
+		track.Size.Width = svgScrollbarThickness
+		thumb = track
+		thumb.Position.Y += thumbStart
+		thumb.Size.Height = thumbLength
+	} else {
+		track.Position.Y += viewport.Size.Height - svgScrollbarThickness
+		track.Size.Height = svgScrollbarThickness
+		thumb = track
+		thumb.Position.X += thumbStart
+		thumb.Size.Width = thumbLength
+	}
+	s.writeRect(track, 0, fmt.Sprintf(`fill="%s"`, trackColor.Hex()))
+	s.writeRect(thumb, svgScrollbarThickness/2, fmt.Sprintf(`fill="%s"`, thumbColor.Hex()))
+}
+
+// Flush は描画した要素を SVG 文書として io.Writer に出力します
+// 閉じられていないクリップ領域は閉じてから出力し、書き込みエラーは Err で取得できます
+func (s *SVGRenderTarget) Flush() {
+	for s.clips > 0 {
+		s.PopClip()
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder,
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\" font-family=\"monospace\">\n",
+		svgNumber(s.width), svgNumber(s.height), svgNumber(s.width), svgNumber(s.height),
+	)
+	if s.defs.Len() > 0 {
+		builder.WriteString("<defs>\n")
+		builder.WriteString(s.defs.String())
+		builder.WriteString("</defs>\n")
+	}
+	builder.WriteString(s.body.String())
+	builder.WriteString("</svg>\n")
+
+	_, s.err = io.WriteString(s.writer, builder.String())
+}
+
+// Err は直前の Flush で発生した書き込みエラーを返します
+func (s *SVGRenderTarget) Err() error {
+	return s.err
+}
+
+// writeRect は rect 要素を本体に追加します
+func (s *SVGRenderTarget) writeRect(rect layout.Rect, radius float64, attributes string) {
+	s.body.WriteString(svgRectElement(rect, radius, attributes))
+	s.body.WriteString("\n")
+}
+
+// svgRectElement は矩形の rect 要素を返します（radius が正の場合は角を丸めます）
+func svgRectElement(rect layout.Rect, radius float64, attributes string) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<rect x="%s" y="%s" width="%s" height="%s"`,
+		svgNumber(rect.Position.X), svgNumber(rect.Position.Y),
+		svgNumber(rect.Size.Width), svgNumber(rect.Size.Height),
+	)
+	if radius > 0 {
+		fmt.Fprintf(&builder, ` rx="%s" ry="%s"`, svgNumber(radius), svgNumber(radius))
+	}
+	if attributes != "" {
+		builder.WriteString(" ")
+		builder.WriteString(attributes)
+	}
+	builder.WriteString("/>")
+	return builder.String()
+}
+
+// svgNumber は数値を小数点以下3桁に丸め、属性値として最短の表記で返します
+// 丸めることで浮動小数点の誤差によって出力が変わらないようにします
+func svgNumber(value float64) string {
+	rounded := math.Round(value*1000) / 1000
+	if rounded == 0 {
+		// -0 を "0" と出力する
+		rounded = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
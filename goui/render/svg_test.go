@@ -0,0 +1,183 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
+)
+
+var update = flag.Bool("update", false, "testdata のゴールデンファイルを更新します")
+
+// rectAt は位置とサイズから矩形を作成します
+func rectAt(x, y, width, height float64) layout.Rect {
+	return layout.Rect{
+		Position: layout.Position{X: x, Y: y},
+		Size:     layout.Size{Width: width, Height: height},
+	}
+}
+
+// svgCases は testdata の名前と、その SVG を描画する処理です
+// rendered が true の処理は Renderer を通して描画し、Flush も Renderer が呼び出します
+var svgCases = []struct {
+	name     string
+	rendered bool
+	draw     func(target *SVGRenderTarget)
+}{
+	{
+		name: "rect",
+		draw: func(target *SVGRenderTarget) {
+			target.DrawRect(rectAt(10, 10, 80, 40), core.Props{"backgroundColor": "#3366CC"})
+			target.DrawRect(rectAt(100, 10, 80, 40), core.Props{
+				"backgroundColor": "#FFFFFF",
+				"borderWidth":     4.0,
+				"borderColor":     "#FF0000",
+			})
+			target.DrawRect(rectAt(10, 60, 80, 40), core.Props{
+				"backgroundColor": "#00AA00",
+				"borderRadius":    8.0,
+			})
+			target.DrawRect(rectAt(100, 60, 80, 40), core.Props{
+				"borderWidth":  2.0,
+				"borderRadius": 8.0,
+			})
+			// 枠線の幅が角の半径より大きい場合は内側の角を丸めない
+			target.DrawRect(rectAt(190, 60, 40, 40), core.Props{
+				"borderWidth":  10.0,
+				"borderColor":  "#000080",
+				"borderRadius": 4.0,
+			})
+		},
+	},
+	{
+		name: "text",
+		draw: func(target *SVGRenderTarget) {
+			target.DrawText("default", rectAt(0, 0, 200, 20), core.Props{})
+			target.DrawText("red 24", rectAt(0, 20, 200, 30), core.Props{"color": "#FF0000", "fontSize": 24.0})
+			target.DrawText("center", rectAt(0, 50, 200, 20), core.Props{"textAlign": "center"})
+			target.DrawText("end", rectAt(0, 70, 200, 20), core.Props{"textAlign": "end"})
+			target.DrawText("<a & b>", rectAt(0, 90, 200, 20), core.Props{"textAlign": "right", "color": "#008000"})
+		},
+	},
+	{
+		name: "clip",
+		draw: func(target *SVGRenderTarget) {
+			target.PushClip(rectAt(0, 0, 100, 100))
+			target.DrawRect(rectAt(-10, -10, 50, 50), core.Props{"backgroundColor": "#FF0000"})
+			target.PushClip(rectAt(20, 20, 60, 60))
+			target.DrawText("nested", rectAt(20, 20, 100, 20), core.Props{})
+			target.PopClip()
+			target.DrawRect(rectAt(60, 60, 60, 60), core.Props{"backgroundColor": "#0000FF"})
+			target.PopClip()
+			// Flush で閉じられるクリップ領域
+			target.PushClip(rectAt(100, 0, 20, 20))
+			target.DrawRect(rectAt(100, 0, 40, 40), core.Props{"backgroundColor": "#00FF00"})
+		},
+	},
+	{
+		name: "scrollbar",
+		draw: func(target *SVGRenderTarget) {
+			target.DrawScrollbar(rectAt(0, 0, 100, 80), true, 20, 30.5, core.Props{})
+			target.DrawScrollbar(rectAt(0, 90, 100, 30), false, 0, 40, core.Props{
+				"scrollbarColor":      "#FF8800",
+				"scrollbarTrackColor": "#222222",
+			})
+		},
+	},
+	{
+		name:     "renderer",
+		rendered: true,
+		draw: func(target *SVGRenderTarget) {
+			root := core.NewNode(core.BoxNodeType, "root", core.Props{
+				"width":           240.0,
+				"height":          120.0,
+				"backgroundColor": "#F0F0F0",
+				"borderWidth":     1.0,
+				"borderRadius":    6.0,
+			})
+			column := core.NewNode(core.ColumnNodeType, "column", core.Props{"padding": 8.0})
+			column.AddChild(textNode("title", "Hello, SVG", core.Props{"color": "#202020", "fontSize": 16.0}))
+			clipped := overflow(60, core.Props{"clipToBounds": true}, textNode("long", "clipped text", nil))
+			column.AddChild(clipped)
+			root.AddChild(column)
+			NewRenderer(target).Render(root, layout.NewConstraints(0, 0, 240, 120))
+		},
+	},
+}
+
+// renderSVG は新しいターゲットに描画した SVG 出力を返します
+func renderSVG(t *testing.T, draw func(target *SVGRenderTarget), rendered bool) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	target := NewSVGRenderTarget(&out, 240, 120)
+	draw(target)
+	if !rendered {
+		target.Flush()
+	}
+	if err := target.Err(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestSVGRenderTargetGolden(t *testing.T) {
+	for _, tt := range svgCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderSVG(t, tt.draw, tt.rendered)
+			path := filepath.Join("testdata", tt.name+".svg")
+			if *update {
+				if err := os.MkdirAll("testdata", 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+func TestSVGRenderTargetIsDeterministic(t *testing.T) {
+	for _, tt := range svgCases {
+		t.Run(tt.name, func(t *testing.T) {
+			first := renderSVG(t, tt.draw, tt.rendered)
+			for i := 0; i < 5; i++ {
+				if got := renderSVG(t, tt.draw, tt.rendered); !bytes.Equal(got, first) {
+					t.Fatalf("run %d differs from the first run\ngot:\n%s\nfirst:\n%s", i+2, got, first)
+				}
+			}
+		})
+	}
+}
+
+func TestSVGRenderTargetReusedAfterClear(t *testing.T) {
+	// 同じターゲットで描画し直しても前のフレームの要素やクリップの ID が残らない
+	for _, tt := range svgCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			target := NewSVGRenderTarget(&out, 240, 120)
+			for i := 0; i < 2; i++ {
+				out.Reset()
+				target.Clear()
+				tt.draw(target)
+				if !tt.rendered {
+					target.Flush()
+				}
+			}
+			if want := renderSVG(t, tt.draw, tt.rendered); !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("second frame differs from a fresh target\ngot:\n%s\nwant:\n%s", out.Bytes(), want)
+			}
+		})
+	}
+}
@@ -1,11 +1,24 @@
+// Package widgets は基本的なウィジェットを提供します
+//
+// すべてのウィジェットは Props の core.ModifierKey（"modifier"）に
+// core.Modifier を受け取り、レイアウトと描画に適用します。
+//
+//	widgets.Text("title", "Hello", core.Props{
+//		core.ModifierKey: core.NewModifier().Padding(1).Background("#E0E0E0"),
+//	})
+//
+// また、すべてのウィジェットは padding と margin のプロパティを受け取ります。
+// paddingStart、paddingTop、paddingEnd、paddingBottom のように辺ごとに指定することもできます。
 package widgets
 
 import (
 	"fmt"
 	"github.com/tak/goui/core"
+	"github.com/tak/goui/layout"
 )
 
 // Text はテキストウィジェットを作成します
+// softWrap、maxLines、overflow（"clip"、"ellipsis"、"fade"）で折り返しと省略を指定できます
 func Text(key string, text string, props core.Props) *core.Node {
 	// プロパティの設定
 	mergedProps := core.Props{
@@ -20,7 +33,9 @@ func Text(key string, text string, props core.Props) *core.Node {
 	return core.NewNode(core.TextNodeType, key, mergedProps)
 }
 
-// Box はボックスウィジェットを作成します
+// Box は子ノードを重ねて配置するボックスウィジェットを作成します
+// contentAlignment（core.TopStart … core.BottomEnd）で子ノードの位置を指定し、
+// 子ノードの Modifier.Align で個別に上書きできます。後の子ノードほど手前に描画されます
 func Box(key string, props core.Props, children ...*core.Node) *core.Node {
 	node := core.NewNode(core.BoxNodeType, key, props)
 	
@@ -33,6 +48,8 @@ func Box(key string, props core.Props, children ...*core.Node) *core.Node {
 }
 
 // Row は水平方向に子ノードを配置するウィジェットを作成します
+// horizontalArrangement（core.Arrangement）と verticalAlignment（core.Alignment）で配置を指定し、
+// 子ノードの Modifier.Weight で残りの幅を分配できます
 func Row(key string, props core.Props, children ...*core.Node) *core.Node {
 	node := core.NewNode(core.RowNodeType, key, props)
 	
@@ -45,6 +62,8 @@ func Row(key string, props core.Props, children ...*core.Node) *core.Node {
 }
 
 // Column は垂直方向に子ノードを配置するウィジェットを作成します
+// verticalArrangement（core.Arrangement）と horizontalAlignment（core.Alignment）で配置を指定し、
+// 子ノードの Modifier.Weight で残りの高さを分配できます
 func Column(key string, props core.Props, children ...*core.Node) *core.Node {
 	node := core.NewNode(core.ColumnNodeType, key, props)
 	
@@ -56,6 +75,153 @@ func Column(key string, props core.Props, children ...*core.Node) *core.Node {
 	return node
 }
 
+// FlowRow は子ノードを水平方向に並べ、幅に収まらない場合は次の行に折り返すウィジェットを作成します
+// spacing で子ノードの間隔、crossAxisSpacing で行の間隔、maxItemsPerLine で1行の最大数を指定します
+// タグの一覧やチップのグループに使用します
+func FlowRow(key string, props core.Props, children ...*core.Node) *core.Node {
+	node := core.NewNode(core.FlowRowNodeType, key, props)
+	
+	// 子ノードを追加
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	
+	return node
+}
+
+// FlowColumn は子ノードを垂直方向に並べ、高さに収まらない場合は次の列に折り返すウィジェットを作成します
+// spacing で子ノードの間隔、crossAxisSpacing で列の間隔、maxItemsPerLine で1列の最大数を指定します
+func FlowColumn(key string, props core.Props, children ...*core.Node) *core.Node {
+	node := core.NewNode(core.FlowColumnNodeType, key, props)
+	
+	// 子ノードを追加
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	
+	return node
+}
+
+// Grid は子ノードを行と列のトラックで区切られたセルに配置するウィジェットを作成します
+// columns と rows に []layout.Track で列と行の定義（FixedTrack、FractionTrack、AdaptiveTrack、AutoTrack）を、
+// gap（columnGap、rowGap）で間隔を、cellAlignment でセルの中での配置を指定します
+// 子ノードのセルは GridCell、複数のセルにまたがる場合は GridSpan モディファイアで指定します
+func Grid(key string, props core.Props, children ...*core.Node) *core.Node {
+	node := core.NewNode(core.GridNodeType, key, props)
+	
+	// 子ノードを追加
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	
+	return node
+}
+
+// ConstraintLayout は子ノードの間の関係を ConstraintSet の制約で指定して配置するウィジェットを作成します
+// 子ノードは LayoutID モディファイアの ID で ConstraintSet の参照と対応付けられます
+func ConstraintLayout(key string, set *layout.ConstraintSet, props core.Props, children ...*core.Node) *core.Node {
+	// プロパティの設定
+	mergedProps := core.Props{
+		layout.ConstraintSetKey: set,
+	}
+	
+	// 追加のプロパティをマージ
+	for k, v := range props {
+		mergedProps[k] = v
+	}
+	
+	node := core.NewNode(core.ConstraintLayoutNodeType, key, mergedProps)
+	
+	// 子ノードを追加
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	
+	return node
+}
+
+// VerticalScroll は子ノードを Column と同じように並べ、高さを超えた分を垂直方向にスクロールして表示するウィジェットを作成します
+// state（*layout.ScrollState）でスクロール位置を指定し、scrollbar を false にするとスクロールバーを表示しません
+// 表示領域の外にはみ出した部分は、クリップに対応したレンダリングターゲットでは描画されません
+func VerticalScroll(key string, props core.Props, children ...*core.Node) *core.Node {
+	node := core.NewNode(core.VerticalScrollNodeType, key, props)
+	
+	// 子ノードを追加
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	
+	return node
+}
+
+// HorizontalScroll は子ノードを Row と同じように並べ、幅を超えた分を水平方向にスクロールして表示するウィジェットを作成します
+// プロパティは VerticalScroll と同じです
+func HorizontalScroll(key string, props core.Props, children ...*core.Node) *core.Node {
+	node := core.NewNode(core.HorizontalScrollNodeType, key, props)
+	
+	// 子ノードを追加
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	
+	return node
+}
+
+// LazyColumn は itemCount 個の項目を垂直方向に並べ、表示範囲の項目だけを itemContent で構成するウィジェットを作成します
+// state（*layout.LazyListState）でスクロール位置を、itemKey（func(int) string）で項目の安定したキーを、
+// stickyHeader（func(int) bool）で先頭に固定するヘッダーの項目を指定します
+// contentPadding、spacing、horizontalAlignment、prefetch（表示範囲の前後に構成する項目数）も指定できます
+// 項目は Composer でレイアウトの中で構成され、表示範囲から外れると項目の中で保持した状態は破棄されます
+func LazyColumn(key string, itemCount int, itemContent func(index int) *core.Node, props core.Props) *core.Node {
+	return lazyList(core.LazyColumnNodeType, key, itemCount, itemContent, props)
+}
+
+// LazyRow は itemCount 個の項目を水平方向に並べ、表示範囲の項目だけを itemContent で構成するウィジェットを作成します
+// プロパティは LazyColumn と同じで、交差軸方向の配置は verticalAlignment で指定します
+func LazyRow(key string, itemCount int, itemContent func(index int) *core.Node, props core.Props) *core.Node {
+	return lazyList(core.LazyRowNodeType, key, itemCount, itemContent, props)
+}
+
+// lazyList は遅延リストのノードを作成します
+// 子ノードはレイアウトのたびに itemContent から作られるため、ここでは追加しません
+func lazyList(nodeType core.NodeType, key string, itemCount int, itemContent func(index int) *core.Node, props core.Props) *core.Node {
+	// プロパティの設定
+	mergedProps := core.Props{
+		"itemCount":   itemCount,
+		"itemContent": itemContent,
+	}
+	
+	// 追加のプロパティをマージ
+	for k, v := range props {
+		mergedProps[k] = v
+	}
+	
+	return core.NewNode(nodeType, key, mergedProps)
+}
+
+// Layout は MeasurePolicy で子ノードを測定・配置するウィジェットを作成します
+// 組み込みの Row や Column では表現できない独自のレイアウトに使用します
+func Layout(key string, policy layout.MeasurePolicy, props core.Props, children ...*core.Node) *core.Node {
+	// プロパティの設定
+	mergedProps := core.Props{
+		layout.MeasurePolicyKey: policy,
+	}
+	
+	// 追加のプロパティをマージ
+	for k, v := range props {
+		mergedProps[k] = v
+	}
+	
+	node := core.NewNode(core.LayoutNodeType, key, mergedProps)
+	
+	// 子ノードを追加
+	for _, child := range children {
+		node.AddChild(child)
+	}
+	
+	return node
+}
+
 // Container は単一の子ノードを持つコンテナウィジェットを作成します
 func Container(key string, props core.Props, child *core.Node) *core.Node {
 	node := core.NewNode(core.ContainerNodeType, key, props)
@@ -85,11 +251,20 @@ func Button(key string, label string, onClick func(), props core.Props) *core.No
 	buttonComponent := core.NewFunctionComponent(func(props core.Props) *core.Node {
 		label := props.GetString("label", "Button")
 		
+		// クリック処理はボタンの領域全体に設定
+		modifier := core.NewModifier()
+		if value, ok := props.Get("onClick"); ok {
+			if handler, ok := value.(func()); ok && handler != nil {
+				modifier = modifier.Clickable(handler)
+			}
+		}
+		
 		// ボタンの基本構造を作成
 		buttonNode := Box(fmt.Sprintf("%s-box", key), core.Props{
 			"padding":       8.0,
 			"borderRadius":  4.0,
-			"backgroundColor": "#2196F3",
+			"backgroundColor": props.GetString("backgroundColor", "#2196F3"),
+			core.ModifierKey: modifier,
 		}, 
 			Text(fmt.Sprintf("%s-text", key), label, core.Props{
 				"color":     "#FFFFFF",
@@ -164,7 +339,10 @@ func Image(key string, source string, props core.Props) *core.Node {
 	// 画像はカスタムコンポーネントとして実装
 	imageComponent := core.NewFunctionComponent(func(props core.Props) *core.Node {
 		// 実際のレンダリングではここで画像を読み込む処理が必要
-		return Box(fmt.Sprintf("%s-box", key), props)
+		// モディファイアは外側の画像ノードで適用されるため内側には渡さない
+		boxProps := props.Clone()
+		delete(boxProps, core.ModifierKey)
+		return Box(fmt.Sprintf("%s-box", key), boxProps)
 	})
 	
 	// 画像ノードを作成
@@ -175,11 +353,22 @@ func Image(key string, source string, props core.Props) *core.Node {
 }
 
 // Spacer は指定されたサイズのスペースを作成します
-func Spacer(key string, width, height float64) *core.Node {
-	return Box(key, core.Props{
+func Spacer(key string, width, height float64, modifiers ...core.Modifier) *core.Node {
+	props := core.Props{
 		"width":  width,
 		"height": height,
-	})
+	}
+	
+	// モディファイアを連結
+	if len(modifiers) > 0 {
+		modifier := core.NewModifier()
+		for _, m := range modifiers {
+			modifier = modifier.Then(m)
+		}
+		props[core.ModifierKey] = modifier
+	}
+	
+	return Box(key, props)
 }
 
 // Divider は区切り線を作成します
@@ -194,11 +383,17 @@ func Divider(key string, isHorizontal bool, props core.Props) *core.Node {
 		mergedProps[k] = v
 	}
 	
+	// 区切り線は交差方向に親の最大サイズいっぱいまで広げる
+	// Row の中では Row に IntrinsicHeight(core.IntrinsicMin) を指定すると、内容の高さに揃う
+	fill := core.NewModifier()
 	if isHorizontal {
 		mergedProps["height"] = 1.0
+		fill = fill.FillMaxWidth(1)
 	} else {
 		mergedProps["width"] = 1.0
+		fill = fill.FillMaxHeight(1)
 	}
+	mergedProps[core.ModifierKey] = fill.Then(mergedProps.GetModifier())
 	
 	return Box(key, mergedProps)
 }